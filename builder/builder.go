@@ -3,8 +3,11 @@ package builder
 import (
 	"encoding/xml"
 	"fmt"
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/jsando/jb/maven"
 	"github.com/jsando/jb/project"
+	"github.com/pterm/pterm"
+	"os"
 	"strings"
 )
 
@@ -30,6 +33,9 @@ func newModuleBuilder(path string, logger project.BuildLog) (*moduleBuilder, err
 		return nil, fmt.Errorf("error loading '%s': %w", path, err)
 	}
 	builder.project = project
+	if err := builder.builder.repo.SetRepositories(project.Repositories); err != nil {
+		return nil, fmt.Errorf("invalid repositories in project '%s': %w", project.Name, err)
+	}
 	if module != nil {
 		builder.buildModules = append(builder.buildModules, module)
 	} else {
@@ -92,22 +98,138 @@ func BuildAndRunModule(path string, args []string) error {
 	return builder.builder.Run(builder.buildModules[0], args)
 }
 
-func BuildAndPublishModule(path string) error {
+// PublishOptions controls where published artifacts go and whether they are signed.
+type PublishOptions struct {
+	Repo    string        // name or url of a remote repository, empty for the local repository
+	Signer  *maven.Signer // signs every published file if not nil
+	Central bool          // check the modules meet the requirements of maven central before building
+	M2      bool          // install into maven's local repository instead of jb's
+}
+
+// BuildAndPublishModule builds the modules at path and publishes them.
+func BuildAndPublishModule(path string, options PublishOptions) error {
 	logger := NewBuildLog()
 	builder, err := newModuleBuilder(path, logger)
 	if err != nil {
 		return err
 	}
+	var remote *maven.Remote
+	if options.Repo != "" {
+		remote, err = builder.builder.repo.FindRemote(options.Repo)
+		if err != nil {
+			return err
+		}
+	}
+	builder.builder.SetSigner(options.Signer)
+	builder.builder.SetPublishMavenLocal(options.M2)
+	if options.Central {
+		for _, module := range builder.buildModules {
+			if err := builder.builder.ValidateForCentral(module); err != nil {
+				return err
+			}
+		}
+	}
 	builder.Build()
 	logger.BuildFinish()
 	for _, module := range builder.buildModules {
-		if err := builder.builder.Publish(module, "", "", ""); err != nil {
+		if err := builder.builder.Publish(module, remote); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// LockModules writes a lock file for each module found at path. Existing lock files are only
+// verified against the module files and the local repository unless update is set, in which
+// case the graph is resolved again and the lock file is rewritten.
+func LockModules(path string, update bool) error {
+	logger := NewBuildLog()
+	builder, err := newModuleBuilder(path, logger)
+	if err != nil {
+		return err
+	}
+	for _, module := range builder.buildModules {
+		logger.ModuleStart(module.Name)
+		if module.Lock != nil && !update {
+			task := logger.TaskStart("verifying " + project.LockFilename)
+			_, err := builder.builder.ResolveModule(module)
+			task.Done(err)
+			continue
+		}
+		task := logger.TaskStart("writing " + project.LockFilename)
+		task.Done(builder.builder.LockModule(module))
+	}
+	logger.BuildFinish()
+	return nil
+}
+
+// FetchDependencies downloads every dependency of the modules found at path into the local
+// repository, so that they can later be built offline.
+func FetchDependencies(path string) error {
+	logger := NewBuildLog()
+	builder, err := newModuleBuilder(path, logger)
+	if err != nil {
+		return err
+	}
+	if builder.builder.repo.Offline() {
+		return fmt.Errorf("can't fetch dependencies in offline mode")
+	}
+	for _, module := range builder.buildModules {
+		logger.ModuleStart(module.Name)
+		task := logger.TaskStart("fetching dependencies")
+		_, err := builder.builder.ResolveModule(module)
+		task.Done(err)
+	}
+	logger.BuildFinish()
+	return nil
+}
+
+// PrintDependencyTree prints the resolved dependency graph of each module found at path, as
+// text or in the "json" or "dot" format.
+func PrintDependencyTree(path, format string) error {
+	logger := newReportLog()
+	builder, err := newModuleBuilder(path, logger)
+	if err != nil {
+		return err
+	}
+	trees := make([]*ModuleTree, 0, len(builder.buildModules))
+	for _, module := range builder.buildModules {
+		tree, err := builder.builder.DependencyTree(module)
+		if err != nil {
+			return fmt.Errorf("failed to resolve dependencies of module %s: %w", module.Name, err)
+		}
+		trees = append(trees, tree)
+	}
+	return WriteDependencyTrees(os.Stdout, trees, format)
+}
+
+// ReportOutdatedDependencies prints the newer versions available for the direct dependencies
+// of each module found at path, as a table or as JSON, and returns whether any are outdated.
+func ReportOutdatedDependencies(path, format string, preReleases bool) (bool, error) {
+	logger := newReportLog()
+	builder, err := newModuleBuilder(path, logger)
+	if err != nil {
+		return false, err
+	}
+	updates := make([]DependencyUpdate, 0)
+	for _, module := range builder.buildModules {
+		moduleUpdates, err := builder.builder.DependencyUpdates(module, preReleases)
+		if err != nil {
+			return false, fmt.Errorf("failed to check dependencies of module %s: %w", module.Name, err)
+		}
+		updates = append(updates, moduleUpdates...)
+	}
+	if err := WriteDependencyUpdates(os.Stdout, updates, format); err != nil {
+		return false, err
+	}
+	for _, update := range updates {
+		if update.Outdated() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func Clean(path string) error {
 	logger := NewBuildLog()
 	builder, err := newModuleBuilder(path, logger)
@@ -121,19 +243,87 @@ func Clean(path string) error {
 	return nil
 }
 
-func PublishRawJAR(jarPath, gav string) error {
+// VerifySignatures checks that every artifact file under dir has a valid detached signature.
+// The signatures are checked against the public keys in keysFile, or if it is empty against the
+// configured signing key.
+func VerifySignatures(dir, keysFile string) error {
+	var keyring openpgp.EntityList
+	if keysFile != "" {
+		var err error
+		keyring, err = maven.ReadPublicKeys(keysFile)
+		if err != nil {
+			return err
+		}
+	} else {
+		signer, err := maven.LoadSigner("")
+		if err != nil {
+			return fmt.Errorf("%w, or give the public keys to verify with", err)
+		}
+		keyring = signer.PublicKeys()
+	}
+	checked, problems, err := maven.VerifySignatures(dir, keyring)
+	if err != nil {
+		return err
+	}
+	for _, problem := range problems {
+		pterm.Error.Printf("%s: %s\n", problem.Path, problem.Reason)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d of %d files in %s are not signed correctly", len(problems), checked, dir)
+	}
+	pterm.Success.Printf("Verified the signatures of %d files in %s\n", checked, dir)
+	return nil
+}
+
+// VerifyCache checks every file in the local repository for corruption, returning an error if
+// any problems were found.
+func VerifyCache() error {
+	repo := maven.OpenLocalRepository()
+	checked, problems, err := repo.VerifyCache()
+	if err != nil {
+		return err
+	}
+	for _, problem := range problems {
+		pterm.Error.Printf("%s: %s\n", problem.Path, problem.Reason)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d of %d files in %s are corrupt, delete them to download them again", len(problems), checked, repo.BaseDir())
+	}
+	pterm.Success.Printf("Verified %d files in %s\n", checked, repo.BaseDir())
+	return nil
+}
+
+// PublishRawJAR publishes an existing jar under the given coordinates.
+func PublishRawJAR(jarPath, gav string, options PublishOptions) error {
 	logger := NewBuildLog()
 	logger.ModuleStart("Publishing existing jar file")
 	d, err := project.ParseCoordinates(gav)
 	if logger.CheckError("Parsing GAV", err) {
 		return nil
 	}
+	builder := NewBuilder(logger)
+	builder.SetSigner(options.Signer)
+	builder.SetPublishMavenLocal(options.M2)
+	var remote *maven.Remote
+	if options.Repo != "" {
+		remote, err = builder.repo.FindRemote(options.Repo)
+		if err != nil {
+			return err
+		}
+	}
 	task := logger.TaskStart("Creating pom file")
 	pomPath, err := writePOM(jarPath, d)
-	task.Done(err)
-	task = logger.TaskStart("Installing package")
-	repo := maven.OpenLocalRepository()
-	err = repo.InstallPackage(d.Group, d.Artifact, d.Version, jarPath, pomPath)
+	if task.Done(err) {
+		logger.BuildFinish()
+		return nil
+	}
+	files := []maven.ArtifactFile{{Path: jarPath, Extension: "jar"}, {Path: pomPath, Extension: "pom"}}
+	if remote != nil {
+		task = logger.TaskStart("Deploying package")
+	} else {
+		task = logger.TaskStart("Installing package")
+	}
+	err = builder.publishFiles(remote, d.Group, d.Artifact, d.Version, files)
 	task.Done(err)
 	logger.BuildFinish()
 	return nil
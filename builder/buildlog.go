@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"github.com/jsando/jb/project"
 	"github.com/pterm/pterm"
+	"io"
 	"os"
 	"time"
 )
 
 type buildLog struct {
+	out             io.Writer
 	buildStartTime  time.Time
 	moduleStartTime time.Time
 	warnCount       int
@@ -22,17 +24,17 @@ type taskLog struct {
 }
 
 func (t *taskLog) Info(msg string) {
-	pterm.Info.Println(msg)
+	pterm.Info.WithWriter(t.buildLog.out).Println(msg)
 }
 
 func (t *taskLog) Warn(msg string) {
 	t.buildLog.warnCount++
-	pterm.Warning.Println(msg)
+	pterm.Warning.WithWriter(t.buildLog.out).Println(msg)
 }
 
 func (t *taskLog) Error(msg string) {
 	t.buildLog.errorCount++
-	pterm.Error.Println(msg)
+	pterm.Error.WithWriter(t.buildLog.out).Println(msg)
 }
 
 func formatSeconds(t time.Time) string {
@@ -40,14 +42,20 @@ func formatSeconds(t time.Time) string {
 }
 
 func NewBuildLog() *buildLog {
-	bl := &buildLog{}
+	bl := &buildLog{out: os.Stdout}
 	bl.BuildStart()
 	return bl
 }
 
+// newReportLog creates a build log for commands whose output on stdout is a report that other
+// tools may parse, so it writes to stderr and doesn't print the banner.
+func newReportLog() *buildLog {
+	return &buildLog{out: os.Stderr, buildStartTime: time.Now()}
+}
+
 func (b *buildLog) BuildStart() {
 	b.buildStartTime = time.Now()
-	fmt.Printf("JB - Build Started\n")
+	fmt.Fprintf(b.out, "JB - Build Started\n")
 }
 
 func (b *buildLog) BuildFinish() {
@@ -58,16 +66,16 @@ func (b *buildLog) BuildFinish() {
 	}
 	msg := fmt.Sprintf("Build %s in %s (%d Warnings, %d Errors)\n", result, totalTime, b.warnCount, b.errorCount)
 	if b.errorCount > 0 {
-		pterm.Error.Println(msg)
+		pterm.Error.WithWriter(b.out).Println(msg)
 		os.Exit(1)
 	} else {
-		pterm.Success.Println(msg)
+		pterm.Success.WithWriter(b.out).Println(msg)
 	}
 }
 
 func (b *buildLog) ModuleStart(name string) {
 	b.moduleStartTime = time.Now()
-	fmt.Printf("  Module: %s\n", name)
+	fmt.Fprintf(b.out, "  Module: %s\n", name)
 }
 
 func (b *buildLog) CheckError(task string, err error) bool {
@@ -75,7 +83,7 @@ func (b *buildLog) CheckError(task string, err error) bool {
 		return false
 	}
 	b.errorCount++
-	pterm.Fatal.Printf("ERROR %s: %s\n", task, err)
+	pterm.Fatal.WithWriter(b.out).Printf("ERROR %s: %s\n", task, err)
 	//fmt.Printf("ERROR %s: %s\n", task, err)
 	return true
 }
@@ -96,10 +104,10 @@ func (t *taskLog) Done(err error) bool {
 	taskDuration := formatSeconds(t.startTime)
 	if err != nil {
 		t.buildLog.errorCount++
-		pterm.Error.Printf("    ✖ %s FAILED (Time: %s)\n", t.name, taskDuration)
-		pterm.Error.Printf("      └─ Cause: %s\n", err)
+		pterm.Error.WithWriter(t.buildLog.out).Printf("    ✖ %s FAILED (Time: %s)\n", t.name, taskDuration)
+		pterm.Error.WithWriter(t.buildLog.out).Printf("      └─ Cause: %s\n", err)
 	} else {
-		fmt.Printf("    ✔ %s (Time: %s)\n", t.name, taskDuration)
+		fmt.Fprintf(t.buildLog.out, "    ✔ %s (Time: %s)\n", t.name, taskDuration)
 	}
 	return err != nil
 }
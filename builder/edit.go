@@ -0,0 +1,264 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jsando/jb/maven"
+	"github.com/jsando/jb/project"
+)
+
+// AddDependency adds a dependency of the given scope to the module at path, or changes its
+// version if the module already depends on the artifact with that scope. Without a version the
+// dependency is added without one if the module's platforms manage it, and otherwise the latest
+// release is added.
+func AddDependency(path, coordinates, scope string) error {
+	key, err := project.DependencyKey(scope)
+	if err != nil {
+		return err
+	}
+	return editModuleDependencies(path, func(j *Builder, module *project.Module, lists map[string][]string) error {
+		return j.addDependency(module, lists, key, coordinates)
+	})
+}
+
+// RemoveDependency removes every dependency on group:artifact from the module at path, whatever
+// its scope.
+func RemoveDependency(path, groupArtifact string) error {
+	return editModuleDependencies(path, func(j *Builder, module *project.Module, lists map[string][]string) error {
+		return removeDependency(lists, groupArtifact)
+	})
+}
+
+// UpgradeDependencies changes the dependencies of the module at path, of every scope, to their
+// latest release, or only the dependency on group:artifact if it isn't empty.
+func UpgradeDependencies(path, groupArtifact string) error {
+	return editModuleDependencies(path, func(j *Builder, module *project.Module, lists map[string][]string) error {
+		return j.upgradeDependencies(module.Name, lists, groupArtifact)
+	})
+}
+
+// editModuleDependencies loads the single module at path, applies edit to the dependency lists as
+// written in its module file, checks that the result resolves, and saves the module file. The
+// lock file is rewritten too if the module has one.
+func editModuleDependencies(path string, edit func(j *Builder, module *project.Module, lists map[string][]string) error) error {
+	logger := NewBuildLog()
+	builder, err := newModuleBuilder(path, logger)
+	if err != nil {
+		return err
+	}
+	if len(builder.buildModules) != 1 {
+		return fmt.Errorf("'%s' has %d modules, give the path of the one to change", path, len(builder.buildModules))
+	}
+	module := builder.buildModules[0]
+	lists, err := module.DeclaredDependencies()
+	if err != nil {
+		return err
+	}
+	edited := make(map[string][]string, len(lists))
+	for key, deps := range lists {
+		edited[key] = slices.Clone(deps)
+	}
+	if err := edit(builder.builder, module, edited); err != nil {
+		return err
+	}
+	return builder.builder.saveDependencies(module, edited)
+}
+
+// saveDependencies resolves the module with the given dependency lists, by module file key, and
+// if that works writes the lists that changed to its module file. Lists that aren't given are
+// left as they are.
+func (j *Builder) saveDependencies(module *project.Module, lists map[string][]string) error {
+	declared, err := module.DeclaredDependencies()
+	if err != nil {
+		return err
+	}
+	changed := make([]string, 0)
+	for _, key := range project.DependencyKeys() {
+		if deps, found := lists[key]; found && !slices.Equal(deps, declared[key]) {
+			declared[key] = deps
+			changed = append(changed, key)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	edited := *module
+	edited.Dependencies, err = project.ParseDependencyLists(declared)
+	if err != nil {
+		return err
+	}
+	edited.Lock = nil
+	if _, err := j.ResolveModule(&edited); err != nil {
+		return fmt.Errorf("dependencies of module %s don't resolve, %s was not changed: %w", module.Name, project.ModuleFilename, err)
+	}
+	data := module.ModuleFileBytes
+	for _, key := range changed {
+		data, err = project.SetDependencies(data, key, declared[key])
+		if err != nil {
+			return err
+		}
+	}
+	moduleFilePath := filepath.Join(module.ModuleDirAbs, project.ModuleFilename)
+	if err := os.WriteFile(moduleFilePath, data, 0644); err != nil {
+		return err
+	}
+	edited.ModuleFileBytes = data
+	*module = edited
+	if module.LockFileBytes != nil {
+		return j.LockModule(module)
+	}
+	return nil
+}
+
+func (j *Builder) addDependency(module *project.Module, lists map[string][]string, key, coordinates string) error {
+	spec := coordinates
+	if parts := strings.Split(coordinates, ":"); len(parts) == 2 {
+		if parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid dependency '%s', must be in the form <group>:<artifact>[:<version>]", coordinates)
+		}
+		managed, err := j.platformVersions(module)
+		if err != nil {
+			return err
+		}
+		if _, found := managed[coordinates]; found {
+			return setDependency(lists, key, &project.Dependency{Group: parts[0], Artifact: parts[1]})
+		}
+		metadata, err := j.repo.GetMetadata(parts[0], parts[1])
+		if err != nil {
+			return err
+		}
+		version := latestRelease(metadata.Versioning.Versions)
+		if version == "" {
+			return fmt.Errorf("%s has no releases", coordinates)
+		}
+		spec = maven.GAV(parts[0], parts[1], version)
+	}
+	added, err := project.ParseDependency(spec)
+	if err != nil {
+		return err
+	}
+	return setDependency(lists, key, added)
+}
+
+// setDependency adds the dependency to the list under key, or changes the version of the one on
+// the same artifact there. An artifact that is already in another list is refused, since its
+// scope would be ambiguous.
+func setDependency(lists map[string][]string, key string, added *project.Dependency) error {
+	for _, listKey := range project.DependencyKeys() {
+		deps := lists[listKey]
+		for i, existing := range deps {
+			dep, err := project.ParseDependency(existing)
+			if err != nil {
+				return err
+			}
+			if dependencyKey(dep) != dependencyKey(added) {
+				continue
+			}
+			if listKey != key {
+				return fmt.Errorf("%s is already in %s, remove it first to change its scope", dependencyKey(added), listKey)
+			}
+			// keep the exclusions already declared
+			dep.Version = added.Version
+			deps[i] = dep.String()
+			return nil
+		}
+	}
+	lists[key] = append(lists[key], added.String())
+	return nil
+}
+
+// latestRelease returns the newest version that isn't a snapshot or pre-release, or an empty
+// string if there is none.
+func latestRelease(versions []string) string {
+	var latest *maven.Version
+	for _, s := range versions {
+		version := maven.ParseVersion(s)
+		if !version.IsPreRelease() && (latest == nil || version.Compare(*latest) > 0) {
+			latest = &version
+		}
+	}
+	return versionString(latest)
+}
+
+func removeDependency(lists map[string][]string, groupArtifact string) error {
+	group, artifact, err := parseGroupArtifact(groupArtifact)
+	if err != nil {
+		return err
+	}
+	removed := false
+	for key, deps := range lists {
+		before := len(deps)
+		lists[key] = slices.DeleteFunc(deps, func(spec string) bool {
+			dep, err := project.ParseDependency(spec)
+			return err == nil && dep.Group == group && dep.Artifact == artifact
+		})
+		removed = removed || len(lists[key]) < before
+	}
+	if !removed {
+		return fmt.Errorf("no dependency on %s to remove", groupArtifact)
+	}
+	return nil
+}
+
+// upgradeDependencies changes the version of each dependency in every list, or only of
+// group:artifact, to the latest release. Version ranges, LATEST and RELEASE already float and
+// are left alone, as are dependencies whose version is set by a platform.
+func (j *Builder) upgradeDependencies(moduleName string, lists map[string][]string, groupArtifact string) error {
+	var group, artifact string
+	if groupArtifact != "" {
+		var err error
+		group, artifact, err = parseGroupArtifact(groupArtifact)
+		if err != nil {
+			return err
+		}
+	}
+	found := false
+	upgraded := 0
+	for _, key := range project.DependencyKeys() {
+		deps := lists[key]
+		for i, spec := range deps {
+			dep, err := project.ParseDependency(spec)
+			if err != nil {
+				return err
+			}
+			if groupArtifact != "" && (dep.Group != group || dep.Artifact != artifact) {
+				continue
+			}
+			found = true
+			if dep.Version == "" || maven.IsVersionRange(dep.Version) || dep.Version == "LATEST" || dep.Version == "RELEASE" {
+				continue
+			}
+			metadata, err := j.repo.GetMetadata(dep.Group, dep.Artifact)
+			if err != nil {
+				return err
+			}
+			_, _, latest := latestVersions(dep.Version, metadata.Versioning.Versions, maven.ParseVersion(dep.Version).IsPreRelease())
+			if latest == "" {
+				continue
+			}
+			fmt.Printf("%s: %s:%s %s -> %s\n", moduleName, dep.Group, dep.Artifact, dep.Version, latest)
+			dep.Version = latest
+			deps[i] = dep.String()
+			upgraded++
+		}
+	}
+	if groupArtifact != "" && !found {
+		return fmt.Errorf("no dependency on %s to upgrade", groupArtifact)
+	}
+	if upgraded == 0 {
+		fmt.Printf("%s: all dependencies are up to date\n", moduleName)
+	}
+	return nil
+}
+
+func parseGroupArtifact(s string) (string, string, error) {
+	group, artifact, found := strings.Cut(s, ":")
+	if !found || group == "" || artifact == "" || strings.Contains(artifact, ":") {
+		return "", "", fmt.Errorf("invalid artifact '%s', must be in the form <group>:<artifact>", s)
+	}
+	return group, artifact, nil
+}
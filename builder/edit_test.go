@@ -0,0 +1,137 @@
+package builder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jsando/jb/maven"
+	"github.com/jsando/jb/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newEditTestBuilder returns a builder whose repository has org.lib:common 1.0, 1.5 and
+// 2.0-beta, with metadata served by a test remote.
+func newEditTestBuilder(t *testing.T) *Builder {
+	repoDir := t.TempDir()
+	writeTestArtifact(t, repoDir, "org.lib", "common", "1.0")
+	writeTestArtifact(t, repoDir, "org.lib", "common", "1.5")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/org/lib/common/maven-metadata.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`<metadata><versioning><release>1.5</release><versions><version>1.0</version><version>1.5</version><version>2.0-beta</version></versions></versioning></metadata>`))
+	}))
+	t.Cleanup(server.Close)
+	builder := NewBuilder(&MockBuildLog{})
+	builder.repo = maven.NewLocalRepository(repoDir, server.URL)
+	builder.repo.SetChecksumPolicy(maven.ChecksumIgnore)
+	return builder
+}
+
+func TestAddDependency(t *testing.T) {
+	builder := newEditTestBuilder(t)
+
+	lists := map[string][]string{"dependencies": {"org.app:a:1.0"}}
+	require.NoError(t, builder.addDependency(&project.Module{}, lists, "dependencies", "org.lib:common"))
+	assert.Equal(t, []string{"org.app:a:1.0", "org.lib:common:1.5"}, lists["dependencies"])
+
+	// an existing dependency gets the new version and keeps its exclusions
+	lists = map[string][]string{"test_dependencies": {"org.lib:common:1.0 !org.x:y"}}
+	require.NoError(t, builder.addDependency(&project.Module{}, lists, "test_dependencies", "org.lib:common:1.5"))
+	assert.Equal(t, []string{"org.lib:common:1.5 !org.x:y"}, lists["test_dependencies"])
+
+	// but it isn't added again with another scope
+	err := builder.addDependency(&project.Module{}, lists, "dependencies", "org.lib:common:1.5")
+	assert.EqualError(t, err, "org.lib:common is already in test_dependencies, remove it first to change its scope")
+
+	err = builder.addDependency(&project.Module{}, lists, "dependencies", "org.lib:")
+	assert.EqualError(t, err, "invalid dependency 'org.lib:', must be in the form <group>:<artifact>[:<version>]")
+}
+
+func TestRemoveDependency(t *testing.T) {
+	lists := map[string][]string{
+		"dependencies":      {"org.lib:common:1.0", "org.app:a:1.0"},
+		"test_dependencies": {"org.lib:common:1.0:tests"},
+	}
+	require.NoError(t, removeDependency(lists, "org.lib:common"))
+	assert.Equal(t, map[string][]string{"dependencies": {"org.app:a:1.0"}, "test_dependencies": {}}, lists)
+
+	// whichever list holds it
+	lists = map[string][]string{"dependencies": {"org.app:a:1.0"}, "provided_dependencies": {"org.lib:common:1.0"}}
+	require.NoError(t, removeDependency(lists, "org.lib:common"))
+	assert.Empty(t, lists["provided_dependencies"])
+
+	err := removeDependency(lists, "org.lib:common")
+	assert.EqualError(t, err, "no dependency on org.lib:common to remove")
+	err = removeDependency(nil, "org.lib:common:1.0")
+	assert.EqualError(t, err, "invalid artifact 'org.lib:common:1.0', must be in the form <group>:<artifact>")
+}
+
+func TestUpgradeDependencies(t *testing.T) {
+	builder := newEditTestBuilder(t)
+
+	lists := map[string][]string{
+		"dependencies":      {"org.lib:common:1.0 !org.x:y"},
+		"test_dependencies": {"org.lib:common:[1.0,2.0):tests", "org.lib:common:1.0:fixtures"},
+	}
+	require.NoError(t, builder.upgradeDependencies("app", lists, ""))
+	assert.Equal(t, map[string][]string{
+		"dependencies":      {"org.lib:common:1.5 !org.x:y"},
+		"test_dependencies": {"org.lib:common:[1.0,2.0):tests", "org.lib:common:1.5:fixtures"},
+	}, lists)
+
+	err := builder.upgradeDependencies("app", lists, "org.app:a")
+	assert.EqualError(t, err, "no dependency on org.app:a to upgrade")
+}
+
+func TestSaveDependencies(t *testing.T) {
+	builder := newEditTestBuilder(t)
+	moduleDir := t.TempDir()
+	moduleFile := `{
+    "version": "1.0",
+    "dependencies": [
+        "org.lib:common:1.0"
+    ],
+    "javac_args": ["-g"]
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(moduleDir, project.ModuleFilename), []byte(moduleFile), 0644))
+	module, err := project.NewModuleLoader().GetModule(moduleDir)
+	require.NoError(t, err)
+
+	// a version that doesn't resolve leaves the file alone
+	err = builder.saveDependencies(module, map[string][]string{"dependencies": {"org.lib:common:3.0"}})
+	assert.ErrorContains(t, err, "dependencies of module "+module.Name+" don't resolve, jb-module.json was not changed")
+	data, err := os.ReadFile(filepath.Join(moduleDir, project.ModuleFilename))
+	require.NoError(t, err)
+	assert.Equal(t, moduleFile, string(data))
+
+	require.NoError(t, builder.saveDependencies(module, map[string][]string{"dependencies": {"org.lib:common:1.5"}}))
+	data, err = os.ReadFile(filepath.Join(moduleDir, project.ModuleFilename))
+	require.NoError(t, err)
+	assert.Equal(t, `{
+    "version": "1.0",
+    "dependencies": [
+        "org.lib:common:1.5"
+    ],
+    "javac_args": ["-g"]
+}
+`, string(data))
+	assert.Equal(t, "1.5", module.Dependencies[0].Version)
+
+	// scoped lists are written under their own key
+	moduleDir = t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(moduleDir, project.ModuleFilename), []byte(`{"test_dependencies": ["org.lib:common:1.0"]}`), 0644))
+	module, err = project.NewModuleLoader().GetModule(moduleDir)
+	require.NoError(t, err)
+	require.NoError(t, builder.saveDependencies(module, map[string][]string{"dependencies": {}, "test_dependencies": {"org.lib:common:1.5"}}))
+	data, err = os.ReadFile(filepath.Join(moduleDir, project.ModuleFilename))
+	require.NoError(t, err)
+	assert.Equal(t, `{"test_dependencies": ["org.lib:common:1.5"]}`, string(data))
+	assert.Equal(t, project.ScopeTest, module.Dependencies[0].Scope)
+}
@@ -0,0 +1,67 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/jsando/jb/project"
+)
+
+// DownloadWorkersEnv is the environment variable that sets how many artifacts are downloaded
+// at the same time.
+const DownloadWorkersEnv = "JB_DOWNLOAD_WORKERS"
+
+const defaultDownloadWorkers = 8
+
+func downloadWorkersFromEnv() int {
+	value := os.Getenv(DownloadWorkersEnv)
+	if value == "" {
+		return defaultDownloadWorkers
+	}
+	workers, err := strconv.Atoi(value)
+	if err != nil || workers < 1 {
+		fmt.Fprintf(os.Stderr, "warning: ignoring %s: must be a positive number, not '%s'\n", DownloadWorkersEnv, value)
+		return defaultDownloadWorkers
+	}
+	return workers
+}
+
+// fetcher runs the downloads for dependencies ahead of the breadth-first walk on a bounded pool
+// of workers, so independent downloads overlap while the walk still consumes the results one at
+// a time in a deterministic order.
+type fetcher struct {
+	workers chan struct{}
+	results map[*project.Dependency]chan error
+}
+
+func newFetcher(workers int) *fetcher {
+	return &fetcher{
+		workers: make(chan struct{}, max(workers, 1)),
+		results: make(map[*project.Dependency]chan error),
+	}
+}
+
+// start runs fn for dep in the background once a worker is free. The walk must not touch dep
+// until wait returns.
+func (f *fetcher) start(dep *project.Dependency, fn func() error) {
+	if _, started := f.results[dep]; started {
+		return
+	}
+	result := make(chan error, 1)
+	f.results[dep] = result
+	go func() {
+		f.workers <- struct{}{}
+		defer func() { <-f.workers }()
+		result <- fn()
+	}()
+}
+
+// wait returns the result of the function started for dep.
+func (f *fetcher) wait(dep *project.Dependency) error {
+	result, started := f.results[dep]
+	if !started {
+		panic("waiting for a fetch that was never started: " + dep.Coordinates)
+	}
+	return <-result
+}
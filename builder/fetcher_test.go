@@ -0,0 +1,51 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jsando/jb/project"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadWorkersFromEnv(t *testing.T) {
+	t.Setenv(DownloadWorkersEnv, "")
+	assert.Equal(t, defaultDownloadWorkers, downloadWorkersFromEnv())
+	t.Setenv(DownloadWorkersEnv, "3")
+	assert.Equal(t, 3, downloadWorkersFromEnv())
+	t.Setenv(DownloadWorkersEnv, "0")
+	assert.Equal(t, defaultDownloadWorkers, downloadWorkersFromEnv())
+}
+
+func TestFetcher_BoundsWorkers(t *testing.T) {
+	const workers = 2
+	fetches := newFetcher(workers)
+	running := make(chan struct{}, 10)
+	release := make(chan struct{})
+	deps := make([]*project.Dependency, 6)
+	for i := range deps {
+		deps[i] = &project.Dependency{Coordinates: fmt.Sprintf("org.app:a:1.%d", i)}
+		fetches.start(deps[i], func() error {
+			running <- struct{}{}
+			<-release
+			if i == 3 {
+				return errors.New("failed")
+			}
+			return nil
+		})
+	}
+	// only two of the fetches can be running until one is released
+	<-running
+	<-running
+	assert.Len(t, running, 0)
+	close(release)
+	for i, dep := range deps {
+		err := fetches.wait(dep)
+		if i == 3 {
+			assert.EqualError(t, err, "failed")
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
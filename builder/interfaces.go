@@ -65,6 +65,15 @@ type JarArgs struct {
 	WorkDir      string   // Working directory
 }
 
+// JavadocArgs represents arguments for generating API documentation
+type JavadocArgs struct {
+	SourceFiles []string
+	ClassPath   string
+	DestDir     string
+	ExtraFlags  []string
+	WorkDir     string // Working directory, source files are relative to it
+}
+
 // RunArgs represents arguments for running a Java program
 type RunArgs struct {
 	MainClass   string   // Either main class or -jar jarfile
@@ -127,6 +136,15 @@ type JavaRunner interface {
 	IsAvailable() bool
 }
 
+// JavadocTool is the interface for generating API documentation
+type JavadocTool interface {
+	// Generate writes the HTML documentation of the source files to the destination directory
+	Generate(args JavadocArgs) error
+
+	// IsAvailable checks if the tool is available on the system
+	IsAvailable() bool
+}
+
 // ToolProvider provides access to Java development tools
 type ToolProvider interface {
 	// GetCompiler returns a JavaCompiler instance
@@ -138,6 +156,9 @@ type ToolProvider interface {
 	// GetRunner returns a JavaRunner instance
 	GetRunner() JavaRunner
 
+	// GetJavadocTool returns a JavadocTool instance
+	GetJavadocTool() JavadocTool
+
 	// DetectJDK detects and returns information about the available JDK
 	DetectJDK() (*JDKInfo, error)
 }
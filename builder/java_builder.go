@@ -10,7 +10,9 @@ import (
 	"github.com/jsando/jb/project"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 )
 
 const (
@@ -18,28 +20,51 @@ const (
 )
 
 type Builder struct {
-	repo         *maven.LocalRepository
-	logger       project.BuildLog
-	toolProvider ToolProvider
+	repo            *maven.LocalRepository
+	logger          project.BuildLog
+	toolProvider    ToolProvider
+	downloadWorkers int
+	signer          *maven.Signer // signs published files if not nil
+	mavenLocal      bool          // publish to maven's local repository instead of jb's
+	jdkDetected     sync.Once     // POM profiles are activated by the JDK, detected before resolving
 }
 
 func NewBuilder(logger project.BuildLog) *Builder {
 	return &Builder{
-		repo:         maven.OpenLocalRepository(),
-		logger:       logger,
-		toolProvider: GetDefaultToolProvider(),
+		repo:            maven.OpenLocalRepository(),
+		logger:          logger,
+		toolProvider:    GetDefaultToolProvider(),
+		downloadWorkers: downloadWorkersFromEnv(),
 	}
 }
 
 // NewBuilderWithTools creates a new Builder with a custom tool provider
 func NewBuilderWithTools(logger project.BuildLog, toolProvider ToolProvider) *Builder {
 	return &Builder{
-		repo:         maven.OpenLocalRepository(),
-		logger:       logger,
-		toolProvider: toolProvider,
+		repo:            maven.OpenLocalRepository(),
+		logger:          logger,
+		toolProvider:    toolProvider,
+		downloadWorkers: downloadWorkersFromEnv(),
 	}
 }
 
+// SetDownloadWorkers sets how many artifacts are downloaded at the same time while resolving
+// dependencies.
+func (j *Builder) SetDownloadWorkers(workers int) {
+	j.downloadWorkers = workers
+}
+
+// SetSigner sets the signer used to sign every file published, or nil to publish unsigned files.
+func (j *Builder) SetSigner(signer *maven.Signer) {
+	j.signer = signer
+}
+
+// SetPublishMavenLocal sets whether modules published without a remote are installed into
+// maven's local repository, ~/.m2/repository, rather than jb's.
+func (j *Builder) SetPublishMavenLocal(mavenLocal bool) {
+	j.mavenLocal = mavenLocal
+}
+
 func (j *Builder) Clean(module *project.Module) {
 	task := j.logger.TaskStart("cleaning build dir")
 	buildDir := filepath.Join(module.ModuleDirAbs, "build")
@@ -76,17 +101,33 @@ func (j *Builder) Build(module *project.Module) {
 	if j.logger.CheckError("finding java sources", err) {
 		return
 	}
-	if len(sources) == 0 {
-		fmt.Printf("warning: no java sources found in %s\n", module.ModuleDirAbs)
+	sources, err = relativeSources(module, module.SourceDirAbs, sources)
+	if j.logger.CheckError("getting relative path for source", err) {
+		return
+	}
+	if module.TestSourceDirAbs != "" {
+		// the test source dir may be inside the source dir
+		testDir, err := filepath.Rel(module.ModuleDirAbs, module.TestSourceDirAbs)
+		if j.logger.CheckError("getting relative path for test sources", err) {
+			return
+		}
+		sources = slices.DeleteFunc(sources, func(source project.SourceFileInfo) bool {
+			return strings.HasPrefix(source.Path, testDir+string(filepath.Separator))
+		})
 	}
 	if len(sources) == 0 {
 		fmt.Printf("warning: no java sources found in %s\n", module.ModuleDirAbs)
 	}
 
-	// Find source files in the source dir but make the path relative to the module dir
-	for i, source := range sources {
-		sources[i].Path, err = filepath.Rel(module.ModuleDirAbs, filepath.Join(module.SourceDirAbs, source.Path))
-		if j.logger.CheckError("getting relative path for source", err) {
+	// Gather test sources, if they are kept apart from the main sources
+	var testSources []project.SourceFileInfo
+	if info, err := os.Stat(module.TestSourceDirAbs); err == nil && info.IsDir() {
+		testSources, err = project.FindFilesBySuffixR(module.TestSourceDirAbs, ".java")
+		if j.logger.CheckError("finding java test sources", err) {
+			return
+		}
+		testSources, err = relativeSources(module, module.TestSourceDirAbs, testSources)
+		if j.logger.CheckError("getting relative path for test source", err) {
 			return
 		}
 	}
@@ -100,7 +141,7 @@ func (j *Builder) Build(module *project.Module) {
 	// Compute sha1(project-file, sources, embeds) and see if we're up to date
 	hasher := sha1.New()
 	_ = module.HashContent(hasher)
-	for _, source := range sources {
+	for _, source := range append(slices.Clip(sources), testSources...) {
 		hasher.Write([]byte(source.Path))
 		bytes := make([]byte, 8)
 		binary.LittleEndian.PutUint64(bytes, uint64(source.Info.ModTime().UnixNano()))
@@ -137,16 +178,19 @@ func (j *Builder) Build(module *project.Module) {
 		return
 	}
 
-	// For compilation, use the absolute paths to all jar dependencies
-	classPath := ""
-	deps, err := module.GetModuleReferencesInBuildOrder()
+	// Fail early on circular references between modules
+	_, err = module.GetModuleReferencesInBuildOrder()
 	if j.logger.CheckError("getting module references", err) {
 		return
 	}
-	compileClasspath, err := j.getBuildDependencies(module)
+
+	// For compilation, use the absolute paths to all jar dependencies
+	classPath := ""
+	resolution, err := j.ResolveModule(module)
 	if j.logger.CheckError("getting build dependencies", err) {
 		return
 	}
+	compileClasspath := resolution.CompileClasspath()
 	if len(compileClasspath) > 0 {
 		classPath = strings.Join(compileClasspath, string(os.PathListSeparator))
 	}
@@ -160,6 +204,20 @@ func (j *Builder) Build(module *project.Module) {
 		}
 	}
 
+	// Compile test sources against the main classes
+	if len(testSources) > 0 {
+		task := j.logger.TaskStart("compile java test sources")
+		testClasses := filepath.Join(buildTmpDir, "test-classes")
+		err := os.MkdirAll(testClasses, os.ModePerm)
+		if err == nil {
+			testClassPath := strings.Join(append(resolution.TestClasspath(), buildClasses), string(os.PathListSeparator))
+			err = j.compileJava(module, task, buildTmpDir, testClasses, testClassPath, module.CompileArgs, testSources)
+		}
+		if task.Done(err) {
+			return
+		}
+	}
+
 	// Copy embeds to output folder then jar can just jar everything
 	task := j.logger.TaskStart("building jar")
 	for _, embed := range embedFiles {
@@ -187,13 +245,27 @@ func (j *Builder) Build(module *project.Module) {
 	}
 
 	// Build into .jar
-	err = j.buildJar(module, buildDir, jarDate, module.MainClass, compileClasspath, buildTmpDir, buildClasses)
+	err = j.buildJar(module, buildDir, jarDate, module.MainClass, resolution.RuntimeClasspath(), buildTmpDir, buildClasses)
 	if task.Done(err) {
 		return
 	}
 
+	// Build the sources and javadoc jars published alongside it
+	if module.SourcesJar {
+		task := j.logger.TaskStart("building sources jar")
+		if task.Done(j.buildSourcesJar(module, jarDate, sources)) {
+			return
+		}
+	}
+	if module.JavadocJar {
+		task := j.logger.TaskStart("building javadoc jar")
+		if task.Done(j.buildJavadocJar(module, jarDate, buildTmpDir, classPath, sources)) {
+			return
+		}
+	}
+
 	// write pom file
-	err = j.writePOM(module, deps)
+	err = j.writePOM(module)
 	if j.logger.CheckError("writing pom file", err) {
 		return
 	}
@@ -274,8 +346,11 @@ func (j *Builder) compileJava(module *project.Module, task project.TaskLog, buil
 	return err
 }
 
-func (j *Builder) writePOM(module *project.Module, deps []*project.Module) error {
-	pom := maven.POM{
+// buildPOM creates the POM published with the module's jar. References to other modules become
+// compile dependencies, and compile-only dependencies are left out since users of the jar don't
+// need them.
+func buildPOM(module *project.Module) *maven.POM {
+	pom := &maven.POM{
 		Xmlns:             "http://maven.apache.org/POM/4.0.0",                                          // Default namespace
 		XmlnsXsi:          "http://www.w3.org/2001/XMLSchema-instance",                                  // XML Schema instance namespace
 		XsiSchemaLocation: "http://maven.apache.org/POM/4.0.0 http://maven.apache.org/maven-v4_0_0.xsd", // Schema location
@@ -285,32 +360,73 @@ func (j *Builder) writePOM(module *project.Module, deps []*project.Module) error
 		ArtifactID:        module.Name,
 		Version:           module.Version,
 		Name:              module.Name,
-		Description:       module.Name,
+		Description:       module.Description,
+		URL:               module.URL,
+		Licenses:          module.Licenses,
+		Developers:        module.Developers,
+		SCM:               module.SCM,
+	}
+	if pom.Description == "" {
+		pom.Description = module.Name
+	}
+	if len(module.Platforms) > 0 {
+		// users of the jar get the same versions for their transitive dependencies
+		pom.DependencyManagement = &maven.DependencyManagement{}
+		for _, platform := range module.Platforms {
+			pom.DependencyManagement.Dependencies = append(pom.DependencyManagement.Dependencies, maven.Dependency{
+				GroupID:    platform.Group,
+				ArtifactID: platform.Artifact,
+				Version:    platform.Version,
+				Type:       "pom",
+				Scope:      "import",
+			})
+		}
 	}
-	jarPath := j.getModuleJarPath(module)
-	pomPath := strings.TrimSuffix(jarPath, ".jar") + ".pom"
 
-	pom.Dependencies = make([]maven.Dependency, len(deps))
-	if len(deps) > 0 {
-		for i, dep := range deps {
-			pom.Dependencies[i] = maven.Dependency{
-				GroupID:    dep.Group,
-				ArtifactID: dep.Name,
-				Version:    dep.Version,
-			}
-		}
+	pom.Dependencies = make([]maven.Dependency, 0, len(module.References)+len(module.Dependencies))
+	for _, ref := range module.References {
+		pom.Dependencies = append(pom.Dependencies, maven.Dependency{
+			GroupID:    ref.Group,
+			ArtifactID: ref.Name,
+			Version:    ref.Version,
+		})
 	}
-	if module.Dependencies != nil {
-		for _, dep := range module.Dependencies {
-			mavenDep := maven.Dependency{
-				GroupID:    dep.Group,
-				ArtifactID: module.Name,
-				Version:    dep.Version,
-			}
-			pom.Dependencies = append(pom.Dependencies, mavenDep)
+	for _, dep := range module.Dependencies {
+		if dep.Scope == project.ScopeCompileOnly {
+			// maven has no equivalent, and users of the jar don't need it
+			continue
+		}
+		mavenDep := maven.Dependency{
+			GroupID:    dep.Group,
+			ArtifactID: dep.Artifact,
+			Version:    dep.DeclaredVersion(),
+			Type:       dep.Type,
+			Classifier: dep.Classifier,
+			Scope:      dep.Scope,
+		}
+		if dep.Optional {
+			mavenDep.Optional = "true"
+		}
+		for _, exclusion := range dep.Exclusions {
+			mavenDep.Exclusions = append(mavenDep.Exclusions, maven.Exclusion{
+				GroupID:    exclusion.Group,
+				ArtifactID: exclusion.Artifact,
+			})
 		}
+		pom.Dependencies = append(pom.Dependencies, mavenDep)
 	}
-	pomXML, err := xml.MarshalIndent(pom, "", "  ")
+	return pom
+}
+
+func (j *Builder) writePOM(module *project.Module) error {
+	jarPath := j.getModuleJarPath(module)
+	pomPath := strings.TrimSuffix(jarPath, ".jar") + ".pom"
+	// dependencies get the versions their platforms manage
+	applied, _, err := j.applyPlatforms(module)
+	if err != nil {
+		return err
+	}
+	pomXML, err := xml.MarshalIndent(buildPOM(applied), "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to serialize POM to XML: %w", err)
 	}
@@ -366,175 +482,162 @@ func (j *Builder) buildJar(module *project.Module,
 	return jarTool.Create(jarArgs)
 }
 
-func (j *Builder) Publish(m *project.Module, repoURL, user, password string) error {
-	jarPath := j.getModuleJarPath(m)
-	pomPath := strings.TrimSuffix(jarPath, ".jar") + ".pom"
-	return j.repo.InstallPackage(m.Group, m.Name, m.Version, jarPath, pomPath)
-}
-
-type PackageDependency struct {
-	Path       string
-	GroupID    string
-	ArtifactID string
-	Version    string
-	URL        string
-	Transitive []PackageDependency
-}
-
-func (d PackageDependency) PrintTree(index int) {
-	fmt.Printf("%s%s\n", strings.Repeat("  ", index), d.URL)
-	for _, t := range d.Transitive {
-		t.PrintTree(index + 1)
-	}
-}
-
-func (j *Builder) ResolveDependencies(module *project.Module) error {
-	visited := make(map[string]string)
-	for _, ref := range module.Dependencies {
-		if ref == nil {
-			panic("how can ref be nil?")
-		}
-		err := j.resolveDependency(ref, visited)
+// buildSourcesJar jars the main java sources, with paths relative to the source dir.
+func (j *Builder) buildSourcesJar(module *project.Module, jarDate string, sources []project.SourceFileInfo) error {
+	files := make([]string, len(sources))
+	for i, source := range sources {
+		path, err := filepath.Rel(module.SourceDirAbs, filepath.Join(module.ModuleDirAbs, source.Path))
 		if err != nil {
 			return err
 		}
+		files[i] = path
 	}
-	return nil
+	return j.toolProvider.GetJarTool().Create(JarArgs{
+		JarFile: classifierJarPath(j.getModuleJarPath(module), "sources"),
+		BaseDir: module.SourceDirAbs,
+		Files:   files,
+		Date:    jarDate,
+		WorkDir: module.ModuleDirAbs,
+	})
 }
 
-func (j *Builder) resolveDependency(dep *project.Dependency, visited map[string]string) error {
-	// already done?
-	if dep.Path != "" {
-		return nil
+// buildJavadocJar generates the API documentation of the main sources and jars it.
+func (j *Builder) buildJavadocJar(module *project.Module, jarDate, buildTmpDir, classPath string, sources []project.SourceFileInfo) error {
+	javadocTool := j.toolProvider.GetJavadocTool()
+	if !javadocTool.IsAvailable() {
+		return fmt.Errorf("javadoc tool not found. Please ensure JDK is installed and javadoc is in your PATH")
 	}
-	key := fmt.Sprintf("%s:%s", dep.Group, dep.Artifact)
-	if _, exists := visited[key]; exists {
-		// circular, duplicate, or conflicting reference
-		return nil
+	javadocDir := filepath.Join(buildTmpDir, "javadoc")
+	if err := os.RemoveAll(javadocDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(javadocDir, os.ModePerm); err != nil {
+		return err
 	}
-	visited[key] = dep.Version
-	//fmt.Printf("visited: %s\n", key)
+	sourceFiles := make([]string, len(sources))
+	for i, source := range sources {
+		sourceFiles[i] = source.Path
+	}
+	err := javadocTool.Generate(JavadocArgs{
+		SourceFiles: sourceFiles,
+		ClassPath:   classPath,
+		DestDir:     javadocDir,
+		ExtraFlags:  module.JavadocArgs,
+		WorkDir:     module.ModuleDirAbs,
+	})
+	if err != nil {
+		return err
+	}
+	return j.toolProvider.GetJarTool().Create(JarArgs{
+		JarFile: classifierJarPath(j.getModuleJarPath(module), "javadoc"),
+		BaseDir: javadocDir,
+		Files:   []string{"."},
+		Date:    jarDate,
+		WorkDir: module.ModuleDirAbs,
+	})
+}
 
-	//fmt.Printf("resolving %s:%s:%s\n", dep.Group, dep.Artifact, dep.Version)
-	pom, err := j.repo.GetPOM(dep.Group, dep.Artifact, dep.Version)
+// Publish installs the module's jar and POM in the local repository, or uploads them to remote
+// if it isn't nil.
+func (j *Builder) Publish(m *project.Module, remote *maven.Remote) error {
+	jarPath := j.getModuleJarPath(m)
+	files := []maven.ArtifactFile{
+		{Path: jarPath, Extension: "jar"},
+		{Path: strings.TrimSuffix(jarPath, ".jar") + ".pom", Extension: "pom"},
+	}
+	if m.SourcesJar {
+		files = append(files, maven.ArtifactFile{Path: classifierJarPath(jarPath, "sources"), Classifier: "sources", Extension: "jar"})
+	}
+	if m.JavadocJar {
+		files = append(files, maven.ArtifactFile{Path: classifierJarPath(jarPath, "javadoc"), Classifier: "javadoc", Extension: "jar"})
+	}
+	return j.publishFiles(remote, m.Group, m.Name, m.Version, files)
+}
+
+// ValidateForCentral checks the module meets the requirements of maven central: a complete POM,
+// sources and javadoc jars, and signed artifacts.
+func (j *Builder) ValidateForCentral(m *project.Module) error {
+	applied, _, err := j.applyPlatforms(m)
 	if err != nil {
 		return err
 	}
-	switch pom.Packaging {
-	case "", "jar", "bundle":
-		jarPath, err := j.repo.GetJAR(dep.Group, dep.Artifact, dep.Version)
-		if err != nil {
-			return err
-		}
-		dep.Path = jarPath
-	case "pom":
-		// process the POM but there is no jar
-	default:
-		return fmt.Errorf("packaging type not supported: %s", pom.Packaging)
-	}
-	if dep.Transitive == nil {
-		dep.Transitive = make([]*project.Dependency, 0)
-	}
-	for _, pomChild := range pom.Dependencies {
-		if pomChild.Scope == "test" || pomChild.Scope == "provided" || pomChild.Optional == "true" {
-			continue // Skip test and provided scope dependencies
-		}
-		gav := maven.GAV(pomChild.GroupID, pomChild.ArtifactID, pomChild.Version)
-		if pomChild.GroupID == "" || pomChild.ArtifactID == "" || pomChild.Version == "" {
-			return fmt.Errorf("invalid maven package '%s' referenced from %s", gav, dep.Coordinates)
-		}
-		//fmt.Printf("recursive resolving %s\n", gav)
-		child := &project.Dependency{
-			Coordinates: gav,
-			Group:       pomChild.GroupID,
-			Artifact:    pomChild.ArtifactID,
-			Version:     pomChild.Version,
-			Path:        "",
-			Transitive:  make([]*project.Dependency, 0),
-		}
-		err := j.resolveDependency(child, visited)
+	problems := maven.CentralProblems(buildPOM(applied))
+	if !m.SourcesJar {
+		problems = append(problems, "sources_jar must be enabled")
+	}
+	if !m.JavadocJar {
+		problems = append(problems, "javadoc_jar must be enabled")
+	}
+	if j.signer == nil {
+		problems = append(problems, "artifacts must be signed")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("module %s is not valid for maven central:\n  %s", m.Name, strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// publishFiles signs the files if there is a signer and installs them in the local repository,
+// or in maven's if set, or uploads them to remote if it isn't nil.
+func (j *Builder) publishFiles(remote *maven.Remote, group, artifact, version string, files []maven.ArtifactFile) error {
+	if j.signer != nil {
+		var err error
+		files, err = j.signer.SignFiles(files)
 		if err != nil {
 			return err
 		}
-		dep.Transitive = append(dep.Transitive, child)
 	}
-	return nil
+	if remote == nil && j.mavenLocal {
+		return j.repo.InstallMavenLocal(group, artifact, version, files)
+	}
+	if remote == nil {
+		return j.repo.InstallPackage(group, artifact, version, files)
+	}
+	return deploy(remote, group, artifact, version, files)
 }
 
-func (j *Builder) getBuildDependencies(module *project.Module) ([]string, error) {
-	seenDeps := make(map[string]string) // Map to store seen GAV (Group:ArtifactID) and their versions
-	jars := make(map[string]struct{})   // Set to ensure unique jar paths
+// classifierJarPath returns the path of the jar with the given classifier next to the module jar.
+func classifierJarPath(jarPath, classifier string) string {
+	return strings.TrimSuffix(jarPath, ".jar") + "-" + classifier + ".jar"
+}
 
-	// Get the list of modules this module depends on
-	refs, err := module.GetModuleReferencesInBuildOrder()
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve references for module %s: %w", module.Name, err)
-	}
-
-	var addPkg func(pkg *project.Dependency) error
-	addPkg = func(pkg *project.Dependency) error {
-		key := pkg.Group + ":" + pkg.Artifact // Group:ArtifactID
-		if existingVersion, exists := seenDeps[key]; exists {
-			// Check for version conflict
-			if existingVersion != pkg.Version {
-				//fmt.Printf("evict %s:%s (have %s)\n", key, pkg.Version, existingVersion)
-				return nil
-				//return fmt.Errorf("version conflict for dependency %s: %s vs %s",
-				//	key, existingVersion, pkg.Version)
-			}
-		} else {
-			// Store seen dependency version
-			seenDeps[key] = pkg.Version
+func deploy(remote *maven.Remote, group, artifact, version string, files []maven.ArtifactFile) error {
+	if err := remote.Deploy(group, artifact, version, files); err != nil {
+		return err
+	}
+	fmt.Printf("Successfully published %s to %s\n", maven.GAV(group, artifact, version), remote.Name)
+	return nil
+}
 
-			// pkg path can be empty if packaging=pom was encountered
-			if len(pkg.Path) > 0 {
-				jars[pkg.Path] = struct{}{}
-			}
-		}
-		// Recursively collect transitive dependencies
-		for _, dep := range pkg.Transitive {
-			if err := addPkg(dep); err != nil {
-				return err
-			}
-		}
-		return nil
+// ResolveDependencies resolves the dependency graph of a single module, without the modules it references.
+func (j *Builder) ResolveDependencies(module *project.Module) error {
+	applied, managed, err := j.applyPlatforms(module)
+	if err != nil {
+		return err
 	}
+	j.repo.SetJavaVersion(targetJavaVersion(module.CompileArgs))
+	_, err = j.resolveGraph(applied.Dependencies, managed)
+	return err
+}
 
-	// Add the package dependencies for this module and each of its referenced modules
-	err = j.ResolveDependencies(module)
+func (j *Builder) getBuildDependencies(module *project.Module) ([]string, error) {
+	resolution, err := j.ResolveModule(module)
 	if err != nil {
 		return nil, err
 	}
-	for _, dep := range module.Dependencies {
-		if err := addPkg(dep); err != nil {
-			return nil, err
-		}
-	}
-	for _, ref := range refs {
-		// add module jar
-		dep := j.getModulePackage(ref)
-		if err := addPkg(dep); err != nil {
-			return nil, err
-		}
-		// add module dependencies
-		err := j.ResolveDependencies(ref)
+	return resolution.CompileClasspath(), nil
+}
+
+// relativeSources makes the paths of sources found in dir relative to the module dir.
+func relativeSources(module *project.Module, dir string, sources []project.SourceFileInfo) ([]project.SourceFileInfo, error) {
+	for i, source := range sources {
+		path, err := filepath.Rel(module.ModuleDirAbs, filepath.Join(dir, source.Path))
 		if err != nil {
 			return nil, err
 		}
-		for _, dep := range ref.Dependencies {
-			if err := addPkg(dep); err != nil {
-				return nil, err
-			}
-		}
-	}
-
-	// Convert the unique jar paths in the jars set into a slice
-	jarPaths := make([]string, 0, len(jars))
-	for jar := range jars {
-		jarPaths = append(jarPaths, jar)
+		sources[i].Path = path
 	}
-
-	return jarPaths, nil
+	return sources, nil
 }
 
 func (j *Builder) getModulePackage(ref *project.Module) *project.Dependency {
@@ -544,7 +647,10 @@ func (j *Builder) getModulePackage(ref *project.Module) *project.Dependency {
 		Artifact:    ref.Name,
 		Version:     ref.Version,
 		Coordinates: maven.GAV(ref.Group, ref.Name, ref.Version),
-		Transitive:  make([]*project.Dependency, 0),
+		Transitive: slices.DeleteFunc(slices.Clone(ref.Dependencies), func(dep *project.Dependency) bool {
+			// only what the module needs at runtime is passed on to modules that use it
+			return dep.Optional || dep.Scope != "" && dep.Scope != project.ScopeRuntime
+		}),
 	}
 }
 
@@ -561,13 +667,21 @@ func (j *Builder) RunTest(module *project.Module) {
 	buildClasses := filepath.Join(buildTmpDir, "classes")
 	testResultsDir := filepath.Join(buildTmpDir, "test-results")
 
+	testClasses := buildClasses
+	if module.TestSourceDirAbs != "" {
+		testClasses = filepath.Join(buildTmpDir, "test-classes")
+	}
+
 	// Absolute paths to all jar dependencies
-	compileClasspath, err := j.getBuildDependencies(module)
+	resolution, err := j.ResolveModule(module)
 	if j.logger.CheckError("getting build dependencies", err) {
 		return
 	}
-	compileClasspath = append(compileClasspath, buildClasses)
-	classPath := strings.Join(compileClasspath, string(os.PathListSeparator))
+	testClasspath := append(resolution.TestClasspath(), buildClasses)
+	if testClasses != buildClasses {
+		testClasspath = append(testClasspath, testClasses)
+	}
+	classPath := strings.Join(testClasspath, string(os.PathListSeparator))
 	//buildArgsPath := filepath.Join(buildTmpDir, "test-classpath.txt")
 	//buildArgs := fmt.Sprintf("-cp %s\n", classPath)
 	//err = project.WriteFile(buildArgsPath, buildArgs)
@@ -584,7 +698,7 @@ func (j *Builder) RunTest(module *project.Module) {
 		MainClass: "org.junit.platform.console.ConsoleLauncher",
 		ProgramArgs: []string{
 			"execute",
-			"--scan-classpath", buildClasses,
+			"--scan-classpath", testClasses,
 			"--details=tree",
 			"--reports-dir", testResultsDir,
 		},
@@ -3,12 +3,14 @@ package builder
 import (
 	"crypto/sha1"
 	"encoding/hex"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/jsando/jb/maven"
 	"github.com/jsando/jb/project"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -175,6 +177,57 @@ func TestCompileJava_Success(t *testing.T) {
 	assert.Equal(t, "/test/project", call.WorkDir)
 }
 
+func TestBuildSourcesJar(t *testing.T) {
+	mockJar := &MockJarTool{}
+	builder := NewBuilderWithTools(&MockBuildLog{}, &MockToolProvider{JarTool: mockJar})
+	module := &project.Module{
+		ModuleDirAbs: "/test/project",
+		SourceDirAbs: "/test/project/src",
+		Name:         "app",
+		Version:      "1.0",
+	}
+
+	err := builder.buildSourcesJar(module, "", []project.SourceFileInfo{{Path: "src/com/example/Main.java"}})
+
+	require.NoError(t, err)
+	require.Len(t, mockJar.CreateCalls, 1)
+	call := mockJar.CreateCalls[0]
+	assert.Equal(t, "/test/project/build/app-1.0-sources.jar", call.JarFile)
+	assert.Equal(t, "/test/project/src", call.BaseDir)
+	assert.Equal(t, []string{"com/example/Main.java"}, call.Files)
+}
+
+func TestBuildJavadocJar(t *testing.T) {
+	mockJar := &MockJarTool{}
+	mockJavadoc := &MockJavadocTool{}
+	builder := NewBuilderWithTools(&MockBuildLog{}, &MockToolProvider{JarTool: mockJar, JavadocTool: mockJavadoc})
+	moduleDir := t.TempDir()
+	module := &project.Module{
+		ModuleDirAbs: moduleDir,
+		Name:         "app",
+		Version:      "1.0",
+		JavadocArgs:  []string{"-Xdoclint:none"},
+	}
+	buildTmpDir := filepath.Join(moduleDir, "build", "tmp")
+
+	err := builder.buildJavadocJar(module, "", buildTmpDir, "lib.jar", []project.SourceFileInfo{{Path: "src/Main.java"}})
+
+	require.NoError(t, err)
+	require.Len(t, mockJavadoc.GenerateCalls, 1)
+	generate := mockJavadoc.GenerateCalls[0]
+	assert.Equal(t, []string{"src/Main.java"}, generate.SourceFiles)
+	assert.Equal(t, "lib.jar", generate.ClassPath)
+	assert.Equal(t, filepath.Join(buildTmpDir, "javadoc"), generate.DestDir)
+	assert.Equal(t, []string{"-Xdoclint:none"}, generate.ExtraFlags)
+	require.Len(t, mockJar.CreateCalls, 1)
+	assert.Equal(t, filepath.Join(moduleDir, "build", "app-1.0-javadoc.jar"), mockJar.CreateCalls[0].JarFile)
+	assert.Equal(t, generate.DestDir, mockJar.CreateCalls[0].BaseDir)
+
+	mockJavadoc.IsAvailableFunc = func() bool { return false }
+	err = builder.buildJavadocJar(module, "", buildTmpDir, "", nil)
+	assert.ErrorContains(t, err, "javadoc tool not found")
+}
+
 func TestCompileJava_CompilerNotAvailable(t *testing.T) {
 	// Setup
 	mockCompiler := &MockJavaCompiler{
@@ -501,6 +554,23 @@ func TestGetBuildDependencies(t *testing.T) {
 	assert.Contains(t, deps, "/repo/lib3-3.0.0.jar")
 }
 
+func TestGetBuildDependencies_LeavesOutTestAndRuntimeScopes(t *testing.T) {
+	builder := NewBuilder(&MockBuildLog{})
+	module := &project.Module{
+		Dependencies: []*project.Dependency{
+			{Group: "org.example", Artifact: "lib", Version: "1.0.0", Path: "/repo/lib-1.0.0.jar"},
+			{Group: "junit", Artifact: "junit", Version: "4.13.2", Path: "/repo/junit-4.13.2.jar", Scope: project.ScopeTest},
+			{Group: "org.postgresql", Artifact: "postgresql", Version: "42.7.4", Path: "/repo/postgresql-42.7.4.jar", Scope: project.ScopeRuntime},
+			{Group: "org.projectlombok", Artifact: "lombok", Version: "1.18.34", Path: "/repo/lombok-1.18.34.jar", Scope: project.ScopeCompileOnly},
+		},
+	}
+
+	deps, err := builder.getBuildDependencies(module)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/repo/lib-1.0.0.jar", "/repo/lombok-1.18.34.jar"}, deps)
+}
+
 func TestBuild_UpToDate(t *testing.T) {
 	// Setup
 	tempDir := t.TempDir()
@@ -601,6 +671,55 @@ func TestBuild_WithSources(t *testing.T) {
 	assert.Equal(t, jarPath, mockJar.CreateCalls[0].JarFile)
 }
 
+func TestBuild_TestSourceDir(t *testing.T) {
+	tempDir := t.TempDir()
+	for path, content := range map[string]string{
+		"src/main/java/Main.java":     "public class Main {}",
+		"src/test/java/MainTest.java": "public class MainTest {}",
+	} {
+		path = filepath.Join(tempDir, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	mockCompiler := &MockJavaCompiler{
+		IsAvailableFunc: func() bool { return true },
+		VersionFunc: func() (JavaVersion, error) {
+			return JavaVersion{Major: 17}, nil
+		},
+		CompileFunc: func(args CompileArgs) (CompileResult, error) {
+			return CompileResult{Success: true}, nil
+		},
+	}
+	mockJar := &MockJarTool{IsAvailableFunc: func() bool { return true }}
+	logger := &MockBuildLog{}
+	builder := NewBuilderWithTools(logger, &MockToolProvider{Compiler: mockCompiler, JarTool: mockJar})
+
+	// the test source dir is inside the source dir, like a maven layout with source_dir "src"
+	module := &project.Module{
+		ModuleDirAbs:     tempDir,
+		SourceDirAbs:     filepath.Join(tempDir, "src"),
+		TestSourceDirAbs: filepath.Join(tempDir, "src", "test"),
+		ResourceDirAbs:   filepath.Join(tempDir, "src", "main", "resources"),
+		Name:             "test-project",
+		Version:          "1.0.0",
+		ModuleFileBytes:  []byte("module content"),
+		Dependencies: []*project.Dependency{
+			{Group: "org.junit.jupiter", Artifact: "junit-jupiter", Version: "5.9.0", Path: "/repo/junit-jupiter-5.9.0.jar", Scope: project.ScopeTest},
+		},
+	}
+
+	builder.Build(module)
+
+	require.False(t, logger.failed, "Build should not have failed")
+	require.Len(t, mockCompiler.CompileCalls, 2)
+	main, test := mockCompiler.CompileCalls[0], mockCompiler.CompileCalls[1]
+	assert.Equal(t, []string{filepath.Join("src", "main", "java", "Main.java")}, main.SourceFiles)
+	assert.NotContains(t, main.ClassPath, "junit-jupiter")
+	assert.Equal(t, []string{filepath.Join("src", "test", "java", "MainTest.java")}, test.SourceFiles)
+	assert.Contains(t, test.ClassPath, "/repo/junit-jupiter-5.9.0.jar")
+	assert.Equal(t, filepath.Join(tempDir, "build", "tmp", "test-classes"), test.DestDir)
+}
+
 func TestBuild_WithResources(t *testing.T) {
 	// Setup
 	tempDir := t.TempDir()
@@ -700,6 +819,128 @@ func TestBuild_CompilationFailure(t *testing.T) {
 	assert.Len(t, mockJar.CreateCalls, 0, "Jar tool should not have been called")
 }
 
+func TestWritePOM_Exclusions(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "build"), 0755))
+
+	builder := NewBuilder(&MockBuildLog{})
+	dep, err := project.ParseDependency("org.apache.httpcomponents:httpclient:4.5.14 !commons-logging:commons-logging")
+	require.NoError(t, err)
+	module := &project.Module{
+		ModuleDirAbs: tempDir,
+		Group:        "com.example",
+		Name:         "app",
+		Version:      "1.0.0",
+		Dependencies: []*project.Dependency{dep},
+	}
+
+	require.NoError(t, builder.writePOM(module))
+
+	data, err := os.ReadFile(filepath.Join(tempDir, "build", "app-1.0.0.pom"))
+	require.NoError(t, err)
+	var pom maven.POM
+	require.NoError(t, xml.Unmarshal(data, &pom))
+	require.Len(t, pom.Dependencies, 1)
+	assert.Equal(t, []maven.Exclusion{{GroupID: "commons-logging", ArtifactID: "commons-logging"}}, pom.Dependencies[0].Exclusions)
+}
+
+func TestWritePOM_Scopes(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "build"), 0755))
+
+	builder := NewBuilder(&MockBuildLog{})
+	module := &project.Module{
+		ModuleDirAbs: tempDir,
+		Group:        "com.example",
+		Name:         "app",
+		Version:      "1.0.0",
+	}
+	for spec, scope := range map[string]string{
+		"org.lib:common:1.0":               "",
+		"junit:junit:4.13.2":               project.ScopeTest,
+		"org.postgresql:postgresql:42.7.4": project.ScopeRuntime,
+		"jakarta.servlet:servlet-api:6.0":  project.ScopeProvided,
+		"org.projectlombok:lombok:1.18.34": project.ScopeCompileOnly,
+	} {
+		dep, err := project.ParseDependency(spec)
+		require.NoError(t, err)
+		dep.Scope = scope
+		module.Dependencies = append(module.Dependencies, dep)
+	}
+
+	require.NoError(t, builder.writePOM(module))
+
+	data, err := os.ReadFile(filepath.Join(tempDir, "build", "app-1.0.0.pom"))
+	require.NoError(t, err)
+	var pom maven.POM
+	require.NoError(t, xml.Unmarshal(data, &pom))
+	scopes := make(map[string]string)
+	for _, dep := range pom.Dependencies {
+		scopes[dep.GroupID+":"+dep.ArtifactID] = dep.Scope
+	}
+	// compile only dependencies are left out
+	assert.Equal(t, map[string]string{
+		"org.lib:common":              "",
+		"junit:junit":                 "test",
+		"org.postgresql:postgresql":   "runtime",
+		"jakarta.servlet:servlet-api": "provided",
+	}, scopes)
+}
+
+func TestBuildPOM(t *testing.T) {
+	optional, err := project.ParseDependency("com.google.code.gson:gson:2.11.0")
+	require.NoError(t, err)
+	optional.Optional = true
+	module := &project.Module{
+		Group:        "com.example",
+		Name:         "app",
+		Version:      "1.0.0",
+		Description:  "An example application",
+		URL:          "https://example.com/app",
+		Licenses:     []maven.License{{Name: "MIT", URL: "https://opensource.org/licenses/MIT"}},
+		Developers:   []maven.Developer{{ID: "jdoe", Name: "Jane Doe", Email: "jdoe@example.com"}},
+		SCM:          &maven.SCM{URL: "https://github.com/example/app", Connection: "scm:git:https://github.com/example/app.git"},
+		References:   []*project.Module{{Group: "com.example", Name: "core", Version: "1.0.0"}},
+		Dependencies: []*project.Dependency{optional},
+	}
+
+	pom := buildPOM(module)
+	assert.Equal(t, "An example application", pom.Description)
+	assert.Equal(t, module.Licenses, pom.Licenses)
+	assert.Equal(t, module.Developers, pom.Developers)
+	assert.Equal(t, module.SCM, pom.SCM)
+	assert.Equal(t, []maven.Dependency{
+		{GroupID: "com.example", ArtifactID: "core", Version: "1.0.0"},
+		{GroupID: "com.google.code.gson", ArtifactID: "gson", Version: "2.11.0", Optional: "true"},
+	}, pom.Dependencies)
+	assert.Empty(t, maven.CentralProblems(pom))
+
+	// the description defaults to the name
+	module.Description = ""
+	assert.Equal(t, "app", buildPOM(module).Description)
+}
+
+func TestValidateForCentral(t *testing.T) {
+	builder := NewBuilder(&MockBuildLog{})
+	module := &project.Module{
+		Group:   "com.example",
+		Name:    "app",
+		Version: "1.0.0-SNAPSHOT",
+	}
+	err := builder.ValidateForCentral(module)
+	require.Error(t, err)
+	for _, problem := range []string{
+		"module app is not valid for maven central:",
+		"missing <url>",
+		"missing <licenses>",
+		"version 1.0.0-SNAPSHOT is a snapshot",
+		"sources_jar must be enabled",
+		"artifacts must be signed",
+	} {
+		assert.Contains(t, err.Error(), problem)
+	}
+}
+
 func TestPublish(t *testing.T) {
 	// Setup
 	tempDir := t.TempDir()
@@ -723,7 +964,7 @@ func TestPublish(t *testing.T) {
 	}
 
 	// Execute
-	err := builder.Publish(module, "https://repo.example.com", "user", "pass")
+	err := builder.Publish(module, nil)
 
 	// For now, this just tests that it doesn't panic
 	// In a real test, we'd mock the maven repository
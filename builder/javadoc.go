@@ -0,0 +1,70 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultJavadocTool implements JavadocTool using the system javadoc command
+type DefaultJavadocTool struct {
+	javadocPath string
+}
+
+// NewDefaultJavadocTool creates a new DefaultJavadocTool
+func NewDefaultJavadocTool() *DefaultJavadocTool {
+	return &DefaultJavadocTool{}
+}
+
+// Generate writes the HTML documentation of the source files to the destination directory
+func (t *DefaultJavadocTool) Generate(args JavadocArgs) error {
+	if !t.IsAvailable() {
+		return fmt.Errorf("javadoc not found in PATH")
+	}
+
+	// Write the arguments to a file to avoid command line length limits
+	argsFile := filepath.Join(os.TempDir(), fmt.Sprintf("jb-javadoc-args-%d.txt", os.Getpid()))
+	defer os.Remove(argsFile)
+
+	var flags []string
+	flags = append(flags, "-d", args.DestDir, "-quiet")
+	if args.ClassPath != "" {
+		flags = append(flags, "-cp", args.ClassPath)
+	}
+	flags = append(flags, args.ExtraFlags...)
+	flags = append(flags, args.SourceFiles...)
+
+	if err := os.WriteFile(argsFile, []byte(strings.Join(flags, "\n")), 0644); err != nil {
+		return fmt.Errorf("failed to write javadoc arguments: %w", err)
+	}
+
+	cmd := exec.Command(t.javadocPath, "@"+argsFile)
+	if args.WorkDir != "" {
+		cmd.Dir = args.WorkDir
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("javadoc failed: %w\nOutput: %s", err, string(output))
+	}
+
+	return nil
+}
+
+// IsAvailable checks if the tool is available on the system
+func (t *DefaultJavadocTool) IsAvailable() bool {
+	if t.javadocPath != "" {
+		return true
+	}
+
+	// Try to find javadoc
+	path, err := exec.LookPath(GetJavadocExecutable())
+	if err != nil {
+		return false
+	}
+
+	t.javadocPath = path
+	return true
+}
@@ -0,0 +1,197 @@
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/jsando/jb/maven"
+	"github.com/jsando/jb/project"
+)
+
+// LockModule resolves the dependency graph of a module from its module file and the POMs in the
+// repository, ignoring any existing lock file, and writes the result to the module's lock file.
+func (j *Builder) LockModule(module *project.Module) error {
+	refs, err := module.GetModuleReferencesInBuildOrder()
+	if err != nil {
+		return fmt.Errorf("failed to resolve references for module %s: %w", module.Name, err)
+	}
+	applied, appliedRefs, managed, err := j.applyModulePlatforms(module, refs)
+	if err != nil {
+		return err
+	}
+	resolution, err := j.resolveModuleGraph(applied, appliedRefs, managed)
+	if err != nil {
+		return err
+	}
+	lock := &project.LockFileJSON{
+		Dependencies: declaredDependencies(applied, appliedRefs),
+		Packages:     make([]project.LockedPackageJSON, 0, len(resolution.Dependencies)),
+	}
+	referenced := make(map[string]bool)
+	for _, ref := range refs {
+		referenced[maven.GAV(ref.Group, ref.Name, ref.Version)] = true
+	}
+	for _, dep := range resolution.Dependencies {
+		gav := dep.ResolvedCoordinates()
+		if referenced[gav] {
+			// built from source, nothing to pin
+			continue
+		}
+		pkg := project.LockedPackageJSON{Coordinates: gav, Scope: resolution.Scope(dep)}
+		if parent := resolution.Parent(dep); parent != nil {
+			pkg.Parent = parent.ResolvedCoordinates()
+		}
+		pomPath, err := j.repo.GetPOMPath(dep.Group, dep.Artifact, dep.Version)
+		if err != nil {
+			return err
+		}
+		pkg.POMSHA256, err = sha256File(pomPath)
+		if err != nil {
+			return err
+		}
+		if dep.Path != "" {
+			pkg.JarSHA256, err = sha256File(dep.Path)
+			if err != nil {
+				return err
+			}
+		}
+		lock.Packages = append(lock.Packages, pkg)
+	}
+	err = project.WriteLockFile(filepath.Join(module.ModuleDirAbs, project.LockFilename), lock)
+	if err != nil {
+		return err
+	}
+	module.Lock = lock
+	return nil
+}
+
+// resolveLocked rebuilds the dependency graph recorded in the module's lock file, verifying that
+// the lock file still matches the declared dependencies and that every cached file still has
+// the recorded checksum.
+func (j *Builder) resolveLocked(module *project.Module, refs []*project.Module) (*Resolution, error) {
+	if !slices.Equal(module.Lock.Dependencies, declaredDependencies(module, refs)) {
+		return nil, fmt.Errorf("%s is out of date for module %s, run 'jb lock --update'", project.LockFilename, module.Name)
+	}
+	nodes := make(map[string]*project.Dependency)
+	roots := make([]*project.Dependency, 0)
+	for _, ref := range refs {
+		node := j.getModulePackage(ref)
+		node.Transitive = make([]*project.Dependency, 0)
+		nodes[node.Coordinates] = node
+		roots = append(roots, node)
+	}
+	deps := make([]*project.Dependency, len(module.Lock.Packages))
+	fetches := newFetcher(j.downloadWorkers)
+	for i, pkg := range module.Lock.Packages {
+		dep, err := project.ParseCoordinates(pkg.Coordinates)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", project.LockFilename, err)
+		}
+		dep.Transitive = make([]*project.Dependency, 0)
+		deps[i] = dep
+		fetches.start(dep, func() error { return j.verifyLockedPackage(dep, pkg) })
+	}
+	missing := make(missingFiles, 0)
+	for i, pkg := range module.Lock.Packages {
+		dep := deps[i]
+		if err := missing.add(fetches.wait(dep)); err != nil {
+			return nil, err
+		}
+		if pkg.Parent == "" {
+			dep.Scope = pkg.Scope
+			roots = append(roots, dep)
+		} else {
+			parent, found := nodes[pkg.Parent]
+			if !found {
+				return nil, fmt.Errorf("%s: parent %s of %s is not locked", project.LockFilename, pkg.Parent, pkg.Coordinates)
+			}
+			parent.Transitive = append(parent.Transitive, dep)
+		}
+		nodes[pkg.Coordinates] = dep
+	}
+	if err := missing.err(); err != nil {
+		return nil, err
+	}
+	resolution, err := j.resolveGraph(roots, nil)
+	if err != nil {
+		return nil, err
+	}
+	// the graph only keeps the first path to each package, so use the scopes worked out when locking
+	for i, pkg := range module.Lock.Packages {
+		resolution.scopes[dependencyKey(deps[i])] = pkg.Scope
+	}
+	return resolution, nil
+}
+
+func (j *Builder) verifyLockedPackage(dep *project.Dependency, pkg project.LockedPackageJSON) error {
+	pomPath, err := j.repo.GetPOMPath(dep.Group, dep.Artifact, dep.Version)
+	if err != nil {
+		return err
+	}
+	if err := verifySHA256(pomPath, pkg.POMSHA256); err != nil {
+		return err
+	}
+	if pkg.JarSHA256 == "" {
+		return nil
+	}
+	jarPath, err := j.repo.GetArtifact(dep.Group, dep.Artifact, dep.Version, dep.Classifier, dep.Type)
+	if err != nil {
+		return err
+	}
+	if err := verifySHA256(jarPath, pkg.JarSHA256); err != nil {
+		return err
+	}
+	dep.Path = jarPath
+	return nil
+}
+
+// declaredDependencies lists the dependencies and platforms declared by a module and the modules it
+// references, sorted so that reordering the module file does not invalidate the lock file.
+func declaredDependencies(module *project.Module, refs []*project.Module) []string {
+	declared := make([]string, 0)
+	for _, m := range append([]*project.Module{module}, refs...) {
+		for _, platform := range m.Platforms {
+			declared = append(declared, platform.String()+" (platform)")
+		}
+		for _, dep := range m.Dependencies {
+			if dep.Scope != "" {
+				declared = append(declared, dep.String()+" ("+dep.Scope+")")
+			} else if dep.Optional {
+				declared = append(declared, dep.String()+" (optional)")
+			} else {
+				declared = append(declared, dep.String())
+			}
+		}
+	}
+	slices.Sort(declared)
+	return slices.Compact(declared)
+}
+
+func verifySHA256(path, expected string) error {
+	actual, err := sha256File(path)
+	if err != nil {
+		return err
+	}
+	if actual != expected {
+		return fmt.Errorf("checksum mismatch for %s: sha256 is %s but %s expects %s", path, actual, project.LockFilename, expected)
+	}
+	return nil
+}
+
+func sha256File(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -0,0 +1,108 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jsando/jb/maven"
+	"github.com/jsando/jb/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLockTestModule(t *testing.T, deps ...string) (*Builder, *project.Module, string) {
+	repoDir := t.TempDir()
+	writeTestArtifact(t, repoDir, "org.app", "a", "1.0", "org.lib:common:1.0")
+	writeTestArtifact(t, repoDir, "org.lib", "common", "1.0")
+
+	builder := NewBuilder(&MockBuildLog{})
+	builder.repo = maven.NewLocalRepository(repoDir)
+	module := &project.Module{ModuleDirAbs: t.TempDir(), Name: "app", Dependencies: []*project.Dependency{}}
+	for _, spec := range deps {
+		dep, err := project.ParseDependency(spec)
+		require.NoError(t, err)
+		module.Dependencies = append(module.Dependencies, dep)
+	}
+	return builder, module, repoDir
+}
+
+func TestLockModule(t *testing.T) {
+	builder, module, _ := newLockTestModule(t, "org.app:a:1.0")
+
+	require.NoError(t, builder.LockModule(module))
+
+	data, err := os.ReadFile(filepath.Join(module.ModuleDirAbs, project.LockFilename))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"gav": "org.lib:common:1.0"`)
+	assert.Contains(t, string(data), `"parent": "org.app:a:1.0"`)
+
+	require.NotNil(t, module.Lock)
+	assert.Equal(t, []string{"org.app:a:1.0"}, module.Lock.Dependencies)
+	require.Len(t, module.Lock.Packages, 2)
+	assert.Equal(t, "", module.Lock.Packages[0].Parent)
+	assert.Len(t, module.Lock.Packages[0].JarSHA256, 64)
+	assert.Len(t, module.Lock.Packages[0].POMSHA256, 64)
+}
+
+func TestResolveModule_UsesLockFile(t *testing.T) {
+	builder, module, _ := newLockTestModule(t, "org.app:a:1.0")
+	require.NoError(t, builder.LockModule(module))
+	unlocked, err := builder.resolveModuleGraph(module, nil, nil)
+	require.NoError(t, err)
+
+	locked, err := builder.ResolveModule(module)
+	require.NoError(t, err)
+
+	assert.Equal(t, unlocked.Classpath(), locked.Classpath())
+	assert.Equal(t, "org.app:a:1.0", locked.Parent(locked.Dependencies[1]).Coordinates)
+}
+
+func TestResolveModule_LockFileOutOfDate(t *testing.T) {
+	builder, module, _ := newLockTestModule(t, "org.app:a:1.0")
+	require.NoError(t, builder.LockModule(module))
+
+	extra, err := project.ParseDependency("org.lib:common:1.0")
+	require.NoError(t, err)
+	module.Dependencies = append(module.Dependencies, extra)
+
+	_, err = builder.ResolveModule(module)
+	assert.EqualError(t, err, "jb-lock.json is out of date for module app, run 'jb lock --update'")
+}
+
+func TestResolveModule_LockFileChecksumMismatch(t *testing.T) {
+	builder, module, repoDir := newLockTestModule(t, "org.app:a:1.0")
+	require.NoError(t, builder.LockModule(module))
+
+	jarPath := filepath.Join(repoDir, "org", "lib", "common", "1.0", "common-1.0.jar")
+	require.NoError(t, os.WriteFile(jarPath, []byte("tampered"), 0644))
+
+	_, err := builder.ResolveModule(module)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch for "+jarPath)
+}
+
+func TestDeclaredDependencies(t *testing.T) {
+	dep := func(spec string) *project.Dependency {
+		d, err := project.ParseDependency(spec)
+		require.NoError(t, err)
+		return d
+	}
+	lib := &project.Module{Dependencies: []*project.Dependency{dep("org.lib:common:1.0"), dep("org.b:b:1.0")}}
+	module := &project.Module{Dependencies: []*project.Dependency{dep("org.b:b:1.0"), dep("org.a:a:1.0 !x:y")}}
+
+	assert.Equal(t, []string{"org.a:a:1.0 !x:y", "org.b:b:1.0", "org.lib:common:1.0"},
+		declaredDependencies(module, []*project.Module{lib}))
+}
+
+func TestResolveModule_LockFileKeepsScopes(t *testing.T) {
+	builder, module, _ := newLockTestModule(t, "org.app:a:1.0")
+	module.Dependencies[0].Scope = project.ScopeTest
+	require.NoError(t, builder.LockModule(module))
+	assert.Equal(t, []string{"org.app:a:1.0 (test)"}, module.Lock.Dependencies)
+
+	locked, err := builder.ResolveModule(module)
+	require.NoError(t, err)
+	assert.Len(t, locked.TestClasspath(), 2)
+	assert.Empty(t, locked.CompileClasspath())
+}
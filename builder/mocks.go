@@ -166,13 +166,40 @@ func (m *MockJavaRunner) IsAvailable() bool {
 	return true
 }
 
+// MockJavadocTool is a mock implementation of JavadocTool for testing
+type MockJavadocTool struct {
+	GenerateFunc    func(args JavadocArgs) error
+	IsAvailableFunc func() bool
+
+	// Record of calls
+	GenerateCalls    []JavadocArgs
+	IsAvailableCalls int
+}
+
+func (m *MockJavadocTool) Generate(args JavadocArgs) error {
+	m.GenerateCalls = append(m.GenerateCalls, args)
+	if m.GenerateFunc != nil {
+		return m.GenerateFunc(args)
+	}
+	return nil
+}
+
+func (m *MockJavadocTool) IsAvailable() bool {
+	m.IsAvailableCalls++
+	if m.IsAvailableFunc != nil {
+		return m.IsAvailableFunc()
+	}
+	return true
+}
+
 // MockToolProvider is a mock implementation of ToolProvider for testing
 type MockToolProvider struct {
-	Compiler JavaCompiler
-	JarTool  JarTool
-	Runner   JavaRunner
-	JDKInfo  *JDKInfo
-	JDKError error
+	Compiler    JavaCompiler
+	JarTool     JarTool
+	Runner      JavaRunner
+	JavadocTool JavadocTool
+	JDKInfo     *JDKInfo
+	JDKError    error
 }
 
 func (m *MockToolProvider) GetCompiler() JavaCompiler {
@@ -196,6 +223,13 @@ func (m *MockToolProvider) GetRunner() JavaRunner {
 	return &MockJavaRunner{}
 }
 
+func (m *MockToolProvider) GetJavadocTool() JavadocTool {
+	if m.JavadocTool != nil {
+		return m.JavadocTool
+	}
+	return &MockJavadocTool{}
+}
+
 func (m *MockToolProvider) DetectJDK() (*JDKInfo, error) {
 	if m.JDKError != nil {
 		return nil, m.JDKError
@@ -0,0 +1,148 @@
+package builder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/jsando/jb/maven"
+	"github.com/jsando/jb/project"
+)
+
+// DependencyUpdate lists the newer versions available for a direct dependency of a module.
+// The latest fields are empty if there is no newer version of that kind.
+type DependencyUpdate struct {
+	Module      string `json:"module"`
+	Dependency  string `json:"dependency"` // group:artifact
+	Current     string `json:"current"`
+	LatestPatch string `json:"latest_patch,omitempty"` // newest version with the same major and minor version
+	LatestMinor string `json:"latest_minor,omitempty"` // newest version with the same major version
+	LatestMajor string `json:"latest_major,omitempty"` // newest version
+}
+
+// Outdated returns true if there is any newer version.
+func (u DependencyUpdate) Outdated() bool {
+	return u.LatestMajor != ""
+}
+
+// DependencyUpdates compares the direct dependencies of a module to the versions listed in
+// their maven-metadata.xml. Pre-releases are only considered if preReleases is set.
+func (j *Builder) DependencyUpdates(module *project.Module, preReleases bool) ([]DependencyUpdate, error) {
+	// dependencies declared without a version get it from the module's platforms
+	applied, _, err := j.applyPlatforms(module)
+	if err != nil {
+		return nil, err
+	}
+	updates := make([]DependencyUpdate, 0, len(applied.Dependencies))
+	for _, dep := range applied.Dependencies {
+		metadata, err := j.repo.GetMetadata(dep.Group, dep.Artifact)
+		if err != nil {
+			return nil, err
+		}
+		current, err := j.repo.ResolveVersion(dep.Group, dep.Artifact, dep.Version)
+		if err != nil {
+			return nil, err
+		}
+		update := DependencyUpdate{
+			Module:     module.Name,
+			Dependency: dep.Group + ":" + dep.Artifact,
+			Current:    current,
+		}
+		update.LatestPatch, update.LatestMinor, update.LatestMajor = latestVersions(current, metadata.Versioning.Versions, preReleases)
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
+
+// latestVersions returns the newest versions newer than current that share its major and minor
+// version, that share its major version, and of any version.
+func latestVersions(current string, versions []string, preReleases bool) (string, string, string) {
+	currentVersion := maven.ParseVersion(current)
+	currentNumbers := currentVersion.Numbers()
+	var patch, minor, major *maven.Version
+	for _, s := range versions {
+		version := maven.ParseVersion(s)
+		if version.Compare(currentVersion) <= 0 || (!preReleases && version.IsPreRelease()) {
+			continue
+		}
+		numbers := version.Numbers()
+		if major == nil || version.Compare(*major) > 0 {
+			major = &version
+		}
+		if versionNumber(numbers, 0) != versionNumber(currentNumbers, 0) {
+			continue
+		}
+		if minor == nil || version.Compare(*minor) > 0 {
+			minor = &version
+		}
+		if versionNumber(numbers, 1) != versionNumber(currentNumbers, 1) {
+			continue
+		}
+		if patch == nil || version.Compare(*patch) > 0 {
+			patch = &version
+		}
+	}
+	return versionString(patch), versionString(minor), versionString(major)
+}
+
+// versionNumber returns the i'th number of a version, where missing numbers count as 0 so that
+// "2" and "2.0" have the same minor version.
+func versionNumber(numbers []int, i int) int {
+	if i < len(numbers) {
+		return numbers[i]
+	}
+	return 0
+}
+
+func versionString(v *maven.Version) string {
+	if v == nil {
+		return ""
+	}
+	return v.String()
+}
+
+// WriteDependencyUpdates writes the updates as a table, or as JSON if format is "json".
+func WriteDependencyUpdates(w io.Writer, updates []DependencyUpdate, format string) error {
+	switch format {
+	case "", "text":
+		if len(updates) == 0 {
+			fmt.Fprintln(w, "No dependencies.")
+			return nil
+		}
+		table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "MODULE\tDEPENDENCY\tCURRENT\tPATCH\tMINOR\tMAJOR")
+		outdated := 0
+		for _, update := range updates {
+			if update.Outdated() {
+				outdated++
+			}
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\n", update.Module, update.Dependency, update.Current,
+				orDash(update.LatestPatch), orDash(update.LatestMinor), orDash(update.LatestMajor))
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+		if outdated == 0 {
+			fmt.Fprintln(w, "All dependencies are up to date.")
+		} else {
+			fmt.Fprintf(w, "%d of %d dependencies are outdated.\n", outdated, len(updates))
+		}
+	case "json":
+		data, err := json.MarshalIndent(updates, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(data))
+	default:
+		return fmt.Errorf("unknown output format '%s', must be text or json", format)
+	}
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
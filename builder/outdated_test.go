@@ -0,0 +1,90 @@
+package builder
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jsando/jb/maven"
+	"github.com/jsando/jb/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestVersions(t *testing.T) {
+	versions := []string{"4.12", "4.12.1", "4.13-beta-1", "4.13", "4.13.2", "5.0.0-M1", "5.0.0", "5.1.0-RC1", "5.1.0"}
+
+	patch, minor, major := latestVersions("4.12", versions, false)
+	assert.Equal(t, []string{"4.12.1", "4.13.2", "5.1.0"}, []string{patch, minor, major})
+
+	patch, minor, major = latestVersions("5.1.0", versions, false)
+	assert.Equal(t, []string{"", "", ""}, []string{patch, minor, major})
+
+	patch, minor, major = latestVersions("5.0", versions, true)
+	assert.Equal(t, []string{"", "5.1.0", "5.1.0"}, []string{patch, minor, major})
+
+	// pre-releases are only candidates when asked for
+	patch, minor, major = latestVersions("4.12.1", []string{"4.12.1", "4.12.2-rc1", "4.13-beta-1"}, false)
+	assert.Equal(t, []string{"", "", ""}, []string{patch, minor, major})
+	patch, minor, major = latestVersions("4.12.1", []string{"4.12.1", "4.12.2-rc1", "4.13-beta-1"}, true)
+	assert.Equal(t, []string{"4.12.2-rc1", "4.13-beta-1", "4.13-beta-1"}, []string{patch, minor, major})
+}
+
+func TestDependencyUpdates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/junit/junit/maven-metadata.xml":
+			_, _ = w.Write([]byte(`<metadata><versioning><versions><version>4.12</version><version>4.13.2</version><version>5.0-alpha</version></versions></versioning></metadata>`))
+		case "/org/lib/common/maven-metadata.xml":
+			_, _ = w.Write([]byte(`<metadata><versioning><versions><version>1.0</version><version>1.5</version></versions></versioning></metadata>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	builder := NewBuilder(&MockBuildLog{})
+	builder.repo = maven.NewLocalRepository(t.TempDir(), server.URL)
+	builder.repo.SetChecksumPolicy(maven.ChecksumIgnore)
+	junit, _ := project.ParseCoordinates("junit:junit:4.12")
+	common, _ := project.ParseCoordinates("org.lib:common:[1.0,2.0)")
+	module := &project.Module{Name: "app", Dependencies: []*project.Dependency{junit, common}}
+
+	// only the report goes to stdout, not the progress of fetching the metadata
+	var updates []DependencyUpdate
+	var err error
+	stdout := captureStdout(t, func() {
+		updates, err = builder.DependencyUpdates(module, false)
+	})
+	require.NoError(t, err)
+	assert.Empty(t, string(stdout))
+	assert.Equal(t, []DependencyUpdate{
+		{Module: "app", Dependency: "junit:junit", Current: "4.12", LatestMinor: "4.13.2", LatestMajor: "4.13.2"},
+		{Module: "app", Dependency: "org.lib:common", Current: "1.5"},
+	}, updates)
+
+	var out strings.Builder
+	require.NoError(t, WriteDependencyUpdates(&out, updates, "text"))
+	assert.Equal(t, `MODULE  DEPENDENCY      CURRENT  PATCH  MINOR   MAJOR
+app     junit:junit     4.12     -      4.13.2  4.13.2
+app     org.lib:common  1.5      -      -       -
+1 of 2 dependencies are outdated.
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, WriteDependencyUpdates(&out, updates[1:], "json"))
+	assert.JSONEq(t, `[{"module": "app", "dependency": "org.lib:common", "current": "1.5"}]`, out.String())
+}
+
+func TestReportOutdatedDependencies_OnlyJSONOnStdout(t *testing.T) {
+	var err error
+	stdout := captureStdout(t, func() {
+		_, err = ReportOutdatedDependencies("../tests/nodeps", "json", false)
+	})
+	require.NoError(t, err)
+	var updates []DependencyUpdate
+	require.NoError(t, json.Unmarshal(stdout, &updates), string(stdout))
+	assert.Empty(t, updates)
+}
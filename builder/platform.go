@@ -0,0 +1,93 @@
+package builder
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/jsando/jb/project"
+)
+
+// platformVersions returns the versions managed by the module's platforms, the BOMs it imports,
+// by dependency key. Like imports in a POM, the first platform to manage an artifact wins.
+func (j *Builder) platformVersions(module *project.Module) (map[string]string, error) {
+	managed := make(map[string]string)
+	for _, platform := range module.Platforms {
+		pom, err := j.repo.GetPOM(platform.Group, platform.Artifact, platform.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load platform %s: %w", platform.Coordinates, err)
+		}
+		for _, pomDep := range pom.DependencyManagement.Dependencies {
+			if pomDep.Scope == "import" || pomDep.Version == "" {
+				// imported BOMs are already merged into the dependency management
+				continue
+			}
+			dep := &project.Dependency{Group: pomDep.GroupID, Artifact: pomDep.ArtifactID, Classifier: pomDep.Classifier, Type: pomDep.Type}
+			if dep.Type == "jar" {
+				dep.Type = ""
+			}
+			key := dependencyKey(dep)
+			if _, found := managed[key]; !found {
+				managed[key] = pomDep.Version
+			}
+		}
+	}
+	return managed, nil
+}
+
+// applyPlatforms returns a copy of the module in which each dependency declared without a version
+// has the version its platforms manage, and the versions the platforms manage. The module itself
+// is left as it was loaded.
+func (j *Builder) applyPlatforms(module *project.Module) (*project.Module, map[string]string, error) {
+	managed, err := j.platformVersions(module)
+	if err != nil {
+		return nil, nil, err
+	}
+	applied := *module
+	applied.Dependencies = slices.Clone(module.Dependencies)
+	for i, dep := range applied.Dependencies {
+		if dep.Version != "" {
+			continue
+		}
+		version, found := managed[dependencyKey(dep)]
+		if !found {
+			return nil, nil, fmt.Errorf("dependency %s of module %s has no version and none of its platforms manage it", dep.Coordinates, module.Name)
+		}
+		versioned := *dep
+		versioned.Version = version
+		versioned.Coordinates = versioned.ResolvedCoordinates()
+		applied.Dependencies[i] = &versioned
+	}
+	return &applied, managed, nil
+}
+
+// applyModulePlatforms applies the platforms of the module and of each module it references, and
+// returns the copies along with the versions managed by the module's own platforms.
+func (j *Builder) applyModulePlatforms(module *project.Module, refs []*project.Module) (*project.Module, []*project.Module, map[string]string, error) {
+	appliedRefs := make([]*project.Module, 0, len(refs))
+	for _, ref := range refs {
+		applied, _, err := j.applyPlatforms(ref)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		appliedRefs = append(appliedRefs, applied)
+	}
+	applied, managed, err := j.applyPlatforms(module)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return applied, appliedRefs, managed, nil
+}
+
+// managedVersion returns the dependency with the version its platforms manage, or the
+// dependency itself if they don't manage it. Platforms constrain transitive dependencies the
+// same way dependency management in a POM does.
+func managedVersion(dep *project.Dependency, managed map[string]string) *project.Dependency {
+	version, found := managed[dependencyKey(dep)]
+	if !found || version == dep.Version {
+		return dep
+	}
+	constrained := *dep
+	constrained.Version = version
+	constrained.Coordinates = constrained.ResolvedCoordinates()
+	return &constrained
+}
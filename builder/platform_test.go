@@ -0,0 +1,119 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jsando/jb/maven"
+	"github.com/jsando/jb/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPlatformTestModule returns a module on org.app:a without a version, with a platform that
+// manages a and raises the version of its dependency on org.lib:common.
+func newPlatformTestModule(t *testing.T) (*Builder, *project.Module) {
+	repoDir := t.TempDir()
+	writeTestArtifact(t, repoDir, "org.app", "a", "1.0", "org.lib:common:1.0")
+	writeTestArtifact(t, repoDir, "org.lib", "common", "1.0")
+	writeTestArtifact(t, repoDir, "org.lib", "common", "2.0")
+	bomDir := filepath.Join(repoDir, "org", "platform", "bom", "1.0")
+	require.NoError(t, os.MkdirAll(bomDir, 0755))
+	bom := `<project><groupId>org.platform</groupId><artifactId>bom</artifactId><version>1.0</version><packaging>pom</packaging>
+<dependencyManagement><dependencies>
+<dependency><groupId>org.app</groupId><artifactId>a</artifactId><version>1.0</version></dependency>
+<dependency><groupId>org.lib</groupId><artifactId>common</artifactId><version>2.0</version></dependency>
+</dependencies></dependencyManagement></project>`
+	require.NoError(t, os.WriteFile(filepath.Join(bomDir, "bom-1.0.pom"), []byte(bom), 0644))
+
+	builder := NewBuilder(&MockBuildLog{})
+	builder.repo = maven.NewLocalRepository(repoDir)
+	platform, err := project.ParseCoordinates("org.platform:bom:1.0")
+	require.NoError(t, err)
+	dep, err := project.ParseDependency("org.app:a")
+	require.NoError(t, err)
+	module := &project.Module{
+		ModuleDirAbs: t.TempDir(),
+		Group:        "org.app",
+		Name:         "app",
+		Version:      "1.0",
+		Dependencies: []*project.Dependency{dep},
+		Platforms:    []*project.Dependency{platform},
+	}
+	return builder, module
+}
+
+func TestResolveModule_Platforms(t *testing.T) {
+	builder, module := newPlatformTestModule(t)
+
+	resolution, err := builder.ResolveModule(module)
+	require.NoError(t, err)
+	// the module keeps the dependency as declared
+	assert.Equal(t, "", module.Dependencies[0].Version)
+	assert.Equal(t, "org.app:a", module.Dependencies[0].String())
+	versions := make(map[string]string)
+	for _, dep := range resolution.Dependencies {
+		versions[dep.Group+":"+dep.Artifact] = dep.Version
+	}
+	// the platform constrains the transitive dependency too
+	assert.Equal(t, map[string]string{"org.app:a": "1.0", "org.lib:common": "2.0"}, versions)
+
+	applied, _, err := builder.applyPlatforms(module)
+	require.NoError(t, err)
+	pom := buildPOM(applied)
+	assert.Equal(t, "1.0", pom.Dependencies[0].Version)
+	require.NotNil(t, pom.DependencyManagement)
+	assert.Equal(t, []maven.Dependency{{GroupID: "org.platform", ArtifactID: "bom", Version: "1.0", Type: "pom", Scope: "import"}},
+		pom.DependencyManagement.Dependencies)
+}
+
+func TestResolveModule_PlatformDoesNotManage(t *testing.T) {
+	builder, module := newPlatformTestModule(t)
+	dep, err := project.ParseDependency("org.other:lib")
+	require.NoError(t, err)
+	module.Dependencies = append(module.Dependencies, dep)
+
+	_, err = builder.ResolveModule(module)
+	assert.EqualError(t, err, "dependency org.other:lib of module app has no version and none of its platforms manage it")
+}
+
+func TestAddDependency_Platform(t *testing.T) {
+	builder, module := newPlatformTestModule(t)
+
+	lists := map[string][]string{"dependencies": {"org.app:a"}}
+	require.NoError(t, builder.addDependency(module, lists, "dependencies", "org.lib:common"))
+	assert.Equal(t, []string{"org.app:a", "org.lib:common"}, lists["dependencies"])
+}
+
+func TestPlatformVersions_ModuleOverridesProject(t *testing.T) {
+	repoDir := t.TempDir()
+	writeTestArtifact(t, repoDir, "org.lib", "common", "1.0")
+	writeTestArtifact(t, repoDir, "org.lib", "common", "2.0")
+	// each version of the BOM manages the same version of common
+	for _, version := range []string{"1.0", "2.0"} {
+		bomDir := filepath.Join(repoDir, "org", "platform", "bom", version)
+		require.NoError(t, os.MkdirAll(bomDir, 0755))
+		bom := `<project><groupId>org.platform</groupId><artifactId>bom</artifactId><version>` + version + `</version><packaging>pom</packaging>
+<dependencyManagement><dependencies>
+<dependency><groupId>org.lib</groupId><artifactId>common</artifactId><version>` + version + `</version></dependency>
+</dependencies></dependencyManagement></project>`
+		require.NoError(t, os.WriteFile(filepath.Join(bomDir, "bom-"+version+".pom"), []byte(bom), 0644))
+	}
+	projectDir := t.TempDir()
+	moduleDir := filepath.Join(projectDir, "app")
+	require.NoError(t, os.MkdirAll(moduleDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, project.ProjectFilename),
+		[]byte(`{"name": "example", "modules": ["app"], "platforms": ["org.platform:bom:1.0"]}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(moduleDir, project.ModuleFilename),
+		[]byte(`{"platforms": ["org.platform:bom:2.0"], "dependencies": ["org.lib:common"]}`), 0644))
+	loaded, _, err := project.NewModuleLoader().LoadProject(projectDir)
+	require.NoError(t, err)
+
+	builder := NewBuilder(&MockBuildLog{})
+	builder.repo = maven.NewLocalRepository(repoDir)
+	// like a child POM's dependency management, the module's BOM wins over the project's
+	managed, err := builder.platformVersions(loaded.Modules[0])
+	require.NoError(t, err)
+	assert.Equal(t, "2.0", managed["org.lib:common"])
+}
@@ -14,6 +14,7 @@ type DefaultToolProvider struct {
 	compiler JavaCompiler
 	jarTool  JarTool
 	runner   JavaRunner
+	javadoc  JavadocTool
 	jdkInfo  *JDKInfo
 }
 
@@ -46,6 +47,14 @@ func (p *DefaultToolProvider) GetRunner() JavaRunner {
 	return p.runner
 }
 
+// GetJavadocTool returns a JavadocTool instance
+func (p *DefaultToolProvider) GetJavadocTool() JavadocTool {
+	if p.javadoc == nil {
+		p.javadoc = NewDefaultJavadocTool()
+	}
+	return p.javadoc
+}
+
 // DetectJDK detects and returns information about the available JDK
 func (p *DefaultToolProvider) DetectJDK() (*JDKInfo, error) {
 	if p.jdkInfo != nil {
@@ -235,6 +244,14 @@ func GetJarExecutable() string {
 	return "jar"
 }
 
+// GetJavadocExecutable returns the platform-specific javadoc executable name
+func GetJavadocExecutable() string {
+	if runtime.GOOS == "windows" {
+		return "javadoc.exe"
+	}
+	return "javadoc"
+}
+
 // NormalizePath normalizes a file path for the current platform
 func NormalizePath(path string) string {
 	// Convert forward slashes to the platform separator
@@ -35,6 +35,13 @@ func TestDefaultToolProvider(t *testing.T) {
 	// Should return same instance on subsequent calls
 	runner2 := provider.GetRunner()
 	assert.Same(t, runner, runner2)
+
+	// Test getting javadoc tool
+	javadocTool := provider.GetJavadocTool()
+	assert.NotNil(t, javadocTool)
+	// Should return same instance on subsequent calls
+	javadocTool2 := provider.GetJavadocTool()
+	assert.Same(t, javadocTool, javadocTool2)
 }
 
 func TestDefaultToolProvider_DetectJDK(t *testing.T) {
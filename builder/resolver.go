@@ -0,0 +1,496 @@
+package builder
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jsando/jb/maven"
+	"github.com/jsando/jb/project"
+)
+
+// Resolution is the result of resolving a dependency graph with Maven's "nearest wins" mediation.
+type Resolution struct {
+	Dependencies []*project.Dependency // selected artifacts, nearest first
+	Evictions    []Eviction            // versions that lost mediation to a selected artifact
+	Omissions    []Omission            // requests for a version that was already selected
+	parents      map[*project.Dependency]*project.Dependency
+	scopes       map[string]string // effective scope by dependency key
+}
+
+// Eviction records a version of an artifact that was dropped in favor of another version.
+type Eviction struct {
+	Dependency  *project.Dependency // the version that was dropped
+	Winner      *project.Dependency // the version that was selected instead
+	RequestedBy *project.Dependency // the dependency that asked for the dropped version
+	Reason      string
+}
+
+// Omission records a request for an artifact that was left out because the same version, or
+// one in the requested range, was already selected elsewhere in the graph.
+type Omission struct {
+	Dependency  *project.Dependency // the request that was left out
+	Selected    *project.Dependency // the selected dependency it duplicates
+	RequestedBy *project.Dependency
+}
+
+// Parent returns the dependency through which dep was selected, or nil if it was a root.
+func (r *Resolution) Parent(dep *project.Dependency) *project.Dependency {
+	return r.parents[dep]
+}
+
+// Scope returns the effective scope of a selected dependency, empty for compile. A direct
+// dependency has the scope it was declared with. Any other takes the widest scope of the paths
+// that lead to it, where a path through a test dependency is test scope and so on.
+func (r *Resolution) Scope(dep *project.Dependency) string {
+	return r.scopes[dependencyKey(dep)]
+}
+
+// Classpath returns the paths of all selected jars regardless of scope, nearest first.
+func (r *Resolution) Classpath() []string {
+	return r.classpath(func(string) bool { return true })
+}
+
+// CompileClasspath returns the jars needed to compile the main sources: compile, provided and
+// compile-only scopes.
+func (r *Resolution) CompileClasspath() []string {
+	return r.classpath(func(scope string) bool {
+		return scope == "" || scope == project.ScopeProvided || scope == project.ScopeCompileOnly
+	})
+}
+
+// RuntimeClasspath returns the jars needed to run the module: compile and runtime scopes.
+func (r *Resolution) RuntimeClasspath() []string {
+	return r.classpath(func(scope string) bool {
+		return scope == "" || scope == project.ScopeRuntime
+	})
+}
+
+// TestClasspath returns the jars needed to compile and run tests: every scope but compile-only.
+func (r *Resolution) TestClasspath() []string {
+	return r.classpath(func(scope string) bool {
+		return scope != project.ScopeCompileOnly
+	})
+}
+
+func (r *Resolution) classpath(include func(scope string) bool) []string {
+	paths := make([]string, 0, len(r.Dependencies))
+	for _, dep := range r.Dependencies {
+		// path is empty if packaging=pom, and other types such as zip don't belong on the classpath
+		if strings.HasSuffix(dep.Path, ".jar") && include(r.Scope(dep)) {
+			paths = append(paths, dep.Path)
+		}
+	}
+	return paths
+}
+
+type resolveRequest struct {
+	dep        *project.Dependency
+	parent     *project.Dependency
+	depth      int
+	exclusions []project.Exclusion // exclusions declared along the path from the root
+}
+
+// scopeRequest records that parent asked for the artifact with the given key, with the scope it
+// declared for it. Direct dependencies have no parent.
+type scopeRequest struct {
+	key    string
+	parent *project.Dependency
+	scope  string
+}
+
+type selection struct {
+	dep   *project.Dependency
+	depth int
+}
+
+// missingFiles collects the files that are not in the local repository in offline mode, so they
+// can all be reported together instead of failing on the first one.
+type missingFiles []*maven.NotCachedError
+
+// add records err if it is a NotCachedError and returns any other error.
+func (m *missingFiles) add(err error) error {
+	var notCached *maven.NotCachedError
+	if !errors.As(err, &notCached) {
+		return err
+	}
+	for _, existing := range *m {
+		if *existing == *notCached {
+			return nil
+		}
+	}
+	*m = append(*m, notCached)
+	return nil
+}
+
+func (m missingFiles) err() error {
+	if len(m) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "offline and %d files are missing from the local repository, run 'jb deps fetch' while online:", len(m))
+	for _, file := range m {
+		fmt.Fprintf(&sb, "\n  %s (%s)", file.Coordinates(), file.File)
+	}
+	return errors.New(sb.String())
+}
+
+// dependencyKey identifies the artifact a dependency refers to regardless of version. Artifacts
+// with a classifier or type are distinct from the main jar, so that for example the natives of a
+// library are on the classpath next to it.
+func dependencyKey(dep *project.Dependency) string {
+	key := dep.Group + ":" + dep.Artifact
+	if dep.Classifier != "" {
+		key += ":" + dep.Classifier
+	}
+	if dep.Type != "" {
+		key += "@" + dep.Type
+	}
+	return key
+}
+
+// ResolveModule resolves the dependency graph of a module together with the modules it
+// references. The jars of referenced modules are treated as direct dependencies, so their
+// own dependencies are one level further away than the module's. If the module has a lock
+// file the graph is taken from it instead of from the POMs.
+func (j *Builder) ResolveModule(module *project.Module) (*Resolution, error) {
+	refs, err := module.GetModuleReferencesInBuildOrder()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve references for module %s: %w", module.Name, err)
+	}
+	j.repo.SetJavaVersion(targetJavaVersion(module.CompileArgs))
+	// the locked dependencies are compared with the declared ones, which need their versions
+	applied, appliedRefs, managed, err := j.applyModulePlatforms(module, refs)
+	if err != nil {
+		return nil, err
+	}
+	if module.Lock != nil {
+		return j.resolveLocked(applied, appliedRefs)
+	}
+	return j.resolveModuleGraph(applied, appliedRefs, managed)
+}
+
+// setProfileActivation activates POM profiles by the JDK that builds the modules. Without a JDK,
+// profiles that need one stay inactive.
+func (j *Builder) setProfileActivation() {
+	jdk, err := j.toolProvider.DetectJDK()
+	if err != nil {
+		return
+	}
+	activation := maven.DefaultActivationContext()
+	activation.SetJDK(javaVersionProperty(jdk.Version))
+	j.repo.SetActivationContext(activation)
+}
+
+// targetJavaVersion returns the Java version that javac args compile for, from --release or else
+// -target, such as 8 for "--release 8" or "-target 1.8". It returns 0 if they don't say, in which
+// case javac compiles for the version of the JDK.
+func targetJavaVersion(args []string) int {
+	release, target := "", ""
+	for i, arg := range args {
+		name, value, found := strings.Cut(arg, "=")
+		if !found && i+1 < len(args) {
+			value = args[i+1]
+		}
+		switch name {
+		case "--release", "-release":
+			release = value
+		case "--target", "-target":
+			target = value
+		}
+	}
+	version, _ := strconv.Atoi(strings.TrimPrefix(cmp.Or(release, target), "1."))
+	return version
+}
+
+// javaVersionProperty returns the java.version a JVM of the given version reports, such as
+// 1.8.0 or 17.0.2.
+func javaVersionProperty(version JavaVersion) string {
+	if version.Major < 9 {
+		return fmt.Sprintf("1.%d.%d", version.Major, version.Patch)
+	}
+	return fmt.Sprintf("%d.%d.%d", version.Major, version.Minor, version.Patch)
+}
+
+// resolveModuleGraph resolves the graph of a module and the modules it references, after their
+// platforms are applied.
+func (j *Builder) resolveModuleGraph(module *project.Module, refs []*project.Module, managed map[string]string) (*Resolution, error) {
+	roots := make([]*project.Dependency, 0, len(module.Dependencies)+len(refs))
+	roots = append(roots, module.Dependencies...)
+	for _, ref := range refs {
+		roots = append(roots, j.getModulePackage(ref))
+	}
+	return j.resolveGraph(roots, managed)
+}
+
+// resolveGraph walks the dependency graph breadth-first. The first version of a
+// group:artifact reached is selected, so direct dependencies always win and otherwise the
+// version nearest to the root wins. Direct dependencies are visited sorted by coordinates
+// so the result does not depend on the order they were declared in. Exclusions apply to
+// everything below the dependency that declares them. Transitive dependencies get the version
+// managed by the module's platforms, if any. In offline mode the walk continues past
+// missing files so they can all be reported at once.
+//
+// Downloads run ahead of the walk in parallel. Only the first request queued for each
+// group:artifact is fetched, since that is the one the walk will select.
+func (j *Builder) resolveGraph(roots []*project.Dependency, managed map[string]string) (*Resolution, error) {
+	j.jdkDetected.Do(j.setProfileActivation)
+	direct := slices.Clone(roots)
+	slices.SortStableFunc(direct, func(a, b *project.Dependency) int {
+		return strings.Compare(dependencyKey(a), dependencyKey(b))
+	})
+
+	result := &Resolution{
+		Dependencies: make([]*project.Dependency, 0),
+		Evictions:    make([]Eviction, 0),
+		Omissions:    make([]Omission, 0),
+		parents:      make(map[*project.Dependency]*project.Dependency),
+	}
+	selected := make(map[string]selection)
+	scopeRequests := make([]scopeRequest, 0)
+	missing := make(missingFiles, 0)
+	fetches := newFetcher(j.downloadWorkers)
+	queued := make(map[string]bool)
+	queue := make([]resolveRequest, 0, len(direct))
+	enqueue := func(req resolveRequest) {
+		queue = append(queue, req)
+		key := dependencyKey(req.dep)
+		if !queued[key] {
+			queued[key] = true
+			fetches.start(req.dep, func() error { return j.resolveDependency(req.dep) })
+		}
+	}
+	for i, dep := range direct {
+		if i > 0 && dependencyKey(direct[i-1]) == dependencyKey(dep) {
+			if direct[i-1].Version != dep.Version {
+				return nil, fmt.Errorf("dependency %s is declared with conflicting versions %s and %s",
+					dependencyKey(dep), direct[i-1].Version, dep.Version)
+			}
+			continue
+		}
+		enqueue(resolveRequest{dep: dep})
+	}
+
+	for len(queue) > 0 {
+		req := queue[0]
+		queue = queue[1:]
+		key := dependencyKey(req.dep)
+		scopeRequests = append(scopeRequests, scopeRequest{key: key, parent: req.parent, scope: req.dep.Scope})
+		if winner, exists := selected[key]; exists {
+			if satisfies(winner.dep, req.dep.Version) {
+				// circular or duplicate reference to the selected version
+				result.Omissions = append(result.Omissions, Omission{
+					Dependency:  req.dep,
+					Selected:    winner.dep,
+					RequestedBy: req.parent,
+				})
+				continue
+			}
+			result.Evictions = append(result.Evictions, Eviction{
+				Dependency:  req.dep,
+				Winner:      winner.dep,
+				RequestedBy: req.parent,
+				Reason:      evictionReason(winner.depth, req.depth),
+			})
+			continue
+		}
+		selected[key] = selection{dep: req.dep, depth: req.depth}
+		err := fetches.wait(req.dep)
+		if err := missing.add(err); err != nil {
+			return nil, err
+		}
+		result.Dependencies = append(result.Dependencies, req.dep)
+		if req.parent != nil {
+			result.parents[req.dep] = req.parent
+		}
+		exclusions := append(slices.Clip(req.exclusions), req.dep.Exclusions...)
+		for _, child := range req.dep.Transitive {
+			if isExcluded(child, exclusions) {
+				continue
+			}
+			enqueue(resolveRequest{dep: managedVersion(child, managed), parent: req.dep, depth: req.depth + 1, exclusions: exclusions})
+		}
+	}
+	if err := missing.err(); err != nil {
+		return nil, err
+	}
+	result.scopes = effectiveScopes(scopeRequests)
+	return result, nil
+}
+
+// scopeRank orders scopes from widest to narrowest, the same way maven picks the scope of an
+// artifact that is reached through several paths.
+var scopeRank = map[string]int{
+	"":                       0,
+	project.ScopeRuntime:     1,
+	project.ScopeProvided:    2,
+	project.ScopeCompileOnly: 3,
+	project.ScopeTest:        4,
+}
+
+// effectiveScopes gives every requested artifact the widest scope of the requests for it.
+// Direct dependencies keep their declared scope. Widening the scope of one artifact can widen
+// the artifacts below it, so this repeats until nothing changes.
+func effectiveScopes(requests []scopeRequest) map[string]string {
+	scopes := make(map[string]string)
+	direct := make(map[string]bool)
+	for _, req := range requests {
+		if req.parent == nil && !direct[req.key] {
+			scopes[req.key] = req.scope
+			direct[req.key] = true
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, req := range requests {
+			if req.parent == nil || direct[req.key] {
+				continue
+			}
+			parentScope, found := scopes[dependencyKey(req.parent)]
+			if !found {
+				continue
+			}
+			scope := inheritedScope(parentScope, req.scope)
+			if current, found := scopes[req.key]; !found || scopeRank[scope] < scopeRank[current] {
+				scopes[req.key] = scope
+				changed = true
+			}
+		}
+	}
+	return scopes
+}
+
+// inheritedScope returns the scope of a transitive dependency: a runtime dependency of a compile
+// dependency is runtime, otherwise it has the scope of its parent.
+func inheritedScope(parent, declared string) string {
+	if parent == "" && declared == project.ScopeRuntime {
+		return project.ScopeRuntime
+	}
+	return parent
+}
+
+// satisfies returns true if the selected dependency has the requested version or one in the
+// requested range.
+func satisfies(dep *project.Dependency, version string) bool {
+	if dep.Version == version || dep.Requested == version {
+		return true
+	}
+	if !maven.IsVersionRange(version) {
+		return false
+	}
+	versionRange, err := maven.ParseVersionRange(version)
+	return err == nil && versionRange.Contains(maven.ParseVersion(dep.Version))
+}
+
+func isExcluded(dep *project.Dependency, exclusions []project.Exclusion) bool {
+	for _, exclusion := range exclusions {
+		if exclusion.Matches(dep) {
+			return true
+		}
+	}
+	return false
+}
+
+func evictionReason(winnerDepth, depth int) string {
+	if winnerDepth == 0 {
+		return "direct dependency wins"
+	}
+	if winnerDepth < depth {
+		return fmt.Sprintf("nearer dependency wins (depth %d vs %d)", winnerDepth, depth)
+	}
+	return fmt.Sprintf("first declaration wins at equal depth %d", depth)
+}
+
+// resolveVersion replaces a version range or LATEST/RELEASE with the newest matching version
+// from the artifact's maven-metadata.xml, keeping what was requested.
+func (j *Builder) resolveVersion(dep *project.Dependency) error {
+	version, err := j.repo.ResolveVersion(dep.Group, dep.Artifact, dep.Version)
+	if err != nil {
+		return err
+	}
+	if version != dep.Version {
+		dep.Requested = dep.Version
+		dep.Version = version
+		dep.Coordinates = dep.ResolvedCoordinates()
+	}
+	return nil
+}
+
+// resolveDependency downloads the POM and jar, or the file selected by the dependency's
+// classifier and type, for a single dependency and fills in its path
+// and immediate transitive dependencies. Dependencies that already have a path or transitive
+// list (such as the jars of referenced modules) are left alone. The transitive dependencies are
+// filled in even if the jar can't be fetched.
+func (j *Builder) resolveDependency(dep *project.Dependency) error {
+	// already done?
+	if dep.Path != "" || dep.Transitive != nil {
+		return nil
+	}
+	if err := j.resolveVersion(dep); err != nil {
+		return err
+	}
+	pom, err := j.repo.GetPOM(dep.Group, dep.Artifact, dep.Version)
+	if err != nil {
+		return err
+	}
+	hasJar := false
+	switch {
+	case dep.Classifier != "" || dep.Type != "":
+		// the POM describes the main artifact, the dependency asks for another file
+		hasJar = dep.Type != "pom"
+	case pom.Packaging == "" || pom.Packaging == "jar" || pom.Packaging == "bundle":
+		hasJar = true
+	case pom.Packaging == "pom":
+		// process the POM but there is no jar
+	default:
+		return fmt.Errorf("packaging type not supported: %s", pom.Packaging)
+	}
+	transitive := make([]*project.Dependency, 0)
+	skipped := make([]*project.Dependency, 0)
+	for _, pomChild := range pom.Dependencies {
+		// test, provided and optional dependencies aren't needed by the dependency at runtime,
+		// they are kept only to report them
+		skip := pomChild.Scope == "test" || pomChild.Scope == "provided" || pomChild.Optional == "true"
+		gav := maven.GAV(pomChild.GroupID, pomChild.ArtifactID, pomChild.Version)
+		if !skip && (pomChild.GroupID == "" || pomChild.ArtifactID == "" || pomChild.Version == "") {
+			return fmt.Errorf("invalid maven package '%s' referenced from %s", gav, dep.Coordinates)
+		}
+		child := &project.Dependency{
+			Group:      pomChild.GroupID,
+			Artifact:   pomChild.ArtifactID,
+			Version:    pomChild.Version,
+			Classifier: pomChild.Classifier,
+			Type:       pomChild.Type,
+			Scope:      pomChild.Scope,
+			Optional:   pomChild.Optional == "true",
+		}
+		if child.Type == "jar" {
+			child.Type = ""
+		}
+		if child.Scope == "compile" {
+			child.Scope = ""
+		}
+		child.Coordinates = child.ResolvedCoordinates()
+		for _, exclusion := range pomChild.Exclusions {
+			child.Exclusions = append(child.Exclusions, project.Exclusion{Group: exclusion.GroupID, Artifact: exclusion.ArtifactID})
+		}
+		if skip {
+			skipped = append(skipped, child)
+			continue
+		}
+		transitive = append(transitive, child)
+	}
+	dep.Transitive = transitive
+	dep.Skipped = skipped
+	if hasJar {
+		jarPath, err := j.repo.GetArtifact(dep.Group, dep.Artifact, dep.Version, dep.Classifier, dep.Type)
+		if err != nil {
+			return err
+		}
+		dep.Path = jarPath
+	}
+	return nil
+}
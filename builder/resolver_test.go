@@ -0,0 +1,404 @@
+package builder
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jsando/jb/maven"
+	"github.com/jsando/jb/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resolvedDep creates a dependency that is already resolved so the resolver doesn't need a repository.
+func resolvedDep(group, artifact, version string, transitive ...*project.Dependency) *project.Dependency {
+	if transitive == nil {
+		transitive = []*project.Dependency{}
+	}
+	return &project.Dependency{
+		Coordinates: group + ":" + artifact + ":" + version,
+		Group:       group,
+		Artifact:    artifact,
+		Version:     version,
+		Path:        "/repo/" + artifact + "-" + version + ".jar",
+		Transitive:  transitive,
+	}
+}
+
+// writeTestArtifact installs a POM listing the given "group:artifact:version" dependencies and a
+// fake jar into a local repository directory.
+func writeTestArtifact(t *testing.T, repoDir, group, artifact, version string, deps ...string) {
+	t.Helper()
+	dir := filepath.Join(repoDir, filepath.Join(strings.Split(group, ".")...), artifact, version)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	var pomDeps strings.Builder
+	for _, dep := range deps {
+		parts := strings.Split(dep, ":")
+		fmt.Fprintf(&pomDeps, "<dependency><groupId>%s</groupId><artifactId>%s</artifactId><version>%s</version></dependency>",
+			parts[0], parts[1], parts[2])
+	}
+	pom := fmt.Sprintf(`<project><groupId>%s</groupId><artifactId>%s</artifactId><version>%s</version><dependencies>%s</dependencies></project>`,
+		group, artifact, version, pomDeps.String())
+	base := filepath.Join(dir, artifact+"-"+version)
+	require.NoError(t, os.WriteFile(base+".pom", []byte(pom), 0644))
+	require.NoError(t, os.WriteFile(base+".jar", []byte("jar "+artifact+" "+version), 0644))
+}
+
+func TestResolveGraph_NearestWins(t *testing.T) {
+	builder := NewBuilder(&MockBuildLog{})
+
+	// a -> b -> common:1.0 is deeper than c -> common:2.0, so 2.0 wins even though a is declared first
+	deep := resolvedDep("org.lib", "common", "1.0")
+	near := resolvedDep("org.lib", "common", "2.0")
+	a := resolvedDep("org.app", "a", "1.0", resolvedDep("org.app", "b", "1.0", deep))
+	c := resolvedDep("org.app", "c", "1.0", near)
+
+	resolution, err := builder.resolveGraph([]*project.Dependency{a, c}, nil)
+	require.NoError(t, err)
+
+	classpath := resolution.Classpath()
+	assert.Contains(t, classpath, "/repo/common-2.0.jar")
+	assert.NotContains(t, classpath, "/repo/common-1.0.jar")
+
+	require.Len(t, resolution.Evictions, 1)
+	eviction := resolution.Evictions[0]
+	assert.Same(t, deep, eviction.Dependency)
+	assert.Same(t, near, eviction.Winner)
+	assert.Equal(t, "b", eviction.RequestedBy.Artifact)
+	assert.Equal(t, "nearer dependency wins (depth 1 vs 2)", eviction.Reason)
+}
+
+func TestResolveGraph_DirectWins(t *testing.T) {
+	builder := NewBuilder(&MockBuildLog{})
+
+	direct := resolvedDep("org.lib", "common", "1.0")
+	app := resolvedDep("org.app", "app", "2.0", resolvedDep("org.lib", "common", "3.0"))
+
+	resolution, err := builder.resolveGraph([]*project.Dependency{app, direct}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"/repo/app-2.0.jar", "/repo/common-1.0.jar"}, resolution.Classpath())
+	require.Len(t, resolution.Evictions, 1)
+	assert.Equal(t, "3.0", resolution.Evictions[0].Dependency.Version)
+	assert.Equal(t, "direct dependency wins", resolution.Evictions[0].Reason)
+}
+
+func TestResolveGraph_DeclarationOrderDoesNotMatter(t *testing.T) {
+	builder := NewBuilder(&MockBuildLog{})
+
+	newRoots := func() []*project.Dependency {
+		return []*project.Dependency{
+			resolvedDep("org.x", "x", "1.0", resolvedDep("org.lib", "common", "1.0")),
+			resolvedDep("org.y", "y", "1.0", resolvedDep("org.lib", "common", "2.0")),
+		}
+	}
+	forward := newRoots()
+	reversed := newRoots()
+	reversed[0], reversed[1] = reversed[1], reversed[0]
+
+	first, err := builder.resolveGraph(forward, nil)
+	require.NoError(t, err)
+	second, err := builder.resolveGraph(reversed, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, first.Classpath(), second.Classpath())
+	assert.Equal(t, "first declaration wins at equal depth 1", first.Evictions[0].Reason)
+}
+
+func TestResolveGraph_ConflictingDirectVersions(t *testing.T) {
+	builder := NewBuilder(&MockBuildLog{})
+
+	_, err := builder.resolveGraph([]*project.Dependency{
+		resolvedDep("org.lib", "common", "1.0"),
+		resolvedDep("org.lib", "common", "2.0"),
+	}, nil)
+	assert.EqualError(t, err, "dependency org.lib:common is declared with conflicting versions 1.0 and 2.0")
+
+	// the same version declared twice is harmless
+	resolution, err := builder.resolveGraph([]*project.Dependency{
+		resolvedDep("org.lib", "common", "1.0"),
+		resolvedDep("org.lib", "common", "1.0"),
+	}, nil)
+	require.NoError(t, err)
+	assert.Len(t, resolution.Dependencies, 1)
+}
+
+func TestResolveModule_ReferencedModules(t *testing.T) {
+	builder := NewBuilder(&MockBuildLog{})
+
+	lib := &project.Module{
+		ModuleDirAbs: "/work/lib",
+		Group:        "com.example",
+		Name:         "lib",
+		Version:      "1.0",
+		Dependencies: []*project.Dependency{resolvedDep("org.lib", "common", "2.0")},
+	}
+	app := &project.Module{
+		ModuleDirAbs: "/work/app",
+		Group:        "com.example",
+		Name:         "app",
+		Version:      "1.0",
+		References:   []*project.Module{lib},
+		Dependencies: []*project.Dependency{resolvedDep("org.lib", "common", "1.0")},
+	}
+
+	resolution, err := builder.ResolveModule(app)
+	require.NoError(t, err)
+
+	// the module's own declaration is nearer than the one made by the referenced module
+	classpath := resolution.Classpath()
+	assert.Contains(t, classpath, builder.getModuleJarPath(lib))
+	assert.Contains(t, classpath, "/repo/common-1.0.jar")
+	assert.NotContains(t, classpath, "/repo/common-2.0.jar")
+}
+
+func TestResolveGraph_Exclusions(t *testing.T) {
+	builder := NewBuilder(&MockBuildLog{})
+
+	logging := resolvedDep("commons-logging", "commons-logging", "1.2")
+	codec := resolvedDep("commons-codec", "commons-codec", "1.11")
+	core := resolvedDep("org.apache.httpcomponents", "httpcore", "4.4.16", resolvedDep("org.slf4j", "slf4j-api", "1.7.36"))
+	client := resolvedDep("org.apache.httpcomponents", "httpclient", "4.5.14", core, logging, codec)
+	client.Exclusions = []project.Exclusion{
+		{Group: "commons-logging", Artifact: "commons-logging"},
+		{Group: "org.slf4j", Artifact: "*"},
+	}
+
+	resolution, err := builder.resolveGraph([]*project.Dependency{client}, nil)
+	require.NoError(t, err)
+
+	// exclusions apply to the whole subtree, not only immediate children
+	assert.Equal(t, []string{
+		"/repo/httpclient-4.5.14.jar",
+		"/repo/httpcore-4.4.16.jar",
+		"/repo/commons-codec-1.11.jar",
+	}, resolution.Classpath())
+}
+
+func TestResolveModule_FromRepository(t *testing.T) {
+	repoDir := t.TempDir()
+	writeTestArtifact(t, repoDir, "org.app", "a", "1.0", "org.app:b:1.0")
+	writeTestArtifact(t, repoDir, "org.app", "b", "1.0", "org.lib:common:1.0")
+	writeTestArtifact(t, repoDir, "org.app", "c", "1.0", "org.lib:common:2.0")
+	writeTestArtifact(t, repoDir, "org.lib", "common", "1.0")
+	writeTestArtifact(t, repoDir, "org.lib", "common", "2.0")
+
+	builder := NewBuilder(&MockBuildLog{})
+	builder.repo = maven.NewLocalRepository(repoDir)
+	a, _ := project.ParseCoordinates("org.app:a:1.0")
+	c, _ := project.ParseCoordinates("org.app:c:1.0")
+	module := &project.Module{Name: "app", Dependencies: []*project.Dependency{c, a}}
+
+	resolution, err := builder.ResolveModule(module)
+	require.NoError(t, err)
+
+	var coordinates []string
+	for _, dep := range resolution.Dependencies {
+		coordinates = append(coordinates, dep.Coordinates)
+	}
+	assert.Equal(t, []string{"org.app:a:1.0", "org.app:c:1.0", "org.app:b:1.0", "org.lib:common:2.0"}, coordinates)
+	assert.Same(t, a, resolution.Parent(resolution.Dependencies[2]))
+	require.Len(t, resolution.Evictions, 1)
+	assert.Equal(t, "org.lib:common:1.0", resolution.Evictions[0].Dependency.Coordinates)
+}
+
+func TestResolveModule_OfflineReportsAllMissing(t *testing.T) {
+	repoDir := t.TempDir()
+	writeTestArtifact(t, repoDir, "org.app", "a", "1.0", "org.lib:common:1.0", "org.lib:util:1.0")
+	writeTestArtifact(t, repoDir, "org.lib", "util", "1.0")
+	require.NoError(t, os.Remove(filepath.Join(repoDir, "org", "lib", "util", "1.0", "util-1.0.jar")))
+
+	builder := NewBuilder(&MockBuildLog{})
+	builder.repo = maven.NewLocalRepository(repoDir)
+	builder.repo.SetOffline(true)
+	a, _ := project.ParseCoordinates("org.app:a:1.0")
+	b, _ := project.ParseCoordinates("org.app:b:1.0")
+	module := &project.Module{Name: "app", Dependencies: []*project.Dependency{a, b}}
+
+	_, err := builder.ResolveModule(module)
+	require.Error(t, err)
+	assert.Equal(t, `offline and 3 files are missing from the local repository, run 'jb deps fetch' while online:
+  org.app:b:1.0 (b-1.0.pom)
+  org.lib:common:1.0 (common-1.0.pom)
+  org.lib:util:1.0 (util-1.0.jar)`, err.Error())
+}
+
+func TestResolveModule_ParallelDownloadsAreDeterministic(t *testing.T) {
+	repoDir := t.TempDir()
+	writeTestArtifact(t, repoDir, "org.app", "a", "1.0", "org.lib:x:1.0", "org.lib:y:1.0", "org.lib:common:1.0")
+	writeTestArtifact(t, repoDir, "org.app", "b", "1.0", "org.lib:z:1.0", "org.lib:common:2.0")
+	for _, artifact := range []string{"x", "y", "z"} {
+		writeTestArtifact(t, repoDir, "org.lib", artifact, "1.0", "org.lib:leaf:1.0")
+	}
+	writeTestArtifact(t, repoDir, "org.lib", "common", "1.0")
+	writeTestArtifact(t, repoDir, "org.lib", "common", "2.0")
+	writeTestArtifact(t, repoDir, "org.lib", "leaf", "1.0")
+
+	var expected []string
+	for _, workers := range []int{1, 2, 16} {
+		for range 5 {
+			builder := NewBuilder(&MockBuildLog{})
+			builder.repo = maven.NewLocalRepository(repoDir)
+			builder.SetDownloadWorkers(workers)
+			a, _ := project.ParseCoordinates("org.app:a:1.0")
+			b, _ := project.ParseCoordinates("org.app:b:1.0")
+			resolution, err := builder.ResolveModule(&project.Module{Name: "app", Dependencies: []*project.Dependency{b, a}})
+			require.NoError(t, err)
+			var coordinates []string
+			for _, dep := range resolution.Dependencies {
+				coordinates = append(coordinates, dep.Coordinates)
+			}
+			if expected == nil {
+				expected = coordinates
+			}
+			assert.Equal(t, expected, coordinates)
+		}
+	}
+	assert.Equal(t, []string{"org.app:a:1.0", "org.app:b:1.0", "org.lib:x:1.0", "org.lib:y:1.0", "org.lib:common:1.0", "org.lib:z:1.0", "org.lib:leaf:1.0"}, expected)
+}
+
+func TestResolveModule_VersionRanges(t *testing.T) {
+	repoDir := t.TempDir()
+	writeTestArtifact(t, repoDir, "org.app", "a", "1.0", "org.lib:common:[1.0,2.0)")
+	writeTestArtifact(t, repoDir, "org.lib", "common", "1.5")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/org/lib/common/maven-metadata.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`<metadata><versioning><versions><version>1.0</version><version>1.5</version><version>2.0</version></versions></versioning></metadata>`))
+	}))
+	t.Cleanup(server.Close)
+
+	builder := NewBuilder(&MockBuildLog{})
+	builder.repo = maven.NewLocalRepository(repoDir, server.URL)
+	builder.repo.SetChecksumPolicy(maven.ChecksumIgnore)
+	a, _ := project.ParseCoordinates("org.app:a:1.0")
+	common, _ := project.ParseCoordinates("org.lib:common:[1.2,1.5]")
+	module := &project.Module{Name: "app", Dependencies: []*project.Dependency{a}}
+
+	resolution, err := builder.ResolveModule(module)
+	require.NoError(t, err)
+	require.Len(t, resolution.Dependencies, 2)
+	resolved := resolution.Dependencies[1]
+	assert.Equal(t, "org.lib:common:1.5", resolved.Coordinates)
+	assert.Equal(t, "[1.0,2.0)", resolved.Requested)
+	assert.Equal(t, "org.lib:common:[1.0,2.0)", resolved.String())
+	assert.Empty(t, resolution.Evictions)
+
+	// a direct range resolves too, and the transitive range it satisfies is not an eviction
+	module = &project.Module{Name: "app", Dependencies: []*project.Dependency{a, common}}
+	a.Transitive = nil
+	a.Path = ""
+	resolution, err = builder.ResolveModule(module)
+	require.NoError(t, err)
+	assert.Equal(t, "1.5", common.Version)
+	assert.Empty(t, resolution.Evictions)
+}
+
+func TestResolveModule_ClassifierAndType(t *testing.T) {
+	repoDir := t.TempDir()
+	writeTestArtifact(t, repoDir, "org.lwjgl", "lwjgl", "3.3.4")
+	writeTestArtifact(t, repoDir, "org.lib", "common", "1.0")
+	dir := func(group, artifact string) string {
+		return filepath.Join(repoDir, filepath.Join(strings.Split(group, ".")...), artifact, "1.0")
+	}
+	natives := filepath.Join(repoDir, "org", "lwjgl", "lwjgl", "3.3.4", "lwjgl-3.3.4-natives-linux.jar")
+	require.NoError(t, os.WriteFile(natives, []byte("natives"), 0644))
+	tests := filepath.Join(dir("org.lib", "common"), "common-1.0-tests.jar")
+	require.NoError(t, os.WriteFile(tests, []byte("tests"), 0644))
+	require.NoError(t, os.MkdirAll(dir("org.app", "a"), 0755))
+	pom := `<project><groupId>org.app</groupId><artifactId>a</artifactId><version>1.0</version><dependencies>
+<dependency><groupId>org.lib</groupId><artifactId>common</artifactId><version>1.0</version></dependency>
+<dependency><groupId>org.lib</groupId><artifactId>common</artifactId><version>1.0</version><type>test-jar</type></dependency>
+</dependencies></project>`
+	require.NoError(t, os.WriteFile(filepath.Join(dir("org.app", "a"), "a-1.0.pom"), []byte(pom), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir("org.app", "a"), "a-1.0.jar"), []byte("a"), 0644))
+
+	builder := NewBuilder(&MockBuildLog{})
+	builder.repo = maven.NewLocalRepository(repoDir)
+	lwjgl, _ := project.ParseCoordinates("org.lwjgl:lwjgl:3.3.4")
+	lwjglNatives, _ := project.ParseCoordinates("org.lwjgl:lwjgl:3.3.4:natives-linux")
+	a, _ := project.ParseCoordinates("org.app:a:1.0")
+	module := &project.Module{Name: "app", Dependencies: []*project.Dependency{lwjglNatives, lwjgl, a}}
+
+	resolution, err := builder.ResolveModule(module)
+	require.NoError(t, err)
+	var coordinates []string
+	for _, dep := range resolution.Dependencies {
+		coordinates = append(coordinates, dep.Coordinates)
+	}
+	assert.Equal(t, []string{"org.app:a:1.0", "org.lwjgl:lwjgl:3.3.4", "org.lwjgl:lwjgl:3.3.4:natives-linux",
+		"org.lib:common:1.0", "org.lib:common:1.0@test-jar"}, coordinates)
+	assert.Empty(t, resolution.Evictions)
+	assert.Contains(t, resolution.Classpath(), natives)
+	assert.Contains(t, resolution.Classpath(), tests)
+}
+
+func TestResolveGraph_Scopes(t *testing.T) {
+	builder := NewBuilder(&MockBuildLog{})
+
+	scoped := func(dep *project.Dependency, scope string) *project.Dependency {
+		dep.Scope = scope
+		return dep
+	}
+	// shared is reached through a test dependency first, but the compile path through app wins
+	shared := resolvedDep("org.lib", "shared", "1.0")
+	driver := scoped(resolvedDep("org.lib", "driver", "1.0"), project.ScopeRuntime)
+	app := resolvedDep("org.app", "app", "1.0", driver, resolvedDep("org.lib", "shared", "1.0"))
+	junit := scoped(resolvedDep("junit", "junit", "4.13", resolvedDep("org.hamcrest", "hamcrest", "1.3"), shared), project.ScopeTest)
+	servlet := scoped(resolvedDep("jakarta", "servlet", "6.0"), project.ScopeProvided)
+	lombok := scoped(resolvedDep("org.lombok", "lombok", "1.18"), project.ScopeCompileOnly)
+
+	resolution, err := builder.resolveGraph([]*project.Dependency{junit, app, servlet, lombok}, nil)
+	require.NoError(t, err)
+
+	scopes := make(map[string]string)
+	for _, dep := range resolution.Dependencies {
+		scopes[dep.Artifact] = resolution.Scope(dep)
+	}
+	assert.Equal(t, map[string]string{
+		"app":      "",
+		"shared":   "",
+		"driver":   project.ScopeRuntime,
+		"junit":    project.ScopeTest,
+		"hamcrest": project.ScopeTest,
+		"servlet":  project.ScopeProvided,
+		"lombok":   project.ScopeCompileOnly,
+	}, scopes)
+
+	assert.ElementsMatch(t, []string{"/repo/app-1.0.jar", "/repo/shared-1.0.jar", "/repo/servlet-6.0.jar", "/repo/lombok-1.18.jar"},
+		resolution.CompileClasspath())
+	assert.ElementsMatch(t, []string{"/repo/app-1.0.jar", "/repo/shared-1.0.jar", "/repo/driver-1.0.jar"},
+		resolution.RuntimeClasspath())
+	assert.NotContains(t, resolution.TestClasspath(), "/repo/lombok-1.18.jar")
+	assert.Contains(t, resolution.TestClasspath(), "/repo/hamcrest-1.3.jar")
+	assert.Contains(t, resolution.TestClasspath(), "/repo/driver-1.0.jar")
+}
+
+func TestJavaVersionProperty(t *testing.T) {
+	assert.Equal(t, "1.8.0", javaVersionProperty(JavaVersion{Major: 8}))
+	assert.Equal(t, "17.0.2", javaVersionProperty(JavaVersion{Major: 17, Patch: 2}))
+}
+
+func TestTargetJavaVersion(t *testing.T) {
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{nil, 0},
+		{[]string{"-Xlint:all"}, 0},
+		{[]string{"--release", "17"}, 17},
+		{[]string{"--release=21", "-Xlint:all"}, 21},
+		{[]string{"-source", "1.8", "-target", "1.8"}, 8},
+		{[]string{"-target", "11", "--release", "17"}, 17},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, targetJavaVersion(tt.args), "%v", tt.args)
+	}
+}
@@ -0,0 +1,199 @@
+package builder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/jsando/jb/maven"
+	"github.com/jsando/jb/project"
+)
+
+// Statuses of the nodes in a dependency tree.
+const (
+	TreeSelected = "selected" // on the classpath
+	TreeModule   = "module"   // a referenced module, built from source
+	TreeEvicted  = "evicted"  // lost version mediation to another version
+	TreeOmitted  = "omitted"  // the selected version is already in the tree elsewhere
+	TreeSkipped  = "skipped"  // left out for its scope or because it is optional
+)
+
+// ModuleTree is the resolved dependency graph of a module, as shown by 'jb deps tree'.
+type ModuleTree struct {
+	Module       string      `json:"module"`
+	Dependencies []*TreeNode `json:"dependencies"`
+}
+
+// TreeNode is a dependency in a ModuleTree. Only selected dependencies and modules have children.
+type TreeNode struct {
+	Coordinates string      `json:"coordinates"`
+	Status      string      `json:"status"`
+	Scope       string      `json:"scope,omitempty"`    // effective scope of a selected dependency, empty for compile
+	Selected    string      `json:"selected,omitempty"` // the dependency selected instead, if evicted or omitted
+	Reason      string      `json:"reason,omitempty"`
+	Children    []*TreeNode `json:"children,omitempty"`
+}
+
+// DependencyTree resolves the dependencies of a module and returns them as a tree, including
+// the versions that were evicted, the duplicates that were omitted and the transitive
+// dependencies that were skipped along the way.
+func (j *Builder) DependencyTree(module *project.Module) (*ModuleTree, error) {
+	refs, err := module.GetModuleReferencesInBuildOrder()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve references for module %s: %w", module.Name, err)
+	}
+	resolution, err := j.ResolveModule(module)
+	if err != nil {
+		return nil, err
+	}
+	builder := &treeBuilder{
+		resolution: resolution,
+		modules:    make(map[string]bool),
+		evictions:  make(map[*project.Dependency]Eviction),
+		omissions:  make(map[*project.Dependency]Omission),
+	}
+	for _, ref := range refs {
+		builder.modules[maven.GAV(ref.Group, ref.Name, ref.Version)] = true
+	}
+	for _, eviction := range resolution.Evictions {
+		builder.evictions[eviction.Dependency] = eviction
+	}
+	for _, omission := range resolution.Omissions {
+		builder.omissions[omission.Dependency] = omission
+	}
+	tree := &ModuleTree{Module: module.Name, Dependencies: make([]*TreeNode, 0)}
+	for _, dep := range resolution.Dependencies {
+		if resolution.Parent(dep) == nil {
+			tree.Dependencies = append(tree.Dependencies, builder.node(dep))
+		}
+	}
+	return tree, nil
+}
+
+type treeBuilder struct {
+	resolution *Resolution
+	modules    map[string]bool
+	evictions  map[*project.Dependency]Eviction
+	omissions  map[*project.Dependency]Omission
+}
+
+func (b *treeBuilder) node(dep *project.Dependency) *TreeNode {
+	node := &TreeNode{Coordinates: dep.Coordinates, Status: TreeSelected, Scope: b.resolution.Scope(dep)}
+	if b.modules[dep.Coordinates] {
+		node.Status = TreeModule
+	}
+	for _, child := range dep.Transitive {
+		if eviction, found := b.evictions[child]; found {
+			node.Children = append(node.Children, &TreeNode{
+				Coordinates: child.Coordinates,
+				Status:      TreeEvicted,
+				Selected:    eviction.Winner.Coordinates,
+				Reason:      eviction.Reason,
+			})
+		} else if omission, found := b.omissions[child]; found {
+			node.Children = append(node.Children, &TreeNode{
+				Coordinates: child.Coordinates,
+				Status:      TreeOmitted,
+				Selected:    omission.Selected.Coordinates,
+			})
+		} else if b.resolution.Parent(child) == dep {
+			node.Children = append(node.Children, b.node(child))
+		}
+		// otherwise it was excluded
+	}
+	for _, child := range dep.Skipped {
+		reason := child.Scope + " scope"
+		if child.Optional {
+			reason = "optional"
+		}
+		node.Children = append(node.Children, &TreeNode{Coordinates: child.Coordinates, Status: TreeSkipped, Reason: reason})
+	}
+	return node
+}
+
+// WriteDependencyTrees writes the trees as text, or in the "json" or graphviz "dot" format.
+func WriteDependencyTrees(w io.Writer, trees []*ModuleTree, format string) error {
+	switch format {
+	case "", "text":
+		for i, tree := range trees {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			tree.PrintTree(w)
+		}
+	case "json":
+		data, err := json.MarshalIndent(trees, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(data))
+	case "dot":
+		writeDot(w, trees)
+	default:
+		return fmt.Errorf("unknown output format '%s', must be text, json, or dot", format)
+	}
+	return nil
+}
+
+// PrintTree writes the tree in the style of 'mvn dependency:tree'.
+func (t *ModuleTree) PrintTree(w io.Writer) {
+	fmt.Fprintln(w, t.Module)
+	printTreeNodes(w, t.Dependencies, "")
+}
+
+func printTreeNodes(w io.Writer, nodes []*TreeNode, indent string) {
+	for i, node := range nodes {
+		branch, childIndent := "+- ", "|  "
+		if i == len(nodes)-1 {
+			branch, childIndent = "\\- ", "   "
+		}
+		fmt.Fprintf(w, "%s%s%s\n", indent, branch, node.label())
+		printTreeNodes(w, node.Children, indent+childIndent)
+	}
+}
+
+func (n *TreeNode) label() string {
+	switch n.Status {
+	case TreeModule:
+		return n.Coordinates + " (module)"
+	case TreeEvicted:
+		return fmt.Sprintf("%s (evicted by %s, %s)", n.Coordinates, n.Selected, n.Reason)
+	case TreeOmitted:
+		if n.Selected == n.Coordinates {
+			return n.Coordinates + " (omitted for duplicate)"
+		}
+		return fmt.Sprintf("%s (omitted, %s selected)", n.Coordinates, n.Selected)
+	case TreeSkipped:
+		return fmt.Sprintf("%s (skipped, %s)", n.Coordinates, n.Reason)
+	}
+	if n.Scope != "" {
+		return fmt.Sprintf("%s (%s)", n.Coordinates, n.Scope)
+	}
+	return n.Coordinates
+}
+
+// writeDot writes a single graph with the modules as boxes. Edges to dependencies that are not
+// on the classpath are dashed and labeled with the reason.
+func writeDot(w io.Writer, trees []*ModuleTree) {
+	fmt.Fprintln(w, "digraph dependencies {")
+	for _, tree := range trees {
+		fmt.Fprintf(w, "  %q [shape=box];\n", tree.Module)
+		writeDotEdges(w, tree.Module, tree.Dependencies)
+	}
+	fmt.Fprintln(w, "}")
+}
+
+func writeDotEdges(w io.Writer, from string, nodes []*TreeNode) {
+	for _, node := range nodes {
+		switch node.Status {
+		case TreeSelected:
+			fmt.Fprintf(w, "  %q -> %q;\n", from, node.Coordinates)
+		case TreeModule:
+			fmt.Fprintf(w, "  %q [shape=box];\n", node.Coordinates)
+			fmt.Fprintf(w, "  %q -> %q;\n", from, node.Coordinates)
+		default:
+			fmt.Fprintf(w, "  %q -> %q [style=dashed, label=%q];\n", from, node.Coordinates, node.Status)
+		}
+		writeDotEdges(w, node.Coordinates, node.Children)
+	}
+}
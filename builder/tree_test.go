@@ -0,0 +1,107 @@
+package builder
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jsando/jb/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// treeTestModule returns a module whose graph has one of each kind of node: a referenced module,
+// an eviction, a duplicate, a skipped test dependency and an exclusion.
+func treeTestModule() *project.Module {
+	junit := &project.Dependency{Coordinates: "junit:junit:4.13.2", Group: "junit", Artifact: "junit", Version: "4.13.2", Scope: "test"}
+	b := resolvedDep("org.app", "b", "1.0", resolvedDep("org.lib", "common", "1.0"))
+	b.Skipped = []*project.Dependency{junit}
+	a := resolvedDep("org.app", "a", "1.0", b, resolvedDep("org.lib", "logging", "1.0"))
+	a.Exclusions = []project.Exclusion{{Group: "org.lib", Artifact: "logging"}}
+	lib := &project.Module{
+		ModuleDirAbs: "/work/lib",
+		Group:        "com.example",
+		Name:         "lib",
+		Version:      "1.0",
+		Dependencies: []*project.Dependency{resolvedDep("org.lib", "common", "2.0")},
+	}
+	return &project.Module{
+		ModuleDirAbs: "/work/app",
+		Group:        "com.example",
+		Name:         "app",
+		Version:      "1.0",
+		References:   []*project.Module{lib},
+		Dependencies: []*project.Dependency{a, resolvedDep("org.lib", "common", "2.0")},
+	}
+}
+
+func TestDependencyTree(t *testing.T) {
+	builder := NewBuilder(&MockBuildLog{})
+	tree, err := builder.DependencyTree(treeTestModule())
+	require.NoError(t, err)
+
+	var out strings.Builder
+	require.NoError(t, WriteDependencyTrees(&out, []*ModuleTree{tree}, "text"))
+	assert.Equal(t, `app
++- com.example:lib:1.0 (module)
+|  \- org.lib:common:2.0 (omitted for duplicate)
++- org.app:a:1.0
+|  \- org.app:b:1.0
+|     +- org.lib:common:1.0 (evicted by org.lib:common:2.0, direct dependency wins)
+|     \- junit:junit:4.13.2 (skipped, test scope)
+\- org.lib:common:2.0
+`, out.String())
+}
+
+func TestWriteDependencyTrees_Formats(t *testing.T) {
+	builder := NewBuilder(&MockBuildLog{})
+	tree, err := builder.DependencyTree(treeTestModule())
+	require.NoError(t, err)
+	trees := []*ModuleTree{tree}
+
+	var out strings.Builder
+	require.NoError(t, WriteDependencyTrees(&out, trees, "json"))
+	var decoded []*ModuleTree
+	require.NoError(t, json.Unmarshal([]byte(out.String()), &decoded))
+	assert.Equal(t, trees, decoded)
+
+	out.Reset()
+	require.NoError(t, WriteDependencyTrees(&out, trees, "dot"))
+	assert.Contains(t, out.String(), `"app" [shape=box];`)
+	assert.Contains(t, out.String(), `"app" -> "com.example:lib:1.0";`)
+	assert.Contains(t, out.String(), `"org.app:b:1.0" -> "org.lib:common:1.0" [style=dashed, label="evicted"];`)
+	assert.True(t, strings.HasPrefix(out.String(), "digraph dependencies {\n"))
+
+	assert.EqualError(t, WriteDependencyTrees(&out, trees, "yaml"), "unknown output format 'yaml', must be text, json, or dot")
+}
+
+// captureStdout returns everything written to stdout while run is called.
+func captureStdout(t *testing.T, run func()) []byte {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	output := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- data
+	}()
+	run()
+	require.NoError(t, w.Close())
+	return <-output
+}
+
+func TestPrintDependencyTree_OnlyJSONOnStdout(t *testing.T) {
+	var err error
+	stdout := captureStdout(t, func() {
+		err = PrintDependencyTree("../tests/nodeps", "json")
+	})
+	require.NoError(t, err)
+	var trees []ModuleTree
+	require.NoError(t, json.Unmarshal(stdout, &trees), string(stdout))
+	require.Len(t, trees, 1)
+}
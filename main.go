@@ -4,9 +4,12 @@ import (
 	"flag"
 	"fmt"
 	"github.com/jsando/jb/builder"
+	"github.com/jsando/jb/maven"
 	"github.com/pterm/pterm"
 	"os"
 	"slices"
+	"strconv"
+	"strings"
 )
 
 // Build time variables set via -ldflags
@@ -17,19 +20,28 @@ var (
 )
 
 const USAGE = `jb - The Easier Java Build Tool
-Usage: jb [command] [command-options] [arguments]
+Usage: jb [global-options] [command] [command-options] [arguments]
 
 Execute a command.
 
+Global options:
+  --offline                Resolve dependencies only from the local repository (or set JB_OFFLINE=true).
+  --refresh-snapshots      Check for new snapshot builds regardless of update policies (or set JB_REFRESH_SNAPSHOTS=true).
+  -j, --download-workers N Download up to N artifacts at the same time (default 8, or set JB_DOWNLOAD_WORKERS).
+
 Commands:
-  build    Build a module.
-  clean    Clean build outputs.
-  convert  Convert module(s) from another build system to jb.
-  help     Show command line help.
-  publish  Publish a module to the local maven repository or a remote repository.
-  run      Build and run an ExecutableJar module.
-  test     Run tests for a module.
-  version  Show version information.
+  build              Build a module.
+  cache              Manage the local repository cache.
+  clean              Clean build outputs.
+  convert            Convert module(s) from another build system to jb.
+  deps               Manage the dependencies of a module.
+  help               Show command line help.
+  lock               Write or verify the dependency lock file of a module.
+  publish            Publish a module to the local maven repository or a remote repository.
+  run                Build and run an ExecutableJar module.
+  test               Run tests for a module.
+  verify-signatures  Check the signatures of published artifacts.
+  version            Show version information.
 
 Run 'jb [command] --help' for more information on a command.`
 
@@ -46,26 +58,35 @@ func versionCommand() {
 }
 
 func main() {
-	if len(os.Args) < 2 {
+	args := globalOptions(os.Args[1:])
+	if len(args) < 1 {
 		usage(1)
 	}
 
-	command := os.Args[1]
+	command := args[0]
 	switch command {
 	case "build":
-		buildCommand(os.Args[2:])
+		buildCommand(args[1:])
+	case "cache":
+		cacheCommand(args[1:])
 	case "clean":
-		cleanCommand(os.Args[2:])
+		cleanCommand(args[1:])
 	case "convert":
-		convertCommand(os.Args[2:])
+		convertCommand(args[1:])
+	case "deps":
+		depsCommand(args[1:])
 	case "help", "-help", "--help":
 		usage(0)
+	case "lock":
+		lockCommand(args[1:])
 	case "publish":
-		publishCommand(os.Args[2:])
+		publishCommand(args[1:])
 	case "run":
-		runCommand(os.Args[2:])
+		runCommand(args[1:])
 	case "test":
-		testCommand(os.Args[2:])
+		testCommand(args[1:])
+	case "verify-signatures":
+		verifySignaturesCommand(args[1:])
 	case "version", "-v", "--version":
 		versionCommand()
 	default:
@@ -74,10 +95,63 @@ func main() {
 	}
 }
 
+// globalOptions applies the options that work with every command and returns the remaining
+// arguments. They are accepted anywhere before a "--" separator so that both 'jb --offline build'
+// and 'jb build --offline' work. They are passed on through the environment, where every
+// repository and builder created by the command picks them up.
+func globalOptions(args []string) []string {
+	remaining := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			remaining = append(remaining, args[i:]...)
+			break
+		}
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch name {
+		case "-offline", "--offline":
+			os.Setenv(maven.OfflineEnv, boolOption(name, value, hasValue))
+		case "-refresh-snapshots", "--refresh-snapshots":
+			os.Setenv(maven.RefreshSnapshotsEnv, boolOption(name, value, hasValue))
+		case "-j", "-download-workers", "--download-workers":
+			if !hasValue {
+				if i+1 == len(args) {
+					fmt.Printf("error: %s requires a number of workers\n", name)
+					os.Exit(1)
+				}
+				i++
+				value = args[i]
+			}
+			if workers, err := strconv.Atoi(value); err != nil || workers < 1 {
+				fmt.Printf("error: %s must be a positive number, not '%s'\n", name, value)
+				os.Exit(1)
+			}
+			os.Setenv(builder.DownloadWorkersEnv, value)
+		default:
+			remaining = append(remaining, arg)
+		}
+	}
+	return remaining
+}
+
+// boolOption returns the value of a boolean option, which is true unless given as --name=false
+// and the like, exiting if the value isn't a boolean.
+func boolOption(name, value string, hasValue bool) string {
+	if !hasValue {
+		return "true"
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		fmt.Printf("error: %s must be true or false, not '%s'\n", name, value)
+		os.Exit(1)
+	}
+	return strconv.FormatBool(b)
+}
+
 func buildCommand(args []string) {
 	fs := flag.NewFlagSet("build", flag.ExitOnError)
 	fs.Usage = func() {
-		fmt.Println("Usage: jb build [path]")
+		fmt.Println("Usage: jb build [--refresh-snapshots] [path]")
 		fs.PrintDefaults()
 	}
 	if err := fs.Parse(args); err != nil {
@@ -95,6 +169,49 @@ func buildCommand(args []string) {
 	}
 }
 
+func cacheCommand(args []string) {
+	fs := flag.NewFlagSet("cache", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Println("Usage: jb cache verify")
+		fmt.Println()
+		fmt.Println("Subcommands:")
+		fmt.Println("  verify   Re-hash the local repository and report corrupt or truncated files.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		fmt.Printf("error: %s\n", err)
+		os.Exit(1)
+	}
+	if fs.NArg() != 1 || fs.Arg(0) != "verify" {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if err := builder.VerifyCache(); err != nil {
+		pterm.Fatal.Printf("%s\n", err)
+	}
+}
+
+func verifySignaturesCommand(args []string) {
+	fs := flag.NewFlagSet("verify-signatures", flag.ExitOnError)
+	var keys string
+	fs.StringVar(&keys, "keys", "", "file with the armored public keys to verify with (default: the signing key)")
+	fs.Usage = func() {
+		fmt.Println("Usage: jb verify-signatures [--keys file] <dir>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		fmt.Printf("error: %s\n", err)
+		os.Exit(1)
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if err := builder.VerifySignatures(fs.Arg(0), keys); err != nil {
+		pterm.Fatal.Printf("%s\n", err)
+	}
+}
+
 func cleanCommand(args []string) {
 	fs := flag.NewFlagSet("clean", flag.ExitOnError)
 	fs.Usage = func() {
@@ -130,14 +247,235 @@ func convertCommand(args []string) {
 	builder.ConvertToJB(path)
 }
 
+func depsCommand(args []string) {
+	fs := flag.NewFlagSet("deps", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Println("Usage: jb deps <subcommand> [options] [path]")
+		fmt.Println()
+		fmt.Println("Subcommands:")
+		fmt.Println("  add      Add a dependency to a module, the latest release if no version is given.")
+		fmt.Println("  fetch    Download every dependency into the local repository for building offline.")
+		fmt.Println("  outdated Show newer versions of the direct dependencies of each module.")
+		fmt.Println("  remove   Remove a dependency from a module.")
+		fmt.Println("  tree     Show the resolved dependency graph of each module.")
+		fmt.Println("  upgrade  Upgrade the dependencies of a module, or only the one given, to their latest release.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		fmt.Printf("error: %s\n", err)
+		os.Exit(1)
+	}
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	switch fs.Arg(0) {
+	case "add":
+		depsAddCommand(fs.Args()[1:])
+	case "remove":
+		depsEditCommand(flag.NewFlagSet("deps remove", flag.ExitOnError), "<group>:<artifact>", fs.Args()[1:], builder.RemoveDependency)
+	case "upgrade":
+		depsUpgradeCommand(fs.Args()[1:])
+	case "fetch":
+		depsFetchCommand(fs.Args()[1:])
+	case "outdated":
+		depsOutdatedCommand(fs.Args()[1:])
+	case "tree":
+		depsTreeCommand(fs.Args()[1:])
+	default:
+		fs.Usage()
+		os.Exit(1)
+	}
+}
+
+func depsFetchCommand(args []string) {
+	fs := flag.NewFlagSet("deps fetch", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Println("Usage: jb deps fetch [path]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		fmt.Printf("error: %s\n", err)
+		os.Exit(1)
+	}
+	path := "."
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	if err := builder.FetchDependencies(path); err != nil {
+		pterm.Fatal.Printf("BUILD FAILED: %s\n", err)
+	}
+}
+
+func depsAddCommand(args []string) {
+	fs := flag.NewFlagSet("deps add", flag.ExitOnError)
+	var scope string
+	fs.StringVar(&scope, "scope", "compile", "scope of the dependency: compile, optional, test, runtime, provided or compile_only")
+	depsEditCommand(fs, "<group>:<artifact>[:<version>]", args, func(path, coordinates string) error {
+		return builder.AddDependency(path, coordinates, scope)
+	})
+}
+
+func depsEditCommand(fs *flag.FlagSet, argument string, args []string, edit func(path, coordinates string) error) {
+	fs.Usage = func() {
+		options := ""
+		fs.VisitAll(func(*flag.Flag) { options = " [options]" })
+		fmt.Printf("Usage: jb %s%s %s [path]\n", fs.Name(), options, argument)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		fmt.Printf("error: %s\n", err)
+		os.Exit(1)
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	path := "."
+	if fs.NArg() > 1 {
+		path = fs.Arg(1)
+	}
+	if err := edit(path, fs.Arg(0)); err != nil {
+		pterm.Fatal.Printf("%s\n", err)
+	}
+}
+
+func depsUpgradeCommand(args []string) {
+	fs := flag.NewFlagSet("deps upgrade", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Println("Usage: jb deps upgrade [<group>:<artifact>] [path]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		fmt.Printf("error: %s\n", err)
+		os.Exit(1)
+	}
+	path, coordinates := ".", ""
+	switch {
+	case fs.NArg() == 2:
+		coordinates, path = fs.Arg(0), fs.Arg(1)
+	case fs.NArg() == 1 && strings.Contains(fs.Arg(0), ":") && !fileExists(fs.Arg(0)):
+		coordinates = fs.Arg(0)
+	case fs.NArg() == 1:
+		path = fs.Arg(0)
+	case fs.NArg() > 2:
+		fs.Usage()
+		os.Exit(1)
+	}
+	if err := builder.UpgradeDependencies(path, coordinates); err != nil {
+		pterm.Fatal.Printf("%s\n", err)
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func depsOutdatedCommand(args []string) {
+	fs := flag.NewFlagSet("deps outdated", flag.ExitOnError)
+	var asJSON, preReleases, failOnOutdated bool
+	fs.BoolVar(&asJSON, "json", false, "print the report as JSON")
+	fs.BoolVar(&preReleases, "pre-releases", false, "include alpha, beta, milestone and rc versions")
+	fs.BoolVar(&failOnOutdated, "fail-on-outdated", false, "exit with status 2 if any dependency is outdated, for CI")
+	fs.Usage = func() {
+		fmt.Println("Usage: jb deps outdated [--json] [--pre-releases] [--fail-on-outdated] [path]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		fmt.Printf("error: %s\n", err)
+		os.Exit(1)
+	}
+	format := "text"
+	if asJSON {
+		format = "json"
+	}
+	path := "."
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	outdated, err := builder.ReportOutdatedDependencies(path, format, preReleases)
+	if err != nil {
+		pterm.Fatal.WithWriter(os.Stderr).Printf("%s\n", err)
+	}
+	if outdated && failOnOutdated {
+		os.Exit(2)
+	}
+}
+
+func depsTreeCommand(args []string) {
+	fs := flag.NewFlagSet("deps tree", flag.ExitOnError)
+	var asJSON, asDot bool
+	fs.BoolVar(&asJSON, "json", false, "print the tree as JSON")
+	fs.BoolVar(&asDot, "dot", false, "print the graph in graphviz dot format")
+	fs.Usage = func() {
+		fmt.Println("Usage: jb deps tree [--json | --dot] [path]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		fmt.Printf("error: %s\n", err)
+		os.Exit(1)
+	}
+	format := "text"
+	switch {
+	case asJSON && asDot:
+		fmt.Println("--json and --dot can't be used together")
+		os.Exit(1)
+	case asJSON:
+		format = "json"
+	case asDot:
+		format = "dot"
+	}
+	path := "."
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	if err := builder.PrintDependencyTree(path, format); err != nil {
+		pterm.Fatal.WithWriter(os.Stderr).Printf("%s\n", err)
+	}
+}
+
+func lockCommand(args []string) {
+	fs := flag.NewFlagSet("lock", flag.ExitOnError)
+	var update bool
+	fs.BoolVar(&update, "update", false, "resolve dependencies again and rewrite existing lock files")
+	fs.Usage = func() {
+		fmt.Println("Usage: jb lock [--update] [path]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		fmt.Printf("error: %s\n", err)
+		os.Exit(1)
+	}
+	path := "."
+	lockArgs := fs.Args()
+	if len(lockArgs) > 0 && lockArgs[0] != "--" {
+		path = lockArgs[0]
+	}
+	err := builder.LockModules(path, update)
+	if err != nil {
+		pterm.Fatal.Printf("BUILD FAILED: %s\n", err)
+	}
+}
+
 func publishCommand(args []string) {
 	fs := flag.NewFlagSet("publish", flag.ExitOnError)
 	var jarFile string
 	var gav string
+	var repo string
+	var sign bool
+	var signingKey string
+	var central bool
+	var m2 bool
 	fs.StringVar(&jarFile, "jar", "", "jar file to publish (use with --gav to set maven coordinates)")
+	fs.StringVar(&repo, "repo", "", "name or url of the remote repository to publish to, instead of the local repository")
+	fs.BoolVar(&sign, "sign", false, "sign every published file, with the key in "+maven.SigningKeyFileEnv+" or "+maven.SigningKeyEnv)
+	fs.StringVar(&signingKey, "signing-key", "", "file with the armored private key to sign with, implies --sign")
+	fs.BoolVar(&m2, "m2", false, "install into maven's local repository, ~/.m2/repository, so maven builds can use it")
+	fs.BoolVar(&central, "central", false, "check modules meet the requirements of maven central before building them")
 	fs.StringVar(&gav, "gav", "", "maven coordinates for pushing jar into maven repository")
 	fs.Usage = func() {
-		fmt.Println("Usage: jb publish [path] [--jar jarfile --gav \"group:artifact:version\"]")
+		fmt.Println("Usage: jb publish [--repo name|url | --m2] [--sign] [--central] [path] [--jar jarfile --gav \"group:artifact:version\"]")
 		fs.PrintDefaults()
 	}
 	if err := fs.Parse(args); err != nil {
@@ -149,8 +487,20 @@ func publishCommand(args []string) {
 	if len(buildArgs) > 0 && buildArgs[0] != "--" {
 		path = buildArgs[0]
 	}
+	if m2 && repo != "" {
+		fmt.Println("--m2 and --repo can't be used together")
+		os.Exit(1)
+	}
+	options := builder.PublishOptions{Repo: repo, Central: central, M2: m2}
+	if sign || signingKey != "" {
+		signer, err := maven.LoadSigner(signingKey)
+		if err != nil {
+			pterm.Fatal.Printf("%s\n", err)
+		}
+		options.Signer = signer
+	}
 	if jarFile == "" && gav == "" {
-		err := builder.BuildAndPublishModule(path)
+		err := builder.BuildAndPublishModule(path, options)
 		if err != nil {
 			pterm.Fatal.Printf("BUILD FAILED: %s\n", err)
 		}
@@ -159,7 +509,11 @@ func publishCommand(args []string) {
 			fmt.Println("jar and gav must be specified together")
 			os.Exit(1)
 		}
-		err := builder.PublishRawJAR(jarFile, gav)
+		if central {
+			fmt.Println("--central can only be used when publishing modules")
+			os.Exit(1)
+		}
+		err := builder.PublishRawJAR(jarFile, gav, options)
 		if err != nil {
 			pterm.Fatal.Printf("BUILD FAILED: %s\n", err)
 		}
@@ -0,0 +1,41 @@
+package maven
+
+import "sync"
+
+// callGroup makes concurrent calls with the same key share a single execution, so that two
+// goroutines asking for the same file only download it once.
+type callGroup[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*call[T]
+}
+
+type call[T any] struct {
+	done chan struct{}
+	val  T
+	err  error
+}
+
+// do runs fn for key unless a call for key is already in flight, in which case it waits for
+// that call and returns its result.
+func (g *callGroup[T]) do(key string, fn func() (T, error)) (T, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call[T])
+	}
+	if c, found := g.calls[key]; found {
+		g.mu.Unlock()
+		<-c.done
+		return c.val, c.err
+	}
+	c := &call[T]{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	c.val, c.err = fn()
+	close(c.done)
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	return c.val, c.err
+}
@@ -0,0 +1,42 @@
+package maven
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallGroup_SharesConcurrentCalls(t *testing.T) {
+	var group callGroup[int]
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	results := make([]int, 5)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = group.do("key", func() (int, error) {
+				<-release
+				return int(calls.Add(1)), nil
+			})
+		}()
+	}
+	// give every goroutine time to join the call in flight
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for _, result := range results {
+		assert.Equal(t, 1, result)
+	}
+
+	// once finished, the next call runs again
+	value, _ := group.do("key", func() (int, error) { return 2, nil })
+	assert.Equal(t, 2, value)
+}
@@ -0,0 +1,59 @@
+package maven
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CentralProblems returns the ways a POM falls short of the requirements maven central checks
+// before it accepts a release, or nothing if there are none.
+func CentralProblems(pom *POM) []string {
+	problems := make([]string, 0)
+	require := func(value, element string) {
+		if strings.TrimSpace(value) == "" {
+			problems = append(problems, "missing <"+element+">")
+		}
+	}
+	require(pom.GroupID, "groupId")
+	require(pom.ArtifactID, "artifactId")
+	require(pom.Version, "version")
+	require(pom.Name, "name")
+	require(pom.Description, "description")
+	require(pom.URL, "url")
+	if isSnapshot(pom.Version) {
+		problems = append(problems, fmt.Sprintf("version %s is a snapshot, only releases can be published", pom.Version))
+	}
+
+	if len(pom.Licenses) == 0 {
+		problems = append(problems, "missing <licenses>")
+	}
+	for i, license := range pom.Licenses {
+		if strings.TrimSpace(license.Name) == "" {
+			problems = append(problems, fmt.Sprintf("license %d has no <name>", i+1))
+		}
+	}
+	if len(pom.Developers) == 0 {
+		problems = append(problems, "missing <developers>")
+	}
+	for i, developer := range pom.Developers {
+		if strings.TrimSpace(developer.Name) == "" {
+			problems = append(problems, fmt.Sprintf("developer %d has no <name>", i+1))
+		}
+	}
+	if pom.SCM == nil {
+		problems = append(problems, "missing <scm>")
+	} else {
+		require(pom.SCM.URL, "scm><url")
+		require(pom.SCM.Connection, "scm><connection")
+	}
+
+	for _, dep := range pom.Dependencies {
+		ga := dep.GroupID + ":" + dep.ArtifactID
+		if dep.Version == "" {
+			problems = append(problems, fmt.Sprintf("dependency %s has no <version>", ga))
+		} else if isSnapshot(dep.Version) {
+			problems = append(problems, fmt.Sprintf("dependency %s:%s is a snapshot", ga, dep.Version))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,44 @@
+package maven
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCentralProblems(t *testing.T) {
+	pom := &POM{
+		GroupID:     "com.example",
+		ArtifactID:  "lib",
+		Version:     "1.0",
+		Name:        "lib",
+		Description: "An example library",
+		URL:         "https://example.com/lib",
+		Licenses:    []License{{Name: "MIT"}},
+		Developers:  []Developer{{Name: "Jane Doe"}},
+		SCM:         &SCM{URL: "https://github.com/example/lib", Connection: "scm:git:https://github.com/example/lib.git"},
+		Dependencies: []Dependency{
+			{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.16"},
+		},
+	}
+	assert.Empty(t, CentralProblems(pom))
+
+	pom.Version = "1.1-SNAPSHOT"
+	pom.URL = ""
+	pom.Licenses = nil
+	pom.Developers = []Developer{{ID: "jdoe"}}
+	pom.SCM.Connection = ""
+	pom.Dependencies = append(pom.Dependencies,
+		Dependency{GroupID: "org.example", ArtifactID: "unversioned"},
+		Dependency{GroupID: "org.example", ArtifactID: "snapshot", Version: "2.0-SNAPSHOT"},
+	)
+	assert.Equal(t, []string{
+		"missing <url>",
+		"version 1.1-SNAPSHOT is a snapshot, only releases can be published",
+		"missing <licenses>",
+		"developer 1 has no <name>",
+		"missing <scm><connection>",
+		"dependency org.example:unversioned has no <version>",
+		"dependency org.example:snapshot:2.0-SNAPSHOT is a snapshot",
+	}, CentralProblems(pom))
+}
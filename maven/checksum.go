@@ -0,0 +1,198 @@
+package maven
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ChecksumPolicy controls what happens when a downloaded file does not match the checksum
+// published next to it in the remote repository.
+type ChecksumPolicy string
+
+const (
+	ChecksumFail   ChecksumPolicy = "fail"   // discard the download and report an error
+	ChecksumWarn   ChecksumPolicy = "warn"   // print a warning and keep the download
+	ChecksumIgnore ChecksumPolicy = "ignore" // don't fetch checksums at all
+)
+
+// checksumAlgorithms lists the checksum file extensions we understand, strongest first.
+var checksumAlgorithms = []string{"sha256", "sha1", "md5"}
+
+func ParseChecksumPolicy(s string) (ChecksumPolicy, error) {
+	switch policy := ChecksumPolicy(strings.ToLower(s)); policy {
+	case ChecksumFail, ChecksumWarn, ChecksumIgnore:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid checksum policy '%s', must be one of fail, warn, or ignore", s)
+}
+
+func newHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case "sha256":
+		return sha256.New()
+	case "sha1":
+		return sha1.New()
+	case "md5":
+		return md5.New()
+	}
+	panic("unsupported checksum algorithm " + algorithm)
+}
+
+// checksumHashers computes every supported checksum of the data written to it.
+type checksumHashers map[string]hash.Hash
+
+func newChecksumHashers() checksumHashers {
+	hashers := make(checksumHashers)
+	for _, algorithm := range checksumAlgorithms {
+		hashers[algorithm] = newHash(algorithm)
+	}
+	return hashers
+}
+
+func (h checksumHashers) Write(p []byte) (int, error) {
+	for _, hasher := range h {
+		hasher.Write(p)
+	}
+	return len(p), nil
+}
+
+func (h checksumHashers) sum(algorithm string) string {
+	return hex.EncodeToString(h[algorithm].Sum(nil))
+}
+
+// parseChecksum extracts the hex digest from the contents of a checksum file, which some
+// repositories follow with the file name like the output of sha1sum.
+func parseChecksum(content string) string {
+	fields := strings.Fields(content)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToLower(fields[0])
+}
+
+// verifyRemoteChecksum fetches the strongest checksum published for a file and compares it with
+// the checksums computed while downloading it. It returns the verified algorithm and digest, or
+// an empty algorithm if the remote doesn't publish any checksum. Only a checksum file that isn't
+// found counts as not published, failing to fetch one is an error.
+func verifyRemoteChecksum(remote *Remote, groupID, artifactID, version, file string, hashers checksumHashers) (string, string, error) {
+	for _, algorithm := range checksumAlgorithms {
+		var buf bytes.Buffer
+		fileURL, err := remote.fileURL(groupID, artifactID, version, file+"."+algorithm)
+		if err != nil {
+			return "", "", err
+		}
+		if err := remote.fetch(fileURL, &buf); err != nil {
+			if isNotFound(err) {
+				continue
+			}
+			return "", "", fmt.Errorf("failed to fetch the %s checksum of %s: %w", algorithm, file, err)
+		}
+		expected := parseChecksum(buf.String())
+		actual := hashers.sum(algorithm)
+		if expected != actual {
+			return algorithm, actual, fmt.Errorf("checksum mismatch for %s: expected %s %s, got %s", file, algorithm, expected, actual)
+		}
+		return algorithm, actual, nil
+	}
+	return "", "", nil
+}
+
+// CacheProblem describes a corrupt file found in the local repository.
+type CacheProblem struct {
+	Path   string
+	Reason string
+}
+
+// VerifyCache re-hashes every file in the local repository against the checksum stored next to
+// it and checks for empty files and jars that can't be opened. It returns the number of files
+// checked and the problems found.
+func (c *LocalRepository) VerifyCache() (int, []CacheProblem, error) {
+	checked := 0
+	problems := make([]CacheProblem, 0)
+	baseDir := c.BaseDir()
+	if !fileExists(baseDir) {
+		return 0, problems, nil
+	}
+	err := filepath.WalkDir(baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || slices.Contains(checksumAlgorithms, strings.TrimPrefix(filepath.Ext(path), ".")) {
+			return nil
+		}
+		// lock files, downloads in progress (or abandoned) and records of missing files aren't part of the cache
+		if d.Name() == lockFileName || filepath.Ext(path) == ".part" || filepath.Ext(path) == lastUpdatedExtension {
+			return nil
+		}
+		checked++
+		if reason := verifyCachedFile(path); reason != "" {
+			problems = append(problems, CacheProblem{Path: path, Reason: reason})
+		}
+		return nil
+	})
+	return checked, problems, err
+}
+
+func verifyCachedFile(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err.Error()
+	}
+	if info.Size() == 0 {
+		return "file is empty"
+	}
+	if strings.HasSuffix(path, ".jar") {
+		reader, err := zip.OpenReader(path)
+		if err != nil {
+			return fmt.Sprintf("jar is truncated or corrupt: %s", err)
+		}
+		reader.Close()
+	}
+	return storedChecksumProblem(path)
+}
+
+// storedChecksumProblem compares a file with the strongest checksum stored next to it, returning
+// why it doesn't match or an empty string if it does or there is no stored checksum.
+func storedChecksumProblem(path string) string {
+	for _, algorithm := range checksumAlgorithms {
+		stored, err := os.ReadFile(path + "." + algorithm)
+		if err != nil {
+			continue
+		}
+		expected := parseChecksum(string(stored))
+		actual, err := hashFile(path, algorithm)
+		if err != nil {
+			return err.Error()
+		}
+		if expected != actual {
+			return fmt.Sprintf("checksum mismatch: expected %s %s, got %s", algorithm, expected, actual)
+		}
+		return ""
+	}
+	return ""
+}
+
+func hashFile(path, algorithm string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hasher := newHash(algorithm)
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -0,0 +1,176 @@
+package maven
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRemote serves the given files, keyed by path relative to the repository root.
+func newTestRemote(t *testing.T, files map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, found := files[r.URL.Path[1:]]
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestParseChecksumPolicy(t *testing.T) {
+	for _, value := range []string{"fail", "WARN", "ignore"} {
+		_, err := ParseChecksumPolicy(value)
+		assert.NoError(t, err)
+	}
+	_, err := ParseChecksumPolicy("strict")
+	assert.EqualError(t, err, "invalid checksum policy 'strict', must be one of fail, warn, or ignore")
+}
+
+func TestParseChecksum(t *testing.T) {
+	assert.Equal(t, "abc123", parseChecksum("ABC123"))
+	assert.Equal(t, "abc123", parseChecksum("abc123  lib-1.0.jar\n"))
+	assert.Equal(t, "", parseChecksum("  "))
+}
+
+func TestGetFile_VerifiesChecksum(t *testing.T) {
+	const jarPath = "com/example/lib/1.0/lib-1.0.jar"
+	server := newTestRemote(t, map[string]string{
+		jarPath:           "jar content",
+		jarPath + ".sha1": "9e5c8d4f8b0ab0f5b3dc6e9a0f9ba0bc44bd2d0e  lib-1.0.jar", // wrong
+	})
+	goodSHA1, err := hashFile(writeTemp(t, "good content"), "sha1")
+	require.NoError(t, err)
+
+	t.Run("fail", func(t *testing.T) {
+		repo := NewLocalRepository(t.TempDir(), server.URL)
+		path, err := repo.GetJAR("com.example", "lib", "1.0")
+		require.Error(t, err)
+		assert.NoFileExists(t, path)
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		repo := NewLocalRepository(t.TempDir(), server.URL)
+		repo.SetChecksumPolicy(ChecksumWarn)
+		path, err := repo.GetJAR("com.example", "lib", "1.0")
+		require.NoError(t, err)
+		assert.FileExists(t, path)
+		assert.NoFileExists(t, path+".sha1")
+	})
+
+	t.Run("ignore", func(t *testing.T) {
+		repo := NewLocalRepository(t.TempDir(), server.URL)
+		repo.SetChecksumPolicy(ChecksumIgnore)
+		_, err := repo.GetJAR("com.example", "lib", "1.0")
+		require.NoError(t, err)
+	})
+
+	t.Run("verified", func(t *testing.T) {
+		remote := newTestRemote(t, map[string]string{
+			"com/example/good/1.0/good-1.0.jar":      "good content",
+			"com/example/good/1.0/good-1.0.jar.sha1": goodSHA1 + "\n",
+		})
+		repo := NewLocalRepository(t.TempDir(), remote.URL)
+		path, err := repo.GetJAR("com.example", "good", "1.0")
+		require.NoError(t, err)
+		stored, err := os.ReadFile(path + ".sha1")
+		require.NoError(t, err)
+		assert.Equal(t, goodSHA1, string(stored))
+	})
+}
+
+func TestGetFile_ChecksumFetchFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/com/example/lib/1.0/lib-1.0.jar":
+			_, _ = w.Write([]byte("jar content"))
+		case "/com/example/lib/1.0/lib-1.0.jar.sha256":
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	none := 0
+	newRepo := func(policy ChecksumPolicy) *LocalRepository {
+		repo := NewLocalRepository(t.TempDir(), server.URL)
+		repo.SetChecksumPolicy(policy)
+		repo.remotes[0].client = newTestHTTPClient(t, &HTTPSettings{Retries: &none})
+		return repo
+	}
+
+	// the weaker checksums aren't tried, the file can't be verified
+	repo := newRepo(ChecksumFail)
+	algorithm, _, err := verifyRemoteChecksum(repo.remotes[0], "com.example", "lib", "1.0", "lib-1.0.jar", newChecksumHashers())
+	assert.ErrorContains(t, err, "failed to fetch the sha256 checksum of lib-1.0.jar")
+	assert.Empty(t, algorithm)
+	path, err := repo.GetJAR("com.example", "lib", "1.0")
+	require.Error(t, err)
+	assert.NoFileExists(t, path)
+
+	path, err = newRepo(ChecksumWarn).GetJAR("com.example", "lib", "1.0")
+	require.NoError(t, err)
+	assert.FileExists(t, path)
+}
+
+func writeTemp(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestVerifyCache(t *testing.T) {
+	baseDir := t.TempDir()
+	repo := NewLocalRepository(baseDir)
+	dir := repo.artifactDir("com.example", "lib", "1.0")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+
+	var jar bytes.Buffer
+	zw := zip.NewWriter(&jar)
+	_, err := zw.Create("META-INF/MANIFEST.MF")
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	good := filepath.Join(dir, "lib-1.0.jar")
+	require.NoError(t, os.WriteFile(good, jar.Bytes(), 0644))
+	goodSHA1, err := hashFile(good, "sha1")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(good+".sha1", []byte(goodSHA1), 0644))
+
+	truncated := filepath.Join(dir, "lib-1.0-sources.jar")
+	require.NoError(t, os.WriteFile(truncated, jar.Bytes()[:jar.Len()/2], 0644))
+
+	empty := filepath.Join(dir, "lib-1.0.pom")
+	require.NoError(t, os.WriteFile(empty, nil, 0644))
+
+	mismatch := filepath.Join(dir, "lib-1.0.module")
+	require.NoError(t, os.WriteFile(mismatch, []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(mismatch+".md5", []byte("0000"), 0644))
+
+	checked, problems, err := repo.VerifyCache()
+	require.NoError(t, err)
+	assert.Equal(t, 4, checked)
+	require.Len(t, problems, 3)
+	reasons := make(map[string]string)
+	for _, problem := range problems {
+		reasons[problem.Path] = problem.Reason
+	}
+	assert.Contains(t, reasons[truncated], "jar is truncated or corrupt")
+	assert.Equal(t, "file is empty", reasons[empty])
+	assert.Contains(t, reasons[mismatch], "checksum mismatch: expected md5 0000")
+	assert.NotContains(t, reasons, good)
+
+	// a repository that was never created has nothing to verify
+	checked, _, err = NewLocalRepository(filepath.Join(baseDir, "missing")).VerifyCache()
+	require.NoError(t, err)
+	assert.Equal(t, 0, checked)
+}
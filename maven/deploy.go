@@ -0,0 +1,201 @@
+package maven
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FindRemote returns the configured remote with the given name or URL, ignoring mirrors. A URL
+// that isn't configured is used as is, without credentials.
+func (c *LocalRepository) FindRemote(nameOrURL string) (*Remote, error) {
+	matches := func(remote *Remote) bool {
+		return remote.Name == nameOrURL || strings.TrimSuffix(remote.URL, "/") == strings.TrimSuffix(nameOrURL, "/")
+	}
+	for _, remote := range c.remotes {
+		// mirrors only apply to downloads, publishing goes to the repository itself
+		for _, mirrored := range remote.mirrorOf {
+			if matches(mirrored) {
+				return mirrored, nil
+			}
+		}
+		if matches(remote) {
+			return remote, nil
+		}
+	}
+	if strings.Contains(nameOrURL, "://") {
+		remote, err := NewRemote(RepositoryConfig{Name: nameOrURL, URL: nameOrURL})
+		if err != nil {
+			return nil, err
+		}
+		remote.client = c.client
+		return remote, nil
+	}
+	return nil, fmt.Errorf("no repository named '%s' is configured", nameOrURL)
+}
+
+// Deploy uploads the files of a version of an artifact to the remote with HTTP PUT, each followed
+// by its checksums, and then updates the maven-metadata.xml of the artifact so that the version
+// can be found. Snapshot files are uploaded as a new timestamped build, recorded in the
+// maven-metadata.xml of the snapshot version.
+func (r *Remote) Deploy(groupID, artifactID, version string, files []ArtifactFile) error {
+	if !r.Serves(version) {
+		kind := "release"
+		if isSnapshot(version) {
+			kind = "snapshot"
+		}
+		return fmt.Errorf("repository %s does not accept %s versions", r.Name, kind)
+	}
+	now := time.Now().UTC()
+	lastUpdated := now.Format("20060102150405")
+
+	fileVersion := version
+	var versionMetadata *Metadata
+	if isSnapshot(version) {
+		var err error
+		versionMetadata, err = r.fetchMetadata(groupID, artifactID, version)
+		if err != nil {
+			return err
+		}
+		buildNumber := 1
+		if snapshot := versionMetadata.Versioning.Snapshot; snapshot != nil {
+			buildNumber = snapshot.BuildNumber + 1
+		}
+		snapshot := &Snapshot{Timestamp: now.Format("20060102.150405"), BuildNumber: buildNumber}
+		versionMetadata.Versioning.Snapshot = snapshot
+		versionMetadata.Versioning.LastUpdated = lastUpdated
+		fileVersion = fmt.Sprintf("%s%s-%d", version[:len(version)-len("SNAPSHOT")], snapshot.Timestamp, snapshot.BuildNumber)
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file.Path)
+		if err != nil {
+			return err
+		}
+		if err := r.upload(groupID, artifactID, version, file.name(artifactID, fileVersion), data); err != nil {
+			return err
+		}
+		if versionMetadata != nil {
+			versionMetadata.setSnapshotVersion(SnapshotVersion{
+				Classifier: file.Classifier,
+				Extension:  file.Extension,
+				Value:      fileVersion,
+				Updated:    lastUpdated,
+			})
+		}
+	}
+	if versionMetadata != nil {
+		if err := r.uploadMetadata(versionMetadata, version); err != nil {
+			return err
+		}
+	}
+
+	metadata, err := r.fetchMetadata(groupID, artifactID, "")
+	if err != nil {
+		return err
+	}
+	metadata.addVersion(version)
+	metadata.Versioning.LastUpdated = lastUpdated
+	return r.uploadMetadata(metadata, "")
+}
+
+// fetchMetadata downloads the maven-metadata.xml of an artifact, or of a snapshot version if
+// version isn't empty. Metadata that doesn't exist yet is returned empty.
+func (r *Remote) fetchMetadata(groupID, artifactID, version string) (*Metadata, error) {
+	metadata := &Metadata{GroupID: groupID, ArtifactID: artifactID, Version: version}
+	fileURL, err := r.fileURL(groupID, artifactID, version, metadataFile)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = r.fetch(fileURL, &buf)
+	if isNotFound(err) {
+		return metadata, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := xml.Unmarshal(buf.Bytes(), metadata); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", fileURL, err)
+	}
+	return metadata, nil
+}
+
+func (r *Remote) uploadMetadata(metadata *Metadata, version string) error {
+	data, err := xml.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize %s: %w", metadataFile, err)
+	}
+	data = append([]byte(xml.Header), data...)
+	return r.upload(metadata.GroupID, metadata.ArtifactID, version, metadataFile, append(data, '\n'))
+}
+
+// upload puts a file followed by a file with each of its checksums.
+func (r *Remote) upload(groupID, artifactID, version, file string, data []byte) error {
+	fileURL, err := r.fileURL(groupID, artifactID, version, file)
+	if err != nil {
+		return err
+	}
+	if err := r.put(fileURL, data); err != nil {
+		return err
+	}
+	for _, algorithm := range checksumAlgorithms {
+		hasher := newHash(algorithm)
+		hasher.Write(data)
+		checksum := fmt.Sprintf("%x", hasher.Sum(nil))
+		if err := r.put(fileURL+"."+algorithm, []byte(checksum)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Remote) put(fileURL string, data []byte) error {
+	client := r.httpClient()
+	return client.retry(func() error {
+		req, err := r.newRequest(http.MethodPut, fileURL, bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("error uploading %s: %v", fileURL, err)
+		}
+		resp, err := client.client.Do(req)
+		if err != nil {
+			return transportError(fmt.Errorf("error uploading %s: %w", fileURL, err))
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return statusResult(resp, fmt.Errorf("failed to upload %s: %s", fileURL, resp.Status))
+		}
+		return nil
+	})
+}
+
+// setSnapshotVersion records the timestamped version of a file, replacing the one from the
+// previous build.
+func (m *Metadata) setSnapshotVersion(snapshotVersion SnapshotVersion) {
+	versions := m.Versioning.SnapshotVersions
+	i := slices.IndexFunc(versions, func(v SnapshotVersion) bool {
+		return v.Classifier == snapshotVersion.Classifier && v.Extension == snapshotVersion.Extension
+	})
+	if i < 0 {
+		m.Versioning.SnapshotVersions = append(versions, snapshotVersion)
+	} else {
+		versions[i] = snapshotVersion
+	}
+}
+
+// addVersion lists a newly deployed version, keeping the versions sorted oldest first.
+func (m *Metadata) addVersion(version string) {
+	if !slices.Contains(m.Versioning.Versions, version) {
+		m.Versioning.Versions = append(m.Versioning.Versions, version)
+		slices.SortStableFunc(m.Versioning.Versions, CompareVersions)
+	}
+	m.Versioning.Latest = newestVersion(m.Versioning.Latest, version)
+	if !isSnapshot(version) {
+		m.Versioning.Release = newestVersion(m.Versioning.Release, version)
+	}
+}
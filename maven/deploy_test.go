@@ -0,0 +1,161 @@
+package maven
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newWritableTestRemote serves a repository that files can be PUT into, like Nexus or
+// Artifactory. Uploads with credentials other than the given authorization are rejected.
+func newWritableTestRemote(t *testing.T, authorization string) (*httptest.Server, map[string]string) {
+	var mu sync.Mutex
+	files := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		path := r.URL.Path[1:]
+		switch r.Method {
+		case http.MethodPut:
+			if r.Header.Get("Authorization") != authorization {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			data, _ := io.ReadAll(r.Body)
+			files[path] = string(data)
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodGet:
+			content, found := files[path]
+			if !found {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(content))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, files
+}
+
+func writeArtifactFiles(t *testing.T, content string) []ArtifactFile {
+	dir := t.TempDir()
+	jarPath := filepath.Join(dir, "lib.jar")
+	pomPath := filepath.Join(dir, "lib.pom")
+	require.NoError(t, os.WriteFile(jarPath, []byte(content), 0644))
+	require.NoError(t, os.WriteFile(pomPath, []byte("<project/>"), 0644))
+	return []ArtifactFile{{Path: jarPath, Extension: "jar"}, {Path: pomPath, Extension: "pom"}}
+}
+
+func parseMetadata(t *testing.T, content string) *Metadata {
+	metadata := &Metadata{}
+	require.NoError(t, xml.Unmarshal([]byte(content), metadata))
+	return metadata
+}
+
+func TestDeploy_Release(t *testing.T) {
+	server, files := newWritableTestRemote(t, "")
+	remote, err := NewRemote(RepositoryConfig{Name: "releases", URL: server.URL})
+	require.NoError(t, err)
+
+	require.NoError(t, remote.Deploy("com.example", "lib", "1.1", writeArtifactFiles(t, "jar 1.1")))
+	require.NoError(t, remote.Deploy("com.example", "lib", "1.0", writeArtifactFiles(t, "jar 1.0")))
+
+	assert.Equal(t, "jar 1.1", files["com/example/lib/1.1/lib-1.1.jar"])
+	assert.Equal(t, "<project/>", files["com/example/lib/1.1/lib-1.1.pom"])
+	// sha1 of "jar 1.1"
+	assert.Equal(t, "6ba276aa0c89b3cc723b76651094a173577f1422", files["com/example/lib/1.1/lib-1.1.jar.sha1"])
+	for _, algorithm := range []string{"md5", "sha1", "sha256"} {
+		assert.Contains(t, files, "com/example/lib/1.1/lib-1.1.pom."+algorithm)
+		assert.Contains(t, files, "com/example/lib/maven-metadata.xml."+algorithm)
+	}
+
+	metadata := parseMetadata(t, files["com/example/lib/maven-metadata.xml"])
+	assert.Equal(t, []string{"1.0", "1.1"}, metadata.Versioning.Versions)
+	assert.Equal(t, "1.1", metadata.Versioning.Latest)
+	assert.Equal(t, "1.1", metadata.Versioning.Release)
+	assert.Len(t, metadata.Versioning.LastUpdated, 14)
+}
+
+func TestDeploy_Snapshot(t *testing.T) {
+	server, files := newWritableTestRemote(t, "")
+	remote, err := NewRemote(RepositoryConfig{Name: "snapshots", URL: server.URL})
+	require.NoError(t, err)
+
+	require.NoError(t, remote.Deploy("com.example", "lib", "1.0-SNAPSHOT", writeArtifactFiles(t, "first build")))
+	require.NoError(t, remote.Deploy("com.example", "lib", "1.0-SNAPSHOT", writeArtifactFiles(t, "second build")))
+
+	metadata := parseMetadata(t, files["com/example/lib/1.0-SNAPSHOT/maven-metadata.xml"])
+	require.NotNil(t, metadata.Versioning.Snapshot)
+	assert.Equal(t, "1.0-SNAPSHOT", metadata.Version)
+	assert.Equal(t, 2, metadata.Versioning.Snapshot.BuildNumber)
+	require.Len(t, metadata.Versioning.SnapshotVersions, 2)
+	value := metadata.snapshotValue("1.0-SNAPSHOT", "", "jar")
+	assert.Regexp(t, `^1\.0-\d{8}\.\d{6}-2$`, value)
+	assert.Equal(t, "second build", files["com/example/lib/1.0-SNAPSHOT/lib-"+value+".jar"])
+
+	artifactMetadata := parseMetadata(t, files["com/example/lib/maven-metadata.xml"])
+	assert.Equal(t, []string{"1.0-SNAPSHOT"}, artifactMetadata.Versioning.Versions)
+	assert.Equal(t, "1.0-SNAPSHOT", artifactMetadata.Versioning.Latest)
+	assert.Empty(t, artifactMetadata.Versioning.Release)
+
+	// resolving the snapshot from the remote finds the latest build
+	repo := NewLocalRepository(t.TempDir(), server.URL)
+	path, err := repo.GetJAR("com.example", "lib", "1.0-SNAPSHOT")
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "second build", string(data))
+}
+
+func TestDeploy_Credentials(t *testing.T) {
+	server, files := newWritableTestRemote(t, "Basic YWxpY2U6c2VjcmV0")
+	t.Setenv("TEST_REPO_USER", "alice")
+	t.Setenv("TEST_REPO_PASSWORD", "secret")
+
+	anonymous, err := NewRemote(RepositoryConfig{Name: "anonymous", URL: server.URL})
+	require.NoError(t, err)
+	err = anonymous.Deploy("com.example", "lib", "1.0", writeArtifactFiles(t, "jar"))
+	assert.ErrorContains(t, err, "401 Unauthorized")
+	assert.Empty(t, files)
+
+	basic, err := NewRemote(RepositoryConfig{Name: "basic", URL: server.URL, UsernameEnv: "TEST_REPO_USER", PasswordEnv: "TEST_REPO_PASSWORD"})
+	require.NoError(t, err)
+	require.NoError(t, basic.Deploy("com.example", "lib", "1.0", writeArtifactFiles(t, "jar")))
+	assert.Equal(t, "jar", files["com/example/lib/1.0/lib-1.0.jar"])
+}
+
+func TestDeploy_VersionNotServed(t *testing.T) {
+	no := false
+	remote, err := NewRemote(RepositoryConfig{Name: "releases", URL: "https://nexus.example.com/releases/", Snapshots: &no})
+	require.NoError(t, err)
+	err = remote.Deploy("com.example", "lib", "1.0-SNAPSHOT", nil)
+	assert.EqualError(t, err, "repository releases does not accept snapshot versions")
+}
+
+func TestFindRemote(t *testing.T) {
+	repo := NewLocalRepository(t.TempDir())
+	require.NoError(t, repo.SetRepositories([]RepositoryConfig{{Name: "nexus", URL: "https://nexus.example.com/maven/"}}))
+
+	remote, err := repo.FindRemote("nexus")
+	require.NoError(t, err)
+	assert.Equal(t, "https://nexus.example.com/maven/", remote.URL)
+
+	remote, err = repo.FindRemote("https://nexus.example.com/maven")
+	require.NoError(t, err)
+	assert.Equal(t, "nexus", remote.Name)
+
+	remote, err = repo.FindRemote("http://localhost:8080/releases")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/releases", remote.URL)
+
+	_, err = repo.FindRemote("artifactory")
+	assert.EqualError(t, err, "no repository named 'artifactory' is configured")
+}
@@ -0,0 +1,32 @@
+package maven
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lockFileName is the file in a directory of the local repository that jb processes lock while
+// they write to the directory.
+const lockFileName = ".jb.lock"
+
+// lockDir takes an exclusive lock on a directory of the local repository, creating it if needed,
+// so that jb processes running in parallel don't download the same files into it at the same
+// time. It blocks until the lock is free. The returned function releases the lock.
+func lockDir(dir string) (func(), error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, lockFileName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock %s: %w", dir, err)
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", dir, err)
+	}
+	return func() {
+		unlockFile(file)
+		file.Close()
+	}, nil
+}
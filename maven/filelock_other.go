@@ -0,0 +1,16 @@
+//go:build !unix && !windows
+
+package maven
+
+import "os"
+
+// Platforms without file locks rely on atomic renames alone, so parallel processes may download
+// the same file twice but never see a partial one.
+
+func lockFile(file *os.File) error {
+	return nil
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
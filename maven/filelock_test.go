@@ -0,0 +1,37 @@
+//go:build unix || windows
+
+package maven
+
+import (
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockDir(t *testing.T) {
+	dir := t.TempDir()
+	unlock, err := lockDir(dir)
+	require.NoError(t, err)
+
+	var locked atomic.Bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		unlockOther, err := lockDir(dir)
+		if assert.NoError(t, err) {
+			locked.Store(true)
+			unlockOther()
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, locked.Load(), "a second lock is taken while the first is held")
+
+	unlock()
+	<-done
+	assert.True(t, locked.Load())
+	assert.FileExists(t, filepath.Join(dir, lockFileName))
+}
@@ -0,0 +1,254 @@
+package maven
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// gradleMetadataMarker is the comment Gradle puts in the POMs it publishes next to a .module file.
+const gradleMetadataMarker = "published-with-gradle-metadata"
+
+// GradleModule is the Gradle Module Metadata (.module file) of a version of an artifact. Its
+// variants describe the artifact for different uses and environments, each with its own
+// dependencies and files, which a POM can't express.
+type GradleModule struct {
+	FormatVersion string          `json:"formatVersion"`
+	Variants      []GradleVariant `json:"variants"`
+}
+
+// GradleVariant is one way of consuming an artifact, such as its runtime jar for Java 17, told
+// apart by its attributes. A variant that is available at another module has neither
+// dependencies nor files of its own.
+type GradleVariant struct {
+	Name         string             `json:"name"`
+	Attributes   map[string]any     `json:"attributes"` // strings, except org.gradle.jvm.version which is a number
+	Dependencies []GradleDependency `json:"dependencies"`
+	Files        []GradleFile       `json:"files"`
+	AvailableAt  *GradleAvailableAt `json:"available-at"`
+}
+
+type GradleDependency struct {
+	Group      string          `json:"group"`
+	Module     string          `json:"module"`
+	Version    *GradleVersion  `json:"version"`
+	Excludes   []GradleExclude `json:"excludes"`
+	Attributes map[string]any  `json:"attributes"`
+}
+
+// GradleVersion is a rich version constraint. jb uses the strict version if there is one, and
+// otherwise the required or preferred version.
+type GradleVersion struct {
+	Requires string   `json:"requires"`
+	Strictly string   `json:"strictly"`
+	Prefers  string   `json:"prefers"`
+	Rejects  []string `json:"rejects"`
+}
+
+type GradleExclude struct {
+	Group  string `json:"group"`
+	Module string `json:"module"`
+}
+
+type GradleFile struct {
+	Name string `json:"name"`
+	URL  string `json:"url"` // relative to the version directory of the module
+	Size int64  `json:"size"`
+}
+
+type GradleAvailableAt struct {
+	URL     string `json:"url"`
+	Group   string `json:"group"`
+	Module  string `json:"module"`
+	Version string `json:"version"`
+}
+
+func gradleModuleFile(artifactID, version string) string {
+	return artifactID + "-" + version + ".module"
+}
+
+func readGradleModule(path string) (*GradleModule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	module := &GradleModule{}
+	if err := json.Unmarshal(data, module); err != nil {
+		return nil, fmt.Errorf("invalid gradle module metadata %s: %w", path, err)
+	}
+	if !strings.HasPrefix(module.FormatVersion, "1.") {
+		return nil, fmt.Errorf("unsupported gradle module metadata format %s in %s", module.FormatVersion, path)
+	}
+	return module, nil
+}
+
+func (v *GradleVariant) attribute(name string) string {
+	value, found := v.Attributes[name]
+	if !found {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// SelectVariant returns the variant that a Gradle build targeting the given Java version gets on
+// its runtime classpath: a library for java-runtime usage, as a jar with its dependencies
+// external to it, for the JVM rather than another Kotlin platform. Among those it picks the
+// highest org.gradle.jvm.version that isn't above javaVersion, then the standard JVM over
+// android. A javaVersion of 0 accepts any JVM version. It returns nil if no variant fits.
+func (m *GradleModule) SelectVariant(javaVersion int) *GradleVariant {
+	return m.selectVariant(javaVersion, "java-runtime", "java-runtime-jars")
+}
+
+// selectAPIVariant returns the variant a Gradle build gets on its compile classpath, picked the
+// same way as SelectVariant but for java-api usage.
+func (m *GradleModule) selectAPIVariant(javaVersion int) *GradleVariant {
+	return m.selectVariant(javaVersion, "java-api", "java-api-jars")
+}
+
+func (m *GradleModule) selectVariant(javaVersion int, usages ...string) *GradleVariant {
+	var selected *GradleVariant
+	selectedJVM, selectedRank := 0, 0
+	for i := range m.Variants {
+		variant := &m.Variants[i]
+		if !variant.isLibrary(usages) {
+			continue
+		}
+		jvm, _ := strconv.Atoi(variant.attribute("org.gradle.jvm.version"))
+		if javaVersion > 0 && jvm > javaVersion {
+			continue
+		}
+		rank := environmentRank(variant.attribute("org.gradle.jvm.environment"))
+		if selected == nil || cmp.Or(cmp.Compare(jvm, selectedJVM), cmp.Compare(rank, selectedRank)) > 0 {
+			selected, selectedJVM, selectedRank = variant, jvm, rank
+		}
+	}
+	return selected
+}
+
+func (v *GradleVariant) isLibrary(usages []string) bool {
+	matches := func(name string, accepted ...string) bool {
+		value := v.attribute(name)
+		return value == "" || slices.Contains(accepted, value)
+	}
+	return matches("org.gradle.category", "library") &&
+		matches("org.gradle.usage", usages...) &&
+		matches("org.gradle.dependency.bundling", "external") &&
+		matches("org.gradle.libraryelements", "jar") &&
+		matches("org.jetbrains.kotlin.platform.type", "jvm")
+}
+
+func environmentRank(environment string) int {
+	switch environment {
+	case "standard-jvm":
+		return 2
+	case "":
+		return 1
+	}
+	return 0
+}
+
+// applyGradleMetadata replaces the dependencies of a POM with those of the runtime variant
+// selected from its Gradle Module Metadata, and notes the variant's file as the artifact's main
+// file. Like the compile and runtime scopes of a POM, dependencies that the matching api variant
+// doesn't also have get runtime scope. A variant available at another module becomes a
+// dependency on that module, without a jar of its own. The POM is left alone if the metadata
+// can't be fetched or no variant can be used as is, so the artifact resolves like a maven build
+// would. Snapshots always use their POM.
+func (c *LocalRepository) applyGradleMetadata(pom *POM, groupID, artifactID, version string) {
+	if isSnapshot(version) {
+		return
+	}
+	path, err := c.getFile(groupID, artifactID, version, gradleModuleFile(artifactID, version))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: using the POM of %s: %s\n", GAV(groupID, artifactID, version), err)
+		return
+	}
+	module, err := readGradleModule(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: using the POM of %s: %s\n", GAV(groupID, artifactID, version), err)
+		return
+	}
+	c.mu.Lock()
+	javaVersion := cmp.Or(c.javaVersion, c.activation.javaMajorVersion())
+	c.mu.Unlock()
+	variant := module.SelectVariant(javaVersion)
+	if variant == nil || len(variant.Files) > 1 {
+		return
+	}
+
+	if variant.AvailableAt != nil {
+		at := variant.AvailableAt
+		pom.Dependencies = []Dependency{{GroupID: at.Group, ArtifactID: at.Module, Version: at.Version}}
+		pom.Packaging = "pom"
+		pom.variantFile = ""
+		return
+	}
+	if len(variant.Files) == 1 && strings.ContainsAny(variant.Files[0].URL, "/\\") {
+		// only files in the version directory of the module are supported
+		return
+	}
+	var api map[string]bool // group:module of the api variant's dependencies, nil without one
+	if apiVariant := module.selectAPIVariant(javaVersion); apiVariant != nil && apiVariant.AvailableAt == nil {
+		api = make(map[string]bool)
+		for _, gradleDep := range apiVariant.Dependencies {
+			api[gradleDep.Group+":"+gradleDep.Module] = true
+		}
+	}
+	deps := make([]Dependency, 0, len(variant.Dependencies))
+	for _, gradleDep := range variant.Dependencies {
+		category := fmt.Sprint(gradleDep.Attributes["org.gradle.category"])
+		if category == "platform" || category == "enforced-platform" {
+			// platforms only constrain versions, which are already in the variant
+			continue
+		}
+		dep := Dependency{GroupID: gradleDep.Group, ArtifactID: gradleDep.Module}
+		if api != nil && !api[gradleDep.Group+":"+gradleDep.Module] {
+			dep.Scope = "runtime"
+		}
+		if gradleDep.Version != nil {
+			dep.Version = cmp.Or(gradleDep.Version.Strictly, gradleDep.Version.Requires, gradleDep.Version.Prefers)
+		}
+		if dep.Version == "" {
+			// versions left to a platform are the ones the POM declares
+			i := slices.IndexFunc(pom.Dependencies, func(pomDep Dependency) bool {
+				return pomDep.GroupID == dep.GroupID && pomDep.ArtifactID == dep.ArtifactID
+			})
+			if i < 0 || pom.Dependencies[i].Version == "" {
+				return
+			}
+			dep.Version = pom.Dependencies[i].Version
+		}
+		for _, exclude := range gradleDep.Excludes {
+			dep.Exclusions = append(dep.Exclusions, Exclusion{GroupID: cmp.Or(exclude.Group, "*"), ArtifactID: cmp.Or(exclude.Module, "*")})
+		}
+		deps = append(deps, dep)
+	}
+
+	pom.Dependencies = deps
+	if len(variant.Files) == 0 {
+		pom.Packaging = "pom"
+		pom.variantFile = ""
+	} else {
+		pom.Packaging = "jar"
+		pom.variantFile = variant.Files[0].URL
+	}
+}
+
+// javaMajorVersion returns the major version of the JDK, such as 8 for 1.8.0_292 or 21 for
+// 21-ea, or 0 if there is no JDK.
+func (ctx *ActivationContext) javaMajorVersion() int {
+	if ctx == nil {
+		return 0
+	}
+	version := strings.TrimPrefix(ctx.JDK, "1.")
+	end := strings.IndexFunc(version, func(r rune) bool { return r < '0' || r > '9' })
+	if end >= 0 {
+		version = version[:end]
+	}
+	major, _ := strconv.Atoi(version)
+	return major
+}
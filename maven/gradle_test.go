@@ -0,0 +1,223 @@
+package maven
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runtimeVariant(name string, jvm int, environment string) GradleVariant {
+	attributes := map[string]any{
+		"org.gradle.category":            "library",
+		"org.gradle.dependency.bundling": "external",
+		"org.gradle.libraryelements":     "jar",
+		"org.gradle.usage":               "java-runtime",
+		"org.gradle.jvm.version":         float64(jvm),
+	}
+	if environment != "" {
+		attributes["org.gradle.jvm.environment"] = environment
+	}
+	return GradleVariant{Name: name, Attributes: attributes}
+}
+
+func TestGradleModule_SelectVariant(t *testing.T) {
+	api := runtimeVariant("apiElements", 8, "")
+	api.Attributes["org.gradle.usage"] = "java-api"
+	sources := runtimeVariant("sourcesElements", 8, "")
+	sources.Attributes["org.gradle.category"] = "documentation"
+	js := runtimeVariant("jsRuntimeElements", 8, "")
+	js.Attributes["org.jetbrains.kotlin.platform.type"] = "js"
+	module := &GradleModule{Variants: []GradleVariant{
+		api,
+		sources,
+		js,
+		runtimeVariant("androidRuntimeElements", 8, "android"),
+		runtimeVariant("jreRuntimeElements", 8, "standard-jvm"),
+		runtimeVariant("java11RuntimeElements", 11, "standard-jvm"),
+		runtimeVariant("java17RuntimeElements", 17, "standard-jvm"),
+	}}
+
+	tests := []struct {
+		javaVersion int
+		want        string
+	}{
+		{8, "jreRuntimeElements"},
+		{11, "java11RuntimeElements"},
+		{15, "java11RuntimeElements"},
+		{21, "java17RuntimeElements"},
+		{0, "java17RuntimeElements"},
+	}
+	for _, tt := range tests {
+		variant := module.SelectVariant(tt.javaVersion)
+		require.NotNil(t, variant, "java %d", tt.javaVersion)
+		assert.Equal(t, tt.want, variant.Name, "java %d", tt.javaVersion)
+	}
+	assert.Nil(t, module.SelectVariant(6))
+}
+
+func TestActivationContext_JavaMajorVersion(t *testing.T) {
+	for jdk, want := range map[string]int{"": 0, "1.8.0": 8, "1.8.0_292": 8, "11.0.2": 11, "17": 17, "21-ea": 21} {
+		assert.Equal(t, want, (&ActivationContext{JDK: jdk}).javaMajorVersion(), jdk)
+	}
+	assert.Equal(t, 0, (*ActivationContext)(nil).javaMajorVersion())
+}
+
+const gradlePOM = `<project>
+  <!-- This module was also published with a richer model, Gradle metadata,  -->
+  <!-- which should be used instead. Do not delete the following line which  -->
+  <!-- is to indicate to Gradle or any Gradle module metadata file consumer   -->
+  <!-- that they should prefer consuming it instead. -->
+  <!-- do_not_remove: published-with-gradle-metadata -->
+  <groupId>com.example</groupId>
+  <artifactId>lib</artifactId>
+  <version>1.0</version>
+  <dependencies>
+    <dependency><groupId>com.example</groupId><artifactId>dep</artifactId><version>2.0</version></dependency>
+    <dependency><groupId>com.example</groupId><artifactId>managed</artifactId><version>3.0</version></dependency>
+  </dependencies>
+</project>`
+
+const gradleModule = `{
+  "formatVersion": "1.1",
+  "component": {"group": "com.example", "module": "lib", "version": "1.0"},
+  "variants": [
+    {
+      "name": "apiElements",
+      "attributes": {"org.gradle.category": "library", "org.gradle.usage": "java-api", "org.gradle.jvm.version": 8},
+      "dependencies": [
+        {"group": "com.example", "module": "dep", "version": {"requires": "2.0"}}
+      ],
+      "files": [{"name": "lib-1.0.jar", "url": "lib-1.0.jar"}]
+    },
+    {
+      "name": "runtimeElements",
+      "attributes": {"org.gradle.category": "library", "org.gradle.usage": "java-runtime", "org.gradle.jvm.version": 8},
+      "dependencies": [
+        {"group": "com.example", "module": "dep", "version": {"requires": "2.0"}},
+        {"group": "com.example", "module": "managed"},
+        {"group": "com.example", "module": "bom", "version": {"requires": "3.0"}, "attributes": {"org.gradle.category": "platform"}}
+      ],
+      "files": [{"name": "lib-1.0.jar", "url": "lib-1.0.jar"}]
+    },
+    {
+      "name": "java17RuntimeElements",
+      "attributes": {"org.gradle.category": "library", "org.gradle.usage": "java-runtime", "org.gradle.jvm.version": 17},
+      "dependencies": [
+        {"group": "com.example", "module": "dep", "version": {"strictly": "2.1", "requires": "2.0"}, "excludes": [{"group": "com.example", "module": "unwanted"}]},
+        {"group": "com.example", "module": "java17-only", "version": {"requires": "1.0"}}
+      ],
+      "files": [{"name": "lib-1.0-java17.jar", "url": "lib-1.0-java17.jar"}]
+    }
+  ]
+}`
+
+func newGradleTestRepository(t *testing.T, files map[string]string, jdk string) *LocalRepository {
+	t.Helper()
+	server := newTestRemote(t, files)
+	repo := NewLocalRepository(t.TempDir(), server.URL)
+	repo.SetChecksumPolicy(ChecksumIgnore)
+	activation := DefaultActivationContext()
+	activation.SetJDK(jdk)
+	repo.SetActivationContext(activation)
+	return repo
+}
+
+func TestGetPOM_GradleMetadata(t *testing.T) {
+	files := map[string]string{
+		"com/example/lib/1.0/lib-1.0.pom":        gradlePOM,
+		"com/example/lib/1.0/lib-1.0.module":     gradleModule,
+		"com/example/lib/1.0/lib-1.0.jar":        "java 8 jar",
+		"com/example/lib/1.0/lib-1.0-java17.jar": "java 17 jar",
+	}
+	gas := func(deps []Dependency) []string {
+		result := make([]string, 0, len(deps))
+		for _, dep := range deps {
+			result = append(result, GAV(dep.GroupID, dep.ArtifactID, dep.Version))
+		}
+		return result
+	}
+	scopes := func(deps []Dependency) []string {
+		result := make([]string, 0, len(deps))
+		for _, dep := range deps {
+			result = append(result, dep.Scope)
+		}
+		return result
+	}
+
+	repo := newGradleTestRepository(t, files, "11.0.2")
+	pom, err := repo.GetPOM("com.example", "lib", "1.0")
+	require.NoError(t, err)
+	// the version left to the platform comes from the POM, the platform itself is left out
+	assert.Equal(t, []string{"com.example:dep:2.0", "com.example:managed:3.0"}, gas(pom.Dependencies))
+	// dependencies that only the runtime variant has are runtime scope
+	assert.Equal(t, []string{"", "runtime"}, scopes(pom.Dependencies))
+	path, err := repo.GetJAR("com.example", "lib", "1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "lib-1.0.jar", filepath.Base(path))
+
+	repo = newGradleTestRepository(t, files, "17.0.2")
+	// the jar alone is looked up without loading the POM
+	path, err = repo.GetJAR("com.example", "lib", "1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "lib-1.0.jar", filepath.Base(path))
+	pom, err = repo.GetPOM("com.example", "lib", "1.0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"com.example:dep:2.1", "com.example:java17-only:1.0"}, gas(pom.Dependencies))
+	assert.Equal(t, []string{"", "runtime"}, scopes(pom.Dependencies))
+	assert.Equal(t, []Exclusion{{GroupID: "com.example", ArtifactID: "unwanted"}}, pom.Dependencies[0].Exclusions)
+	path, err = repo.GetArtifact("com.example", "lib", "1.0", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "lib-1.0-java17.jar", filepath.Base(path))
+
+	// the Java version the code targets wins over the JDK's
+	repo.SetJavaVersion(11)
+	pom, err = repo.GetPOM("com.example", "lib", "1.0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"com.example:dep:2.0", "com.example:managed:3.0"}, gas(pom.Dependencies))
+	path, err = repo.GetJAR("com.example", "lib", "1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "lib-1.0.jar", filepath.Base(path))
+
+	// without the module file the POM is used
+	delete(files, "com/example/lib/1.0/lib-1.0.module")
+	repo = newGradleTestRepository(t, files, "17.0.2")
+	pom, err = repo.GetPOM("com.example", "lib", "1.0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"com.example:dep:2.0", "com.example:managed:3.0"}, gas(pom.Dependencies))
+	path, err = repo.GetJAR("com.example", "lib", "1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "lib-1.0.jar", filepath.Base(path))
+}
+
+func TestGetPOM_GradleMetadataAvailableAt(t *testing.T) {
+	// kotlin multiplatform libraries point JVM consumers to the module of their JVM target
+	repo := newGradleTestRepository(t, map[string]string{
+		"com/example/mpp/1.0/mpp-1.0.pom": `<project>
+  <!-- do_not_remove: published-with-gradle-metadata -->
+  <groupId>com.example</groupId><artifactId>mpp</artifactId><version>1.0</version>
+</project>`,
+		"com/example/mpp/1.0/mpp-1.0.module": `{
+  "formatVersion": "1.1",
+  "variants": [
+    {
+      "name": "jsRuntimeElements-published",
+      "attributes": {"org.gradle.usage": "kotlin-runtime", "org.jetbrains.kotlin.platform.type": "js"},
+      "available-at": {"url": "../../mpp-js/1.0/mpp-js-1.0.module", "group": "com.example", "module": "mpp-js", "version": "1.0"}
+    },
+    {
+      "name": "jvmRuntimeElements-published",
+      "attributes": {"org.gradle.category": "library", "org.gradle.usage": "java-runtime", "org.jetbrains.kotlin.platform.type": "jvm"},
+      "available-at": {"url": "../../mpp-jvm/1.0/mpp-jvm-1.0.module", "group": "com.example", "module": "mpp-jvm", "version": "1.0"}
+    }
+  ]
+}`,
+	}, "17.0.2")
+
+	pom, err := repo.GetPOM("com.example", "mpp", "1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "pom", pom.Packaging)
+	require.Len(t, pom.Dependencies, 1)
+	assert.Equal(t, "com.example:mpp-jvm:1.0", GAV(pom.Dependencies[0].GroupID, pom.Dependencies[0].ArtifactID, pom.Dependencies[0].Version))
+}
@@ -0,0 +1,239 @@
+package maven
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPSettings configures how remote repositories are reached. Durations are written like "10s"
+// or "2m".
+type HTTPSettings struct {
+	ConnectTimeout string       `json:"connect_timeout,omitempty"` // defaults to 10s
+	ReadTimeout    string       `json:"read_timeout,omitempty"`    // longest wait for data from a remote, defaults to 60s
+	Retries        *int         `json:"retries,omitempty"`         // retries of a failed request, defaults to 3
+	Proxy          *ProxyConfig `json:"proxy,omitempty"`           // defaults to the HTTPS_PROXY and HTTP_PROXY environment variables
+}
+
+// ProxyConfig is an HTTP proxy that remotes are reached through. Credentials are read from the
+// environment like those of repositories.
+type ProxyConfig struct {
+	Host          string   `json:"host"`
+	Port          int      `json:"port"`
+	Protocol      string   `json:"protocol,omitempty"` // of the proxy itself, http (default) or https
+	UsernameEnv   string   `json:"username_env,omitempty"`
+	PasswordEnv   string   `json:"password_env,omitempty"`
+	NonProxyHosts []string `json:"non_proxy_hosts,omitempty"` // hosts reached directly, such as "localhost" or "*.example.com"
+	username      string   // from maven's settings.xml, never written to settings.json
+	password      string
+}
+
+const (
+	defaultConnectTimeout = 10 * time.Second
+	defaultReadTimeout    = 60 * time.Second
+	defaultRetries        = 3
+	initialBackoff        = time.Second
+	maxBackoff            = 30 * time.Second
+	maxRetryAfter         = 2 * time.Minute // a remote asking to wait longer is treated as down
+)
+
+// httpClient sends requests to remotes with timeouts, retrying the failures that are likely to
+// be temporary with exponential backoff.
+type httpClient struct {
+	client     *http.Client
+	retries    int
+	backoff    time.Duration // wait before the first retry, doubled for each one after it
+	maxBackoff time.Duration
+}
+
+var defaultHTTPClient, _ = newHTTPClient(nil)
+
+// newHTTPClient creates a client from the settings, using the defaults for anything not set.
+func newHTTPClient(settings *HTTPSettings) (*httpClient, error) {
+	if settings == nil {
+		settings = &HTTPSettings{}
+	}
+	connectTimeout, err := parseTimeout("connect_timeout", settings.ConnectTimeout, defaultConnectTimeout)
+	if err != nil {
+		return nil, err
+	}
+	readTimeout, err := parseTimeout("read_timeout", settings.ReadTimeout, defaultReadTimeout)
+	if err != nil {
+		return nil, err
+	}
+	retries := defaultRetries
+	if settings.Retries != nil {
+		if *settings.Retries < 0 {
+			return nil, fmt.Errorf("invalid retries %d, must not be negative", *settings.Retries)
+		}
+		retries = *settings.Retries
+	}
+
+	dialer := &net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if settings.Proxy != nil {
+		transport.Proxy, err = settings.Proxy.proxyFunc()
+		if err != nil {
+			return nil, err
+		}
+	}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return &timeoutConn{Conn: conn, readTimeout: readTimeout}, nil
+	}
+	transport.TLSHandshakeTimeout = connectTimeout
+	transport.ResponseHeaderTimeout = readTimeout
+	return &httpClient{
+		client:     &http.Client{Transport: transport},
+		retries:    retries,
+		backoff:    initialBackoff,
+		maxBackoff: maxBackoff,
+	}, nil
+}
+
+// proxyFunc returns the function that picks the proxy for a request, for the transport.
+func (p *ProxyConfig) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	protocol := cmp.Or(strings.ToLower(p.Protocol), "http")
+	if protocol != "http" && protocol != "https" {
+		return nil, fmt.Errorf("invalid proxy protocol '%s', must be http or https", p.Protocol)
+	}
+	if p.Host == "" || p.Port <= 0 || p.Port > 65535 {
+		return nil, fmt.Errorf("proxy needs a host and a port")
+	}
+	proxyURL := &url.URL{Scheme: protocol, Host: net.JoinHostPort(p.Host, strconv.Itoa(p.Port))}
+	username := cmp.Or(os.Getenv(p.UsernameEnv), p.username)
+	password := cmp.Or(os.Getenv(p.PasswordEnv), p.password)
+	if username != "" {
+		proxyURL.User = url.UserPassword(username, password)
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		if p.isNonProxyHost(req.URL.Hostname()) {
+			return nil, nil
+		}
+		return proxyURL, nil
+	}, nil
+}
+
+func (p *ProxyConfig) isNonProxyHost(host string) bool {
+	for _, pattern := range p.NonProxyHosts {
+		if matched, _ := path.Match(strings.ToLower(strings.TrimSpace(pattern)), strings.ToLower(host)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTimeout(name, value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid %s '%s', must be a duration such as 30s", name, value)
+	}
+	return timeout, nil
+}
+
+// timeoutConn fails a read that waits longer than readTimeout for data, so that a stalled
+// download fails instead of hanging the build while a slow one carries on.
+type timeoutConn struct {
+	net.Conn
+	readTimeout time.Duration
+}
+
+func (c *timeoutConn) Read(b []byte) (int, error) {
+	if err := c.Conn.SetReadDeadline(time.Now().Add(c.readTimeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
+
+// retryableError is a failure that is likely to be temporary, such as a dropped connection or a
+// 503 answer, with how long the remote asked to wait before trying again if it did.
+type retryableError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// retry calls attempt until it succeeds, fails with an error that isn't a retryableError, or the
+// retries run out. It waits as long as the remote asked, or with exponential backoff, in between.
+func (c *httpClient) retry(attempt func() error) error {
+	backoff := c.backoff
+	for i := 0; ; i++ {
+		err := attempt()
+		var retryable *retryableError
+		if err == nil || !errors.As(err, &retryable) || i == c.retries || retryable.retryAfter > maxRetryAfter {
+			return err
+		}
+		wait := backoff
+		if retryable.retryAfter > 0 {
+			wait = retryable.retryAfter
+		}
+		fmt.Fprintf(os.Stderr, "warning: %s, retrying in %s\n", err, wait)
+		time.Sleep(wait)
+		backoff = min(2*backoff, c.maxBackoff)
+	}
+}
+
+// transportError wraps an error sending a request as retryable, such as a connection reset or
+// timeout, unless retrying is unlikely to help: the remote can't be reached at all or its
+// certificate isn't trusted.
+func transportError(err error) error {
+	var certErr *tls.CertificateVerificationError
+	if isUnreachable(err) || errors.As(err, &certErr) {
+		return err
+	}
+	return &retryableError{err: err}
+}
+
+// statusResult returns err, wrapped as retryable if the response is a 429 or a 5xx, which
+// remotes answer when they are overloaded or briefly down.
+func statusResult(resp *http.Response, err error) error {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return err
+	}
+	return &retryableError{err: err, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of seconds or an HTTP
+// date, returning zero if it is missing or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+// isUnreachable returns true if the error is a failure to connect to the remote at all, rather
+// than a failure of a particular request.
+func isUnreachable(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
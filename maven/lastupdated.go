@@ -0,0 +1,118 @@
+package maven
+
+import (
+	"bufio"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Like maven, jb remembers which remotes didn't have a file in a <file>.lastUpdated file next to
+// where the file would be cached, so that they aren't asked for it again on every build. A remote
+// is asked again once its update policy is due.
+
+const lastUpdatedExtension = ".lastUpdated"
+
+// lastUpdatedKeys escapes the characters that are special in a properties file key.
+var lastUpdatedKeys = strings.NewReplacer(`\`, `\\`, ":", `\:`, "=", `\=`)
+
+func lastUpdatedKey(remote *Remote) string {
+	return lastUpdatedKeys.Replace(remote.URL) + lastUpdatedExtension
+}
+
+// skipRemote returns true if the remote shouldn't be asked for the file cached at path, because it
+// couldn't be reached earlier in this build or answered that it doesn't have the file recently.
+// Like maven's -U, refreshing snapshots asks again regardless of when the remote last answered.
+func (c *LocalRepository) skipRemote(remote *Remote, path string) bool {
+	return c.skipReason(remote, path) != ""
+}
+
+// skipReason returns why the remote shouldn't be asked for the file cached at path, or an empty
+// string if it should.
+func (c *LocalRepository) skipReason(remote *Remote, path string) string {
+	c.mu.Lock()
+	unreachable := c.unreachable[remote]
+	c.mu.Unlock()
+	if unreachable {
+		return "it can't be reached"
+	}
+	if c.refreshSnapshots {
+		return ""
+	}
+	checked, found := readLastUpdated(path + lastUpdatedExtension)[lastUpdatedKey(remote)]
+	if found && !remote.UpdatePolicy.isDueSince(checked) {
+		return fmt.Sprintf("it didn't have the file at %s, use --refresh-snapshots to ask again", checked.Format(time.DateTime))
+	}
+	return ""
+}
+
+// recordFetch records the outcome of downloading the file cached at path from the remote: a
+// remote that doesn't have it isn't asked again until its update policy is due, and a remote
+// that can't be reached at all is skipped for the rest of the build.
+func (c *LocalRepository) recordFetch(remote *Remote, path string, err error) {
+	switch {
+	case err == nil:
+		_ = os.Remove(path + lastUpdatedExtension)
+	case isNotFound(err):
+		lastUpdated := readLastUpdated(path + lastUpdatedExtension)
+		lastUpdated[lastUpdatedKey(remote)] = time.Now()
+		if err := writeLastUpdated(path+lastUpdatedExtension, lastUpdated); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+		}
+	case isUnreachable(err):
+		c.mu.Lock()
+		if c.unreachable == nil {
+			c.unreachable = make(map[*Remote]bool)
+		}
+		c.unreachable[remote] = true
+		c.mu.Unlock()
+		fmt.Fprintf(os.Stderr, "warning: %s can't be reached, skipping it for the rest of this build\n", remote.Name)
+	}
+}
+
+// readLastUpdated reads when each remote was last asked for a file, ignoring anything it can't
+// parse.
+func readLastUpdated(path string) map[string]time.Time {
+	lastUpdated := make(map[string]time.Time)
+	file, err := os.Open(path)
+	if err != nil {
+		return lastUpdated
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndex(line, "=")
+		if i < 0 || !strings.HasSuffix(line[:i], lastUpdatedExtension) {
+			continue
+		}
+		if millis, err := strconv.ParseInt(line[i+1:], 10, 64); err == nil {
+			lastUpdated[line[:i]] = time.UnixMilli(millis)
+		}
+	}
+	return lastUpdated
+}
+
+func writeLastUpdated(path string, lastUpdated map[string]time.Time) error {
+	var content strings.Builder
+	content.WriteString(resolverFileHeader)
+	content.WriteString("#" + time.Now().Format("Mon Jan 02 15:04:05 MST 2006") + "\n")
+	for _, key := range slices.Sorted(maps.Keys(lastUpdated)) {
+		content.WriteString(key + "=" + strconv.FormatInt(lastUpdated[key].UnixMilli(), 10) + "\n")
+	}
+	err := writeFileAtomic(path, 0644, func(out *os.File) error {
+		_, err := out.WriteString(content.String())
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record missing file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package maven
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetJAR_RemembersMissingFiles(t *testing.T) {
+	var requests atomic.Int32
+	published := atomic.Bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !published.Load() {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("jar content"))
+	}))
+	t.Cleanup(server.Close)
+	baseDir := t.TempDir()
+	newRepo := func(policy UpdatePolicy) *LocalRepository {
+		repo := NewLocalRepository(baseDir, server.URL)
+		repo.SetChecksumPolicy(ChecksumIgnore)
+		repo.remotes[0].UpdatePolicy = policy
+		return repo
+	}
+
+	path, err := newRepo(UpdateDaily).GetJAR("com.example", "lib", "1.0")
+	require.Error(t, err)
+	assert.True(t, isNotFound(err))
+	assert.Equal(t, int32(1), requests.Load())
+	lastUpdated := readLastUpdated(path + lastUpdatedExtension)
+	assert.Contains(t, lastUpdated, lastUpdatedKeys.Replace(server.URL)+".lastUpdated")
+
+	// a later build doesn't ask again until the update policy is due
+	published.Store(true)
+	_, err = newRepo(UpdateDaily).GetJAR("com.example", "lib", "1.0")
+	require.Error(t, err)
+	assert.Equal(t, int32(1), requests.Load())
+	assert.ErrorContains(t, err, "skipped "+server.URL+", it didn't have the file at")
+
+	_, err = newRepo(UpdateAlways).GetJAR("com.example", "lib", "1.0")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), requests.Load())
+	assert.NoFileExists(t, path+lastUpdatedExtension)
+}
+
+func TestLastUpdated_ReadWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lib-1.0.jar.lastUpdated")
+	checked := time.UnixMilli(1704164645000)
+	remote := &Remote{URL: "https://repo.example.com/maven2/"}
+	require.NoError(t, writeLastUpdated(path, map[string]time.Time{lastUpdatedKey(remote): checked}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "\nhttps\\://repo.example.com/maven2/.lastUpdated=1704164645000\n")
+	lastUpdated := readLastUpdated(path)
+	assert.Equal(t, checked, lastUpdated[lastUpdatedKey(remote)])
+
+	// a missing file has no entries
+	assert.Empty(t, readLastUpdated(path+".missing"))
+}
+
+func TestGetJAR_SkipsUnreachableRemote(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	repo := NewLocalRepository(t.TempDir(), server.URL)
+
+	_, err := repo.GetJAR("com.example", "lib", "1.0")
+	require.Error(t, err)
+	assert.True(t, repo.unreachable[repo.remotes[0]])
+	assert.True(t, repo.skipRemote(repo.remotes[0], filepath.Join(repo.BaseDir(), "other.jar")))
+}
+
+func TestGetJAR_RefreshRetriesMissingFiles(t *testing.T) {
+	published := atomic.Bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !published.Load() {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("jar content"))
+	}))
+	t.Cleanup(server.Close)
+	baseDir := t.TempDir()
+	newRepo := func(refresh bool) *LocalRepository {
+		repo := NewLocalRepository(baseDir, server.URL)
+		repo.SetChecksumPolicy(ChecksumIgnore)
+		repo.SetRefreshSnapshots(refresh)
+		repo.remotes[0].UpdatePolicy = UpdateDaily
+		return repo
+	}
+
+	path, err := newRepo(false).GetJAR("com.example", "lib", "1.0")
+	require.Error(t, err)
+	require.FileExists(t, path+lastUpdatedExtension)
+
+	published.Store(true)
+	_, err = newRepo(false).GetJAR("com.example", "lib", "1.0")
+	require.Error(t, err)
+
+	// refreshing asks again even though the remote recently didn't have the file
+	path, err = newRepo(true).GetJAR("com.example", "lib", "1.0")
+	require.NoError(t, err)
+	assert.FileExists(t, path)
+	assert.NoFileExists(t, path+lastUpdatedExtension)
+}
@@ -0,0 +1,198 @@
+package maven
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MavenLocalDir is where maven keeps its local repository.
+const MavenLocalDir = "~/.m2/repository"
+
+const (
+	localMetadataFile      = "maven-metadata-local.xml"
+	remoteRepositoriesFile = "_remote.repositories"
+	resolverFileHeader     = "#NOTE: This is a Maven Resolver internal implementation file, its format can be changed without prior notice.\n"
+)
+
+// SetMavenLocal sets the maven local repository that InstallMavenLocal installs into. If
+// readThrough is set, files missing from this repository are also looked up there before they
+// are downloaded. The maven local repository is never written to otherwise.
+func (c *LocalRepository) SetMavenLocal(dir string, readThrough bool) {
+	c.mavenLocalDir = dir
+	c.readMavenLocal = readThrough && dir != ""
+}
+
+// mavenLocalPath returns the path of a file in the maven local repository, or an empty string if
+// it isn't there or the maven local repository isn't read. A file that is incomplete, or doesn't
+// match the checksum maven stored next to it, is ignored with a warning so that a good copy is
+// downloaded into this repository instead.
+func (c *LocalRepository) mavenLocalPath(groupID, artifactID, version, file string) string {
+	if !c.readMavenLocal {
+		return ""
+	}
+	path := filepath.Join(repositoryDir(expandHome(c.mavenLocalDir), groupID, artifactID, version), file)
+	if !fileExists(path) {
+		return ""
+	}
+	reason := incompleteReason(path)
+	if reason == "" && c.checksumPolicy != ChecksumIgnore {
+		reason = storedChecksumProblem(path)
+	}
+	if reason != "" {
+		fmt.Fprintf(os.Stderr, "warning: ignoring %s in the maven local repository: %s\n", path, reason)
+		return ""
+	}
+	return path
+}
+
+// installedSnapshot returns the newer of the files installed under the plain snapshot name in
+// this repository and the maven local repository, or an empty string if there are none.
+func (c *LocalRepository) installedSnapshot(groupID, artifactID, version, file string) string {
+	installed := ""
+	var installedTime time.Time
+	for _, path := range []string{
+		filepath.Join(c.artifactDir(groupID, artifactID, version), file),
+		c.mavenLocalPath(groupID, artifactID, version, file),
+	} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err == nil && (installed == "" || info.ModTime().After(installedTime)) {
+			installed, installedTime = path, info.ModTime()
+		}
+	}
+	return installed
+}
+
+// InstallMavenLocal copies the files of a version of an artifact into the maven local
+// repository the way 'mvn install' does, so that maven builds can use it. Besides the files it
+// updates maven-metadata-local.xml and _remote.repositories. Each directory is locked while it
+// is updated and every file is replaced atomically, so a build reading it never sees part of one.
+func (c *LocalRepository) InstallMavenLocal(groupID, artifactID, version string, files []ArtifactFile) error {
+	if c.mavenLocalDir == "" {
+		return fmt.Errorf("no maven local repository is configured")
+	}
+	baseDir := expandHome(c.mavenLocalDir)
+	versionDir := repositoryDir(baseDir, groupID, artifactID, version)
+	unlock, err := lockDir(versionDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	now := time.Now().UTC()
+	lastUpdated := now.Format("20060102150405")
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		name := file.name(artifactID, version)
+		if err := copyFile(file.Path, filepath.Join(versionDir, name)); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", file.Path, err)
+		}
+		names = append(names, name)
+	}
+	if err := writeRemoteRepositories(filepath.Join(versionDir, remoteRepositoriesFile), names, now); err != nil {
+		return err
+	}
+
+	if isSnapshot(version) {
+		path := filepath.Join(versionDir, localMetadataFile)
+		metadata, err := readLocalMetadata(path, groupID, artifactID, version)
+		if err != nil {
+			return err
+		}
+		metadata.Versioning.Snapshot = &Snapshot{LocalCopy: true}
+		metadata.Versioning.LastUpdated = lastUpdated
+		for _, file := range files {
+			metadata.setSnapshotVersion(SnapshotVersion{
+				Classifier: file.Classifier,
+				Extension:  file.Extension,
+				Value:      version,
+				Updated:    lastUpdated,
+			})
+		}
+		if err := writeMetadata(path, metadata); err != nil {
+			return err
+		}
+	}
+
+	artifactDir := repositoryDir(baseDir, groupID, artifactID, "")
+	unlockArtifact, err := lockDir(artifactDir)
+	if err != nil {
+		return err
+	}
+	defer unlockArtifact()
+	path := filepath.Join(artifactDir, localMetadataFile)
+	metadata, err := readLocalMetadata(path, groupID, artifactID, "")
+	if err != nil {
+		return err
+	}
+	metadata.addVersion(version)
+	metadata.Versioning.LastUpdated = lastUpdated
+	if err := writeMetadata(path, metadata); err != nil {
+		return err
+	}
+	fmt.Printf("Successfully published %s to maven local repository\n", GAV(groupID, artifactID, version))
+	return nil
+}
+
+// readLocalMetadata reads maven-metadata-local.xml, returning empty metadata if there is none.
+func readLocalMetadata(path, groupID, artifactID, version string) (*Metadata, error) {
+	metadata, err := readMetadata(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Metadata{GroupID: groupID, ArtifactID: artifactID, Version: version}, nil
+	}
+	return metadata, err
+}
+
+func writeMetadata(path string, metadata *Metadata) error {
+	data, err := xml.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize %s: %w", path, err)
+	}
+	data = append([]byte(xml.Header), data...)
+	return writeFileAtomic(path, 0644, func(out *os.File) error {
+		_, err := out.Write(append(data, '\n'))
+		return err
+	})
+}
+
+// writeRemoteRepositories records in _remote.repositories that the files were installed locally
+// rather than downloaded, which maven marks with an empty repository id. Entries for other files
+// are kept.
+func writeRemoteRepositories(path string, names []string, now time.Time) error {
+	entries := make([]string, 0)
+	if file, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			name, _, found := strings.Cut(line, ">")
+			if found && !strings.HasPrefix(line, "#") && !slices.Contains(names, name) {
+				entries = append(entries, line)
+			}
+		}
+		file.Close()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for _, name := range names {
+		entries = append(entries, name+">=")
+	}
+	var content strings.Builder
+	content.WriteString(resolverFileHeader)
+	content.WriteString("#" + now.Format("Mon Jan 02 15:04:05 MST 2006") + "\n")
+	for _, entry := range entries {
+		content.WriteString(entry + "\n")
+	}
+	return writeFileAtomic(path, 0644, func(out *os.File) error {
+		_, err := out.WriteString(content.String())
+		return err
+	})
+}
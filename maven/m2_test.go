@@ -0,0 +1,133 @@
+package maven
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRepositoryFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestGetJAR_MavenLocal(t *testing.T) {
+	m2Dir := t.TempDir()
+	writeRepositoryFile(t, filepath.Join(m2Dir, "com/example/lib/1.0/lib-1.0.jar"), "from m2")
+	repo := NewLocalRepository(t.TempDir())
+	repo.SetOffline(true)
+
+	// without read through the file isn't found
+	repo.SetMavenLocal(m2Dir, false)
+	_, err := repo.GetJAR("com.example", "lib", "1.0")
+	var notCached *NotCachedError
+	assert.ErrorAs(t, err, &notCached)
+
+	repo.SetMavenLocal(m2Dir, true)
+	_, err = repo.GetJAR("com.example", "lib", "1.1")
+	assert.ErrorAs(t, err, &notCached)
+	path, err := repo.GetJAR("com.example", "lib", "1.0")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(m2Dir, "com/example/lib/1.0/lib-1.0.jar"), path)
+
+	// files in jb's repository come first
+	writeRepositoryFile(t, filepath.Join(repo.BaseDir(), "com/example/lib/2.0/lib-2.0.jar"), "from jb")
+	writeRepositoryFile(t, filepath.Join(m2Dir, "com/example/lib/2.0/lib-2.0.jar"), "from m2")
+	path, err = repo.GetJAR("com.example", "lib", "2.0")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(path, repo.BaseDir()))
+}
+
+func TestGetJAR_MavenLocalSnapshot(t *testing.T) {
+	m2Dir := t.TempDir()
+	repo := NewLocalRepository(t.TempDir())
+	repo.SetOffline(true)
+	repo.SetMavenLocal(m2Dir, true)
+	jbPath := filepath.Join(repo.BaseDir(), "com/example/lib/1.0-SNAPSHOT/lib-1.0-SNAPSHOT.jar")
+	m2Path := filepath.Join(m2Dir, "com/example/lib/1.0-SNAPSHOT/lib-1.0-SNAPSHOT.jar")
+	writeRepositoryFile(t, jbPath, "installed by jb")
+	writeRepositoryFile(t, m2Path, "installed by maven")
+
+	// the newer install wins
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(jbPath, past, past))
+	path, err := repo.GetJAR("com.example", "lib", "1.0-SNAPSHOT")
+	require.NoError(t, err)
+	assert.Equal(t, m2Path, path)
+
+	require.NoError(t, os.Chtimes(m2Path, past.Add(-time.Hour), past.Add(-time.Hour)))
+	path, err = repo.GetJAR("com.example", "lib", "1.0-SNAPSHOT")
+	require.NoError(t, err)
+	assert.Equal(t, jbPath, path)
+}
+
+func TestGetJAR_MavenLocalIgnoresBrokenFiles(t *testing.T) {
+	m2Dir := t.TempDir()
+	writeRepositoryFile(t, filepath.Join(m2Dir, "com/example/lib/1.0/lib-1.0.jar"), "PK\x03\x04 cut short")
+	writeRepositoryFile(t, filepath.Join(m2Dir, "com/example/lib/2.0/lib-2.0.jar"), "changed since maven downloaded it")
+	writeRepositoryFile(t, filepath.Join(m2Dir, "com/example/lib/2.0/lib-2.0.jar.sha1"), "9e5c8d4f8b0ab0f5b3dc6e9a0f9ba0bc44bd2d0e")
+	server := newTestRemote(t, map[string]string{
+		"com/example/lib/1.0/lib-1.0.jar": "jar content",
+		"com/example/lib/2.0/lib-2.0.jar": "jar content",
+	})
+	repo := NewLocalRepository(t.TempDir(), server.URL)
+	repo.SetMavenLocal(m2Dir, true)
+
+	for _, version := range []string{"1.0", "2.0"} {
+		path, err := repo.GetJAR("com.example", "lib", version)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(path, repo.BaseDir()), version)
+	}
+}
+
+func TestInstallMavenLocal(t *testing.T) {
+	m2Dir := t.TempDir()
+	repo := NewLocalRepository(t.TempDir())
+	repo.SetMavenLocal(m2Dir, false)
+	versionDir := filepath.Join(m2Dir, "com", "example", "lib", "1.0-SNAPSHOT")
+	writeRepositoryFile(t, filepath.Join(versionDir, remoteRepositoriesFile), "#NOTE\nlib-1.0-SNAPSHOT-tests.jar>central\nlib-1.0-SNAPSHOT.jar>central\n")
+
+	require.NoError(t, repo.InstallMavenLocal("com.example", "lib", "1.0", writeArtifactFiles(t, "release")))
+	require.NoError(t, repo.InstallMavenLocal("com.example", "lib", "1.0-SNAPSHOT", writeArtifactFiles(t, "snapshot")))
+
+	data, err := os.ReadFile(filepath.Join(versionDir, "lib-1.0-SNAPSHOT.jar"))
+	require.NoError(t, err)
+	assert.Equal(t, "snapshot", string(data))
+
+	data, err = os.ReadFile(filepath.Join(versionDir, remoteRepositoriesFile))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Equal(t, []string{"lib-1.0-SNAPSHOT-tests.jar>central", "lib-1.0-SNAPSHOT.jar>=", "lib-1.0-SNAPSHOT.pom>="}, lines[2:])
+
+	snapshotMetadata, err := readMetadata(filepath.Join(versionDir, localMetadataFile))
+	require.NoError(t, err)
+	assert.Equal(t, "1.0-SNAPSHOT", snapshotMetadata.Version)
+	require.NotNil(t, snapshotMetadata.Versioning.Snapshot)
+	assert.True(t, snapshotMetadata.Versioning.Snapshot.LocalCopy)
+	require.Len(t, snapshotMetadata.Versioning.SnapshotVersions, 2)
+	assert.Equal(t, "1.0-SNAPSHOT", snapshotMetadata.Versioning.SnapshotVersions[0].Value)
+
+	// every file was written in place atomically
+	parts, err := filepath.Glob(filepath.Join(versionDir, "*.part"))
+	require.NoError(t, err)
+	assert.Empty(t, parts)
+
+	metadata, err := readMetadata(filepath.Join(m2Dir, "com", "example", "lib", localMetadataFile))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.0-SNAPSHOT", "1.0"}, metadata.Versioning.Versions)
+	assert.Equal(t, "1.0", metadata.Versioning.Release)
+
+	// maven builds reading through find the installed snapshot
+	reader := NewLocalRepository(t.TempDir())
+	reader.SetOffline(true)
+	reader.SetMavenLocal(m2Dir, true)
+	path, err := reader.GetJAR("com.example", "lib", "1.0-SNAPSHOT")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(versionDir, "lib-1.0-SNAPSHOT.jar"), path)
+}
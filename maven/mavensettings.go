@@ -0,0 +1,140 @@
+package maven
+
+import (
+	"cmp"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MavenSettingsPath is where maven reads the user's settings from.
+const MavenSettingsPath = "~/.m2/settings.xml"
+
+// mavenSettings is the part of maven's settings.xml that jb understands.
+type mavenSettings struct {
+	Servers []mavenServer `xml:"servers>server"`
+	Mirrors []struct {
+		ID       string `xml:"id"`
+		URL      string `xml:"url"`
+		MirrorOf string `xml:"mirrorOf"`
+		Blocked  bool   `xml:"blocked"`
+	} `xml:"mirrors>mirror"`
+	Proxies []struct {
+		Active        string `xml:"active"`
+		Protocol      string `xml:"protocol"`
+		Host          string `xml:"host"`
+		Port          string `xml:"port"`
+		Username      string `xml:"username"`
+		Password      string `xml:"password"`
+		NonProxyHosts string `xml:"nonProxyHosts"`
+	} `xml:"proxies>proxy"`
+}
+
+// mavenServer holds the credentials for the repositories and mirrors with the same id.
+type mavenServer struct {
+	ID       string `xml:"id"`
+	Username string `xml:"username"`
+	Password string `xml:"password"`
+}
+
+// ImportMavenSettings reads the servers, mirrors and proxies of maven's settings.xml as jb
+// settings, so that a team's maven configuration works for jb unchanged. It returns empty
+// settings if the file doesn't exist. The credentials of a server apply to the mirror or
+// repository with the same id, and are only kept in memory. ${env.NAME} references are
+// expanded, but passwords encrypted with maven's master password are not supported.
+func ImportMavenSettings(path string) (*Settings, error) {
+	settings := &Settings{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var maven mavenSettings
+	if err := xml.Unmarshal(data, &maven); err != nil {
+		return nil, fmt.Errorf("invalid maven settings %s: %w", path, err)
+	}
+
+	settings.servers = make(map[string]mavenServer)
+	for _, server := range maven.Servers {
+		server.Username = expandEnv(server.Username)
+		server.Password = expandEnv(server.Password)
+		if strings.HasPrefix(server.Password, "{") && strings.HasSuffix(server.Password, "}") {
+			fmt.Fprintf(os.Stderr, "warning: ignoring the encrypted password of server %s in %s, use ${env.NAME} instead\n", server.ID, path)
+			server.Password = ""
+		}
+		settings.servers[server.ID] = server
+	}
+	for _, mirror := range maven.Mirrors {
+		if mirror.Blocked {
+			continue
+		}
+		server := settings.servers[mirror.ID]
+		settings.Mirrors = append(settings.Mirrors, MirrorConfig{
+			Name:     mirror.ID,
+			URL:      expandEnv(mirror.URL),
+			MirrorOf: mirror.MirrorOf,
+			username: server.Username,
+			password: server.Password,
+		})
+	}
+	for _, proxy := range maven.Proxies {
+		if strings.TrimSpace(proxy.Active) == "false" {
+			continue
+		}
+		port, err := strconv.Atoi(strings.TrimSpace(cmp.Or(proxy.Port, "8080")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid maven settings %s: proxy port '%s' is not a number", path, proxy.Port)
+		}
+		settings.HTTP = &HTTPSettings{Proxy: &ProxyConfig{
+			Host:          expandEnv(proxy.Host),
+			Port:          port,
+			Protocol:      proxy.Protocol,
+			NonProxyHosts: strings.FieldsFunc(proxy.NonProxyHosts, func(r rune) bool { return r == '|' || r == ',' }),
+			username:      expandEnv(proxy.Username),
+			password:      expandEnv(proxy.Password),
+		}}
+		break // like maven, only the first active proxy is used
+	}
+	return settings, nil
+}
+
+// expandEnv replaces ${env.NAME} references with the value of the environment variable.
+func expandEnv(s string) string {
+	return mavenVarPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name, found := strings.CutPrefix(ref[2:len(ref)-1], "env.")
+		if !found {
+			return ref
+		}
+		return os.Getenv(name)
+	})
+}
+
+// addMavenSettings adds the settings imported from maven's settings.xml. The user's own settings
+// come first: its mirrors are matched before maven's, and its proxy replaces maven's.
+func (s *Settings) addMavenSettings(maven *Settings) {
+	s.Mirrors = append(s.Mirrors, maven.Mirrors...)
+	if maven.HTTP != nil && maven.HTTP.Proxy != nil {
+		if s.HTTP == nil {
+			s.HTTP = &HTTPSettings{}
+		}
+		if s.HTTP.Proxy == nil {
+			s.HTTP.Proxy = maven.HTTP.Proxy
+		}
+	}
+	s.servers = maven.servers
+}
+
+// withServerCredentials returns the repository with the credentials of the maven server with the
+// same id, unless it has credentials of its own.
+func (s *Settings) withServerCredentials(config RepositoryConfig) RepositoryConfig {
+	server, found := s.servers[config.Name]
+	if found && config.UsernameEnv == "" && config.PasswordEnv == "" && config.TokenEnv == "" {
+		config.username, config.password = server.Username, server.Password
+	}
+	return config
+}
@@ -0,0 +1,113 @@
+package maven
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMavenSettings = `<?xml version="1.0" encoding="UTF-8"?>
+<settings>
+  <servers>
+    <server>
+      <id>nexus</id>
+      <username>${env.NEXUS_USER}</username>
+      <password>${env.NEXUS_PASSWORD}</password>
+    </server>
+    <server>
+      <id>releases</id>
+      <username>deployer</username>
+      <password>{COQLCE6DU6GtcS5P=}</password>
+    </server>
+  </servers>
+  <mirrors>
+    <mirror>
+      <id>maven-default-http-blocker</id>
+      <mirrorOf>external:http:*</mirrorOf>
+      <url>http://0.0.0.0/</url>
+      <blocked>true</blocked>
+    </mirror>
+    <mirror>
+      <id>nexus</id>
+      <mirrorOf>*</mirrorOf>
+      <url>https://nexus.example.com/repository/maven-public/</url>
+    </mirror>
+  </mirrors>
+  <proxies>
+    <proxy>
+      <id>inactive</id>
+      <active>false</active>
+      <host>old-proxy.example.com</host>
+      <port>3128</port>
+    </proxy>
+    <proxy>
+      <id>corporate</id>
+      <protocol>http</protocol>
+      <host>proxy.example.com</host>
+      <port>8080</port>
+      <username>proxyuser</username>
+      <password>proxypass</password>
+      <nonProxyHosts>localhost|*.example.com</nonProxyHosts>
+    </proxy>
+  </proxies>
+</settings>
+`
+
+func TestImportMavenSettings(t *testing.T) {
+	t.Setenv("NEXUS_USER", "alice")
+	t.Setenv("NEXUS_PASSWORD", "secret")
+	path := filepath.Join(t.TempDir(), "settings.xml")
+	require.NoError(t, os.WriteFile(path, []byte(testMavenSettings), 0644))
+
+	settings, err := ImportMavenSettings(path)
+	require.NoError(t, err)
+	require.Len(t, settings.Mirrors, 1)
+	assert.Equal(t, MirrorConfig{
+		Name:     "nexus",
+		URL:      "https://nexus.example.com/repository/maven-public/",
+		MirrorOf: "*",
+		username: "alice",
+		password: "secret",
+	}, settings.Mirrors[0])
+	assert.Equal(t, &ProxyConfig{
+		Host:          "proxy.example.com",
+		Port:          8080,
+		Protocol:      "http",
+		NonProxyHosts: []string{"localhost", "*.example.com"},
+		username:      "proxyuser",
+		password:      "proxypass",
+	}, settings.HTTP.Proxy)
+
+	// server credentials apply to repositories with the same id, encrypted passwords don't work
+	releases := settings.withServerCredentials(RepositoryConfig{Name: "releases"})
+	assert.Equal(t, "deployer", releases.username)
+	assert.Empty(t, releases.password)
+	own := settings.withServerCredentials(RepositoryConfig{Name: "nexus", TokenEnv: "NEXUS_TOKEN"})
+	assert.Empty(t, own.username)
+
+	settings, err = ImportMavenSettings(filepath.Join(t.TempDir(), "missing.xml"))
+	require.NoError(t, err)
+	assert.Empty(t, settings.Mirrors)
+}
+
+func TestOpenLocalRepository_MavenSettings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".m2"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".m2", "settings.xml"), []byte(testMavenSettings), 0644))
+
+	repo := OpenLocalRepository()
+	require.Len(t, repo.Remotes(), 1)
+	assert.Equal(t, "nexus", repo.Remotes()[0].Name)
+	assert.Equal(t, "https://nexus.example.com/repository/maven-public/", repo.Remotes()[0].URL)
+	assert.NotNil(t, repo.client.client.Transport)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".jb"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".jb", SettingsFilename), []byte(`{"maven_settings": false}`), 0644))
+	repo = OpenLocalRepository()
+	require.Len(t, repo.Remotes(), 1)
+	assert.Equal(t, MAVEN_CENTRAL_URL, repo.Remotes()[0].URL)
+}
@@ -0,0 +1,197 @@
+package maven
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+)
+
+const metadataFile = "maven-metadata.xml"
+
+// Metadata is the maven-metadata.xml a repository publishes for an artifact, listing the
+// versions it has.
+type Metadata struct {
+	XMLName    xml.Name   `xml:"metadata"`
+	GroupID    string     `xml:"groupId"`
+	ArtifactID string     `xml:"artifactId"`
+	Version    string     `xml:"version,omitempty"` // only in the metadata of a snapshot version
+	Versioning Versioning `xml:"versioning"`
+}
+
+type Versioning struct {
+	Latest           string            `xml:"latest,omitempty"`
+	Release          string            `xml:"release,omitempty"`
+	Snapshot         *Snapshot         `xml:"snapshot,omitempty"`
+	Versions         []string          `xml:"versions>version"`
+	LastUpdated      string            `xml:"lastUpdated,omitempty"`
+	SnapshotVersions []SnapshotVersion `xml:"snapshotVersions>snapshotVersion"`
+}
+
+// Snapshot identifies the latest build of a snapshot version.
+type Snapshot struct {
+	Timestamp   string `xml:"timestamp,omitempty"` // yyyyMMdd.HHmmss in UTC
+	BuildNumber int    `xml:"buildNumber,omitempty"`
+	LocalCopy   bool   `xml:"localCopy,omitempty"` // files are published without a timestamp
+}
+
+// SnapshotVersion is the timestamped version of one file of the latest snapshot build.
+type SnapshotVersion struct {
+	Classifier string `xml:"classifier,omitempty"`
+	Extension  string `xml:"extension"`
+	Value      string `xml:"value"`
+	Updated    string `xml:"updated"`
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// metadataCacheFile is the name the metadata from a remote is cached under, each remote gets
+// its own copy so that they can be merged.
+func metadataCacheFile(remote *Remote) string {
+	return "maven-metadata-" + unsafeFileChars.ReplaceAllString(remote.Name, "_") + ".xml"
+}
+
+// GetMetadata returns the metadata of an artifact merged from every remote that has it. Cached
+// copies are refreshed according to each remote's update policy, or never in offline mode.
+func (c *LocalRepository) GetMetadata(groupID, artifactID string) (*Metadata, error) {
+	if groupID == "" || artifactID == "" {
+		return nil, fmt.Errorf("invalid maven coordinates %s:%s", groupID, artifactID)
+	}
+	key := groupID + ":" + artifactID
+	c.mu.Lock()
+	metadata, found := c.metadata[key]
+	c.mu.Unlock()
+	if found {
+		return metadata, nil
+	}
+	return c.metadataLoads.do(key, func() (*Metadata, error) {
+		metadata, err := c.loadMetadata(groupID, artifactID)
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.metadata[key] = metadata
+		c.mu.Unlock()
+		return metadata, nil
+	})
+}
+
+func (c *LocalRepository) loadMetadata(groupID, artifactID string) (*Metadata, error) {
+	merged := &Metadata{GroupID: groupID, ArtifactID: artifactID}
+	found := false
+	dir := c.artifactDir(groupID, artifactID, "")
+	for _, remote := range c.remotes {
+		path := filepath.Join(dir, metadataCacheFile(remote))
+		if !c.offline && remote.UpdatePolicy.isDue(path) {
+			if err := c.refreshFile(remote, groupID, artifactID, "", metadataFile, path); err != nil {
+				fmt.Fprintf(os.Stderr, "error fetching from maven %s: %s\n", remote.Name, err.Error())
+			}
+		}
+		if !fileExists(path) {
+			continue
+		}
+		metadata, err := readMetadata(path)
+		if err != nil {
+			return nil, err
+		}
+		merged.merge(metadata)
+		found = true
+	}
+	if !found {
+		if c.offline {
+			return nil, &NotCachedError{GroupID: groupID, ArtifactID: artifactID, File: metadataFile}
+		}
+		return nil, fmt.Errorf("failed to fetch %s for %s:%s from any remote", metadataFile, groupID, artifactID)
+	}
+	return merged, nil
+}
+
+// refreshFile downloads a file again, replacing the cached copy at path only once the download
+// succeeds. Nothing is downloaded if the remote recently didn't have the file.
+func (c *LocalRepository) refreshFile(remote *Remote, groupID, artifactID, version, file, path string) error {
+	if c.skipRemote(remote, path) {
+		return nil
+	}
+	unlock, err := lockDir(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return c.download(remote, groupID, artifactID, version, file, path)
+}
+
+func readMetadata(path string) (*Metadata, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	metadata := &Metadata{}
+	if err := xml.Unmarshal(data, metadata); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return metadata, nil
+}
+
+// merge adds the versions from other, keeping the versions sorted oldest first.
+func (m *Metadata) merge(other *Metadata) {
+	for _, version := range other.Versioning.Versions {
+		if !slices.Contains(m.Versioning.Versions, version) {
+			m.Versioning.Versions = append(m.Versioning.Versions, version)
+		}
+	}
+	slices.SortStableFunc(m.Versioning.Versions, CompareVersions)
+	m.Versioning.Latest = newestVersion(m.Versioning.Latest, other.Versioning.Latest)
+	m.Versioning.Release = newestVersion(m.Versioning.Release, other.Versioning.Release)
+	m.Versioning.LastUpdated = max(m.Versioning.LastUpdated, other.Versioning.LastUpdated)
+}
+
+func newestVersion(a, b string) string {
+	if a == "" || (b != "" && CompareVersions(b, a) > 0) {
+		return b
+	}
+	return a
+}
+
+// ResolveVersion turns a version range or the LATEST and RELEASE keywords into the newest
+// matching version listed in the artifact's metadata. Snapshots are only selected by LATEST or
+// by a range with a snapshot boundary. Any other version is returned as is.
+func (c *LocalRepository) ResolveVersion(groupID, artifactID, spec string) (string, error) {
+	var matches func(Version) bool
+	switch {
+	case spec == "LATEST":
+		matches = func(Version) bool { return true }
+	case spec == "RELEASE":
+		matches = func(v Version) bool { return !v.IsSnapshot() }
+	case IsVersionRange(spec):
+		versionRange, err := ParseVersionRange(spec)
+		if err != nil {
+			return "", err
+		}
+		snapshots := versionRange.allowsSnapshots()
+		matches = func(v Version) bool { return versionRange.Contains(v) && (snapshots || !v.IsSnapshot()) }
+	default:
+		return spec, nil
+	}
+	metadata, err := c.GetMetadata(groupID, artifactID)
+	if err != nil {
+		return "", err
+	}
+	versions := metadata.Versioning.Versions
+	for i := len(versions) - 1; i >= 0; i-- {
+		if matches(ParseVersion(versions[i])) {
+			return versions[i], nil
+		}
+	}
+	return "", fmt.Errorf("no version of %s:%s matches %s", groupID, artifactID, spec)
+}
+
+// ValidateVersion returns an error if version is a malformed range.
+func ValidateVersion(version string) error {
+	if !IsVersionRange(version) {
+		return nil
+	}
+	_, err := ParseVersionRange(version)
+	return err
+}
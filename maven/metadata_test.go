@@ -0,0 +1,82 @@
+package maven
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMetadata(versions ...string) string {
+	xml := "<metadata><groupId>com.example</groupId><artifactId>lib</artifactId><versioning><versions>"
+	for _, version := range versions {
+		xml += "<version>" + version + "</version>"
+	}
+	return xml + "</versions></versioning></metadata>"
+}
+
+func TestGetMetadata_MergesRemotes(t *testing.T) {
+	first := newTestRemote(t, map[string]string{"com/example/lib/maven-metadata.xml": testMetadata("1.0", "1.2", "2.0-SNAPSHOT")})
+	second := newTestRemote(t, map[string]string{"com/example/lib/maven-metadata.xml": testMetadata("1.10", "1.2", "1.9")})
+	baseDir := t.TempDir()
+	repo := NewLocalRepository(baseDir)
+	repo.SetChecksumPolicy(ChecksumIgnore)
+	require.NoError(t, repo.SetRepositories([]RepositoryConfig{
+		{Name: "first", URL: first.URL},
+		{Name: "second", URL: second.URL},
+		{Name: "central", URL: second.URL + "/missing"},
+	}))
+
+	metadata, err := repo.GetMetadata("com.example", "lib")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.0", "1.2", "1.9", "1.10", "2.0-SNAPSHOT"}, metadata.Versioning.Versions)
+	assert.FileExists(t, filepath.Join(baseDir, "com", "example", "lib", "maven-metadata-first.xml"))
+	assert.FileExists(t, filepath.Join(baseDir, "com", "example", "lib", "maven-metadata-second.xml"))
+	assert.NoFileExists(t, filepath.Join(baseDir, "com", "example", "lib", "maven-metadata-central.xml"))
+
+	// a fresh cached copy is used without asking the remote again, and offline mode uses even
+	// a stale copy
+	repo = NewLocalRepository(baseDir)
+	repo.SetOffline(true)
+	require.NoError(t, repo.SetRepositories([]RepositoryConfig{{Name: "first", URL: "http://127.0.0.1:1/"}}))
+	old := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(baseDir, "com", "example", "lib", "maven-metadata-first.xml"), old, old))
+	metadata, err = repo.GetMetadata("com.example", "lib")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.0", "1.2", "2.0-SNAPSHOT"}, metadata.Versioning.Versions)
+
+	_, err = repo.GetMetadata("com.example", "other")
+	assert.EqualError(t, err, "com.example:other (maven-metadata.xml) is not in the local repository")
+}
+
+func TestResolveVersion(t *testing.T) {
+	server := newTestRemote(t, map[string]string{
+		"com/example/lib/maven-metadata.xml": testMetadata("1.0", "1.2", "1.5", "2.0", "2.1-SNAPSHOT"),
+	})
+	repo := NewLocalRepository(t.TempDir(), server.URL)
+	repo.SetChecksumPolicy(ChecksumIgnore)
+
+	tests := map[string]string{
+		"1.1":                "1.1",
+		"[1.2,2.0)":          "1.5",
+		"[1.0,1.5]":          "1.5",
+		"(,1.2]":             "1.2",
+		"[1.0,)":             "2.0",
+		"[2.0,2.1-SNAPSHOT]": "2.1-SNAPSHOT",
+		"RELEASE":            "2.0",
+		"LATEST":             "2.1-SNAPSHOT",
+	}
+	for spec, expected := range tests {
+		version, err := repo.ResolveVersion("com.example", "lib", spec)
+		require.NoError(t, err, spec)
+		assert.Equal(t, expected, version, spec)
+	}
+
+	_, err := repo.ResolveVersion("com.example", "lib", "[3.0,)")
+	assert.EqualError(t, err, "no version of com.example:lib matches [3.0,)")
+	_, err = repo.ResolveVersion("com.example", "lib", "[3.0")
+	assert.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package maven
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// MirrorConfig redirects downloads from some repositories to a mirror, like a <mirror> in maven's
+// settings.xml. Credentials are given the same way as for a repository.
+type MirrorConfig struct {
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	MirrorOf    string `json:"mirror_of"` // repository name, "*", "external:*", or a comma separated list such as "*,!internal"
+	UsernameEnv string `json:"username_env,omitempty"`
+	PasswordEnv string `json:"password_env,omitempty"`
+	TokenEnv    string `json:"token_env,omitempty"`
+	username    string // from maven's settings.xml, never written to settings.json
+	password    string
+}
+
+// applyMirrors replaces each remote that a mirror matches with the mirror. Like maven, a mirror
+// of the remote by name wins over one matching it by a pattern, and otherwise the first matching
+// mirror wins. A mirror of several remotes takes the place of the first of them and serves
+// releases and snapshots if any of them does.
+func applyMirrors(remotes []*Remote, mirrors []MirrorConfig) ([]*Remote, error) {
+	if len(mirrors) == 0 {
+		return remotes, nil
+	}
+	mirrored := make([]*Remote, 0, len(remotes))
+	byName := make(map[string]*Remote)
+	for _, remote := range remotes {
+		config := selectMirror(mirrors, remote)
+		if config == nil {
+			mirrored = append(mirrored, remote)
+			continue
+		}
+		mirror, found := byName[config.Name]
+		if !found {
+			var err error
+			mirror, err = NewRemote(RepositoryConfig{
+				Name:        config.Name,
+				URL:         config.URL,
+				UsernameEnv: config.UsernameEnv,
+				PasswordEnv: config.PasswordEnv,
+				TokenEnv:    config.TokenEnv,
+				username:    config.username,
+				password:    config.password,
+			})
+			if err != nil {
+				return nil, err
+			}
+			mirror.Releases, mirror.Snapshots = false, false
+			mirror.UpdatePolicy = remote.UpdatePolicy
+			mirror.client = remote.client
+			byName[config.Name] = mirror
+			mirrored = append(mirrored, mirror)
+		}
+		mirror.Releases = mirror.Releases || remote.Releases
+		mirror.Snapshots = mirror.Snapshots || remote.Snapshots
+		mirror.mirrorOf = append(mirror.mirrorOf, remote)
+	}
+	return mirrored, nil
+}
+
+func selectMirror(mirrors []MirrorConfig, remote *Remote) *MirrorConfig {
+	for i := range mirrors {
+		if strings.TrimSpace(mirrors[i].MirrorOf) == remote.Name {
+			return &mirrors[i]
+		}
+	}
+	for i := range mirrors {
+		if mirrorMatches(mirrors[i].MirrorOf, remote) {
+			return &mirrors[i]
+		}
+	}
+	return nil
+}
+
+// mirrorMatches returns true if a mirrorOf pattern matches the remote. The pattern is a comma
+// separated list where "*" matches every remote, "external:*" every remote that isn't on this
+// machine, "external:http:*" those that also use plain http, a name matches the remote with that
+// name, and "!name" excludes it.
+func mirrorMatches(mirrorOf string, remote *Remote) bool {
+	patterns := strings.Split(mirrorOf, ",")
+	for i := range patterns {
+		patterns[i] = strings.TrimSpace(patterns[i])
+	}
+	if slices.Contains(patterns, "!"+remote.Name) {
+		return false
+	}
+	for _, pattern := range patterns {
+		switch pattern {
+		case "*", remote.Name:
+			return true
+		case "external:*":
+			if !isLocalURL(remote.URL) {
+				return true
+			}
+		case "external:http:*":
+			if !isLocalURL(remote.URL) && strings.HasPrefix(remote.URL, "http:") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isLocalURL(remoteURL string) bool {
+	u, err := url.Parse(remoteURL)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	return u.Scheme == "file" || host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
@@ -0,0 +1,73 @@
+package maven
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMirrorMatches(t *testing.T) {
+	central := centralRemote()
+	local := &Remote{Name: "local", URL: "http://localhost:8081/repo/"}
+	plain := &Remote{Name: "plain", URL: "http://repo.example.com/"}
+	tests := []struct {
+		mirrorOf string
+		remote   *Remote
+		want     bool
+	}{
+		{"*", central, true},
+		{"central", central, true},
+		{"other", central, false},
+		{"other, central", central, true},
+		{"*,!central", central, false},
+		{"*,!central", local, true},
+		{"external:*", central, true},
+		{"external:*", local, false},
+		{"external:http:*", central, false},
+		{"external:http:*", plain, true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, mirrorMatches(tt.mirrorOf, tt.remote), "%s matching %s", tt.mirrorOf, tt.remote.Name)
+	}
+}
+
+func TestApplyMirrors(t *testing.T) {
+	no := false
+	remotes, err := newRemotes([]RepositoryConfig{
+		{Name: "releases", URL: "https://releases.example.com/", Snapshots: &no},
+		{Name: "snapshots", URL: "https://snapshots.example.com/", Releases: &no},
+		{Name: "internal", URL: "https://internal.example.com/"},
+	})
+	require.NoError(t, err)
+
+	mirrored, err := applyMirrors(remotes, []MirrorConfig{
+		{Name: "all", URL: "https://mirror.example.com/", MirrorOf: "*,!internal"},
+		{Name: "central-mirror", URL: "https://central-mirror.example.com/", MirrorOf: "central"},
+	})
+	require.NoError(t, err)
+	require.Len(t, mirrored, 3)
+
+	// one mirror takes the place of every repository it matches
+	assert.Equal(t, "all", mirrored[0].Name)
+	assert.Equal(t, "https://mirror.example.com/", mirrored[0].URL)
+	assert.True(t, mirrored[0].Releases)
+	assert.True(t, mirrored[0].Snapshots)
+	assert.Equal(t, remotes[:2], mirrored[0].mirrorOf)
+	assert.Same(t, remotes[2], mirrored[1])
+	// a mirror of the repository by name wins over a pattern
+	assert.Equal(t, "central-mirror", mirrored[2].Name)
+	assert.Equal(t, []*Remote{remotes[3]}, mirrored[2].mirrorOf)
+}
+
+func TestFindRemote_IgnoresMirrors(t *testing.T) {
+	repo := NewLocalRepository(t.TempDir())
+	repo.settings.Repositories = []RepositoryConfig{{Name: "releases", URL: "https://releases.example.com/"}}
+	repo.settings.Mirrors = []MirrorConfig{{Name: "all", URL: "https://mirror.example.com/", MirrorOf: "*"}}
+	require.NoError(t, repo.SetRepositories(nil))
+	require.Len(t, repo.Remotes(), 1)
+
+	remote, err := repo.FindRemote("releases")
+	require.NoError(t, err)
+	assert.Equal(t, "https://releases.example.com/", remote.URL)
+}
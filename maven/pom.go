@@ -20,9 +20,39 @@ type POM struct {
 	Name                 string                `xml:"name,omitempty"`
 	Description          string                `xml:"description,omitempty"`
 	URL                  string                `xml:"url,omitempty"`
+	Licenses             []License             `xml:"licenses>license"`
+	Developers           []Developer           `xml:"developers>developer"`
+	SCM                  *SCM                  `xml:"scm,omitempty"`
 	Properties           *Properties           `xml:"properties,omitempty"`
 	Dependencies         []Dependency          `xml:"dependencies>dependency"`
 	DependencyManagement *DependencyManagement `xml:"dependencyManagement"` // parent poms can list default versions here
+	Profiles             []Profile             `xml:"profiles>profile"`
+	variantFile          string                // main file of the variant selected from gradle module metadata, if any
+}
+
+// License is a license the artifact is distributed under. It is also used as is in jb-module.json.
+type License struct {
+	Name         string `xml:"name" json:"name"`
+	URL          string `xml:"url,omitempty" json:"url,omitempty"`
+	Distribution string `xml:"distribution,omitempty" json:"distribution,omitempty"` // repo or manual
+}
+
+// Developer is a person working on the artifact. It is also used as is in jb-module.json.
+type Developer struct {
+	ID              string `xml:"id,omitempty" json:"id,omitempty"`
+	Name            string `xml:"name,omitempty" json:"name,omitempty"`
+	Email           string `xml:"email,omitempty" json:"email,omitempty"`
+	URL             string `xml:"url,omitempty" json:"url,omitempty"`
+	Organization    string `xml:"organization,omitempty" json:"organization,omitempty"`
+	OrganizationURL string `xml:"organizationUrl,omitempty" json:"organization_url,omitempty"`
+}
+
+// SCM locates the source code of the artifact. It is also used as is in jb-module.json.
+type SCM struct {
+	Connection          string `xml:"connection,omitempty" json:"connection,omitempty"`                    // read access, such as scm:git:https://...
+	DeveloperConnection string `xml:"developerConnection,omitempty" json:"developer_connection,omitempty"` // write access
+	URL                 string `xml:"url,omitempty" json:"url,omitempty"`                                  // browsable url
+	Tag                 string `xml:"tag,omitempty" json:"tag,omitempty"`
 }
 
 type DependencyManagement struct {
@@ -30,12 +60,26 @@ type DependencyManagement struct {
 }
 
 type Dependency struct {
+	GroupID    string      `xml:"groupId"`
+	ArtifactID string      `xml:"artifactId"`
+	Version    string      `xml:"version,omitempty"`
+	Type       string      `xml:"type,omitempty"`
+	Classifier string      `xml:"classifier,omitempty"`
+	Scope      string      `xml:"scope,omitempty"`
+	Optional   string      `xml:"optional,omitempty"`
+	Exclusions []Exclusion `xml:"exclusions>exclusion"`
+}
+
+// Exclusion removes a transitive dependency from the graph below the dependency that declares it.
+// Either field can be "*" to match any group or artifact.
+type Exclusion struct {
 	GroupID    string `xml:"groupId"`
 	ArtifactID string `xml:"artifactId"`
-	Version    string `xml:"version,omitempty"`
-	Type       string `xml:"type,omitempty"`
-	Scope      string `xml:"scope,omitempty"`
-	Optional   string `xml:"optional,omitempty"`
+}
+
+// Matches returns true if the exclusion applies to the given group and artifact.
+func (e Exclusion) Matches(groupID, artifactID string) bool {
+	return (e.GroupID == "*" || e.GroupID == groupID) && (e.ArtifactID == "*" || e.ArtifactID == artifactID)
 }
 
 type Properties struct {
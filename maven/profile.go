@@ -0,0 +1,220 @@
+package maven
+
+import (
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// Profile adds properties and dependencies to a POM when its activation conditions hold, for
+// example only on a particular JDK or operating system.
+type Profile struct {
+	ID                   string                `xml:"id"`
+	Activation           *ProfileActivation    `xml:"activation,omitempty"`
+	Properties           *Properties           `xml:"properties,omitempty"`
+	Dependencies         []Dependency          `xml:"dependencies>dependency"`
+	DependencyManagement *DependencyManagement `xml:"dependencyManagement,omitempty"`
+}
+
+// ProfileActivation holds the conditions that activate a profile. All the conditions given must
+// hold.
+type ProfileActivation struct {
+	ActiveByDefault bool                `xml:"activeByDefault"`
+	JDK             string              `xml:"jdk,omitempty"` // version prefix such as "1.8" or a range such as "[11,)", "!" negates
+	OS              *ActivationOS       `xml:"os,omitempty"`
+	Property        *ActivationProperty `xml:"property,omitempty"`
+	File            *ActivationFile     `xml:"file,omitempty"`
+}
+
+// ActivationOS matches the operating system. Each field can be negated with a "!" prefix.
+type ActivationOS struct {
+	Name    string `xml:"name,omitempty"`
+	Family  string `xml:"family,omitempty"`
+	Arch    string `xml:"arch,omitempty"`
+	Version string `xml:"version,omitempty"`
+}
+
+// ActivationProperty matches a property that is set ("name"), not set ("!name"), has a value
+// ("name" and "value") or doesn't have a value ("name" and "!value").
+type ActivationProperty struct {
+	Name  string `xml:"name"`
+	Value string `xml:"value,omitempty"`
+}
+
+// ActivationFile matches files that exist or are missing in the project being built. There is no
+// such project for a POM in a repository, so profiles activated by files are never active.
+type ActivationFile struct {
+	Exists  string `xml:"exists,omitempty"`
+	Missing string `xml:"missing,omitempty"`
+}
+
+// ActivationContext is the environment that POM profiles are activated by.
+type ActivationContext struct {
+	JDK        string            // java.version of the JDK used to build, profiles that need a JDK are inactive if empty
+	OSName     string            // os.name in lower case
+	OSFamilies []string          // families the operating system belongs to, such as "unix" and "mac"
+	OSArch     string            // os.arch
+	OSVersion  string            // os.version
+	Properties map[string]string // system properties, and environment variables as env.NAME
+}
+
+// DefaultActivationContext describes the operating system jb is running on, without a JDK.
+func DefaultActivationContext() *ActivationContext {
+	ctx := &ActivationContext{
+		OSName:     runtime.GOOS,
+		OSFamilies: []string{"unix"},
+		OSArch:     runtime.GOARCH,
+		Properties: make(map[string]string),
+	}
+	// use the values a JVM reports
+	switch runtime.GOOS {
+	case "darwin":
+		ctx.OSName = "mac os x"
+		ctx.OSFamilies = []string{"mac", "unix"}
+	case "windows":
+		ctx.OSFamilies = []string{"windows", "dos"}
+	}
+	switch {
+	case runtime.GOARCH == "amd64" && runtime.GOOS == "darwin":
+		ctx.OSArch = "x86_64"
+	case runtime.GOARCH == "arm64":
+		ctx.OSArch = "aarch64"
+	case runtime.GOARCH == "386":
+		ctx.OSArch = "x86"
+	}
+	for _, env := range os.Environ() {
+		if name, value, found := strings.Cut(env, "="); found {
+			ctx.Properties["env."+name] = value
+		}
+	}
+	ctx.Properties["os.name"] = ctx.OSName
+	ctx.Properties["os.arch"] = ctx.OSArch
+	return ctx
+}
+
+// SetJDK sets the java.version of the JDK used to build.
+func (ctx *ActivationContext) SetJDK(javaVersion string) {
+	ctx.JDK = javaVersion
+	ctx.Properties["java.version"] = javaVersion
+}
+
+// ActiveProfiles returns the profiles whose activation conditions hold. Like maven, profiles that
+// are active by default are only active if no other profile is.
+func (ctx *ActivationContext) ActiveProfiles(profiles []Profile) []Profile {
+	active := make([]Profile, 0)
+	for _, profile := range profiles {
+		if profile.Activation != nil && ctx.isActive(profile.Activation) {
+			active = append(active, profile)
+		}
+	}
+	if len(active) > 0 {
+		return active
+	}
+	for _, profile := range profiles {
+		if profile.Activation != nil && profile.Activation.ActiveByDefault {
+			active = append(active, profile)
+		}
+	}
+	return active
+}
+
+func (ctx *ActivationContext) isActive(activation *ProfileActivation) bool {
+	if activation.File != nil || activation.JDK == "" && activation.OS == nil && activation.Property == nil {
+		return false
+	}
+	if activation.JDK != "" && !ctx.matchesJDK(strings.TrimSpace(activation.JDK)) {
+		return false
+	}
+	if activation.OS != nil && !ctx.matchesOS(activation.OS) {
+		return false
+	}
+	if activation.Property != nil && !ctx.matchesProperty(activation.Property) {
+		return false
+	}
+	return true
+}
+
+func (ctx *ActivationContext) matchesJDK(spec string) bool {
+	if ctx.JDK == "" {
+		return false
+	}
+	if IsVersionRange(spec) {
+		r, err := ParseVersionRange(spec)
+		if err != nil {
+			return false
+		}
+		// 1.8.0_292 compares as 1.8.0.292
+		return r.Contains(ParseVersion(strings.ReplaceAll(ctx.JDK, "_", ".")))
+	}
+	prefix, negated := strings.CutPrefix(spec, "!")
+	return strings.HasPrefix(ctx.JDK, prefix) != negated
+}
+
+func (ctx *ActivationContext) matchesOS(condition *ActivationOS) bool {
+	equal := func(expected, actual string) bool {
+		return strings.EqualFold(expected, actual)
+	}
+	inFamily := func(expected, _ string) bool {
+		return slices.Contains(ctx.OSFamilies, strings.ToLower(expected))
+	}
+	return matchesNegatable(condition.Name, ctx.OSName, equal) &&
+		matchesNegatable(condition.Family, "", inFamily) &&
+		matchesNegatable(condition.Arch, ctx.OSArch, equal) &&
+		matchesNegatable(condition.Version, ctx.OSVersion, equal)
+}
+
+// matchesNegatable returns true if the condition is empty, or if it matches (or with a "!"
+// prefix doesn't match) the actual value.
+func matchesNegatable(condition, actual string, matches func(expected, actual string) bool) bool {
+	condition = strings.TrimSpace(condition)
+	if condition == "" {
+		return true
+	}
+	expected, negated := strings.CutPrefix(condition, "!")
+	return matches(expected, actual) != negated
+}
+
+func (ctx *ActivationContext) matchesProperty(property *ActivationProperty) bool {
+	name, absent := strings.CutPrefix(strings.TrimSpace(property.Name), "!")
+	value := ctx.Properties[name]
+	if absent {
+		return value == ""
+	}
+	if property.Value == "" {
+		return value != ""
+	}
+	expected, negated := strings.CutPrefix(strings.TrimSpace(property.Value), "!")
+	return (value == expected) != negated
+}
+
+// applyProfiles merges the properties, dependencies and dependency management of the active
+// profiles into the POM, replacing what the POM itself declares for the same artifacts.
+func (p *POM) applyProfiles(ctx *ActivationContext) {
+	for _, profile := range ctx.ActiveProfiles(p.Profiles) {
+		if profile.Properties != nil {
+			for _, prop := range profile.Properties.Properties {
+				p.SetProperty(prop.XMLName.Local, prop.Value)
+			}
+		}
+		p.Dependencies = mergeProfileDeps(p.Dependencies, profile.Dependencies)
+		if profile.DependencyManagement != nil {
+			p.DependencyManagement.Dependencies = mergeProfileDeps(p.DependencyManagement.Dependencies, profile.DependencyManagement.Dependencies)
+		}
+	}
+}
+
+func mergeProfileDeps(deps, profileDeps []Dependency) []Dependency {
+	for _, profileDep := range profileDeps {
+		i := slices.IndexFunc(deps, func(dep Dependency) bool {
+			return dep.GroupID == profileDep.GroupID && dep.ArtifactID == profileDep.ArtifactID &&
+				dep.Type == profileDep.Type && dep.Classifier == profileDep.Classifier
+		})
+		if i >= 0 {
+			deps[i] = profileDep
+		} else {
+			deps = append(deps, profileDep)
+		}
+	}
+	return deps
+}
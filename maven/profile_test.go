@@ -0,0 +1,143 @@
+package maven
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func linuxActivationContext(jdk string) *ActivationContext {
+	ctx := &ActivationContext{
+		OSName:     "linux",
+		OSFamilies: []string{"unix"},
+		OSArch:     "amd64",
+		Properties: map[string]string{"env.CI": "true"},
+	}
+	if jdk != "" {
+		ctx.SetJDK(jdk)
+	}
+	return ctx
+}
+
+func TestActivationContext_ActiveProfiles(t *testing.T) {
+	tests := []struct {
+		name       string
+		jdk        string
+		activation ProfileActivation
+		active     bool
+	}{
+		{"jdk prefix", "1.8.0", ProfileActivation{JDK: "1.8"}, true},
+		{"jdk other prefix", "17.0.2", ProfileActivation{JDK: "1.8"}, false},
+		{"jdk negated", "17.0.2", ProfileActivation{JDK: "!1.8"}, true},
+		{"jdk range", "17.0.2", ProfileActivation{JDK: "[9,)"}, true},
+		{"jdk range below", "1.8.0", ProfileActivation{JDK: "[9,)"}, false},
+		{"jdk range upper bound", "11.0.1", ProfileActivation{JDK: "(,11)"}, false},
+		{"jdk unknown", "", ProfileActivation{JDK: "!1.8"}, false},
+		{"os family", "", ProfileActivation{OS: &ActivationOS{Family: "unix"}}, true},
+		{"os family negated", "", ProfileActivation{OS: &ActivationOS{Family: "!windows"}}, true},
+		{"os name and arch", "", ProfileActivation{OS: &ActivationOS{Name: "Linux", Arch: "amd64"}}, true},
+		{"os other arch", "", ProfileActivation{OS: &ActivationOS{Name: "linux", Arch: "aarch64"}}, false},
+		{"property set", "", ProfileActivation{Property: &ActivationProperty{Name: "env.CI"}}, true},
+		{"property not set", "", ProfileActivation{Property: &ActivationProperty{Name: "!env.CI"}}, false},
+		{"property value", "", ProfileActivation{Property: &ActivationProperty{Name: "env.CI", Value: "true"}}, true},
+		{"property other value", "", ProfileActivation{Property: &ActivationProperty{Name: "env.CI", Value: "!true"}}, false},
+		{"all conditions must hold", "17.0.2", ProfileActivation{JDK: "17", OS: &ActivationOS{Family: "windows"}}, false},
+		{"file", "", ProfileActivation{File: &ActivationFile{Missing: "src/main/java"}}, false},
+		{"no conditions", "", ProfileActivation{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			activation := tt.activation
+			active := linuxActivationContext(tt.jdk).ActiveProfiles([]Profile{{ID: "p", Activation: &activation}})
+			assert.Equal(t, tt.active, len(active) == 1)
+		})
+	}
+}
+
+func TestActivationContext_ActiveByDefault(t *testing.T) {
+	ctx := linuxActivationContext("17.0.2")
+	byDefault := Profile{ID: "default", Activation: &ProfileActivation{ActiveByDefault: true}}
+	java8 := Profile{ID: "java8", Activation: &ProfileActivation{JDK: "1.8"}}
+	java17 := Profile{ID: "java17", Activation: &ProfileActivation{JDK: "17"}}
+
+	assert.Equal(t, []Profile{byDefault}, ctx.ActiveProfiles([]Profile{byDefault, java8}))
+	// another active profile turns off the default
+	assert.Equal(t, []Profile{java17}, ctx.ActiveProfiles([]Profile{byDefault, java17}))
+}
+
+func TestGetPOM_Profiles(t *testing.T) {
+	tempDir := t.TempDir()
+	repo := NewLocalRepository(tempDir)
+	repo.SetActivationContext(linuxActivationContext("11.0.2"))
+
+	pomDir := filepath.Join(tempDir, "com", "example", "lib", "1.0")
+	require.NoError(t, os.MkdirAll(pomDir, 0755))
+	pomContent := `<project>
+    <groupId>com.example</groupId>
+    <artifactId>lib</artifactId>
+    <version>1.0</version>
+    <properties>
+        <asm.version>9.5</asm.version>
+    </properties>
+    <dependencies>
+        <dependency>
+            <groupId>org.ow2.asm</groupId>
+            <artifactId>asm</artifactId>
+            <version>${asm.version}</version>
+        </dependency>
+    </dependencies>
+    <profiles>
+        <profile>
+            <id>java9+</id>
+            <activation><jdk>[9,)</jdk></activation>
+            <properties>
+                <asm.version>9.7</asm.version>
+            </properties>
+            <dependencies>
+                <dependency>
+                    <groupId>javax.annotation</groupId>
+                    <artifactId>javax.annotation-api</artifactId>
+                </dependency>
+            </dependencies>
+            <dependencyManagement>
+                <dependencies>
+                    <dependency>
+                        <groupId>javax.annotation</groupId>
+                        <artifactId>javax.annotation-api</artifactId>
+                        <version>1.3.2</version>
+                    </dependency>
+                </dependencies>
+            </dependencyManagement>
+        </profile>
+        <profile>
+            <id>windows</id>
+            <activation><os><family>windows</family></os></activation>
+            <dependencies>
+                <dependency>
+                    <groupId>net.java.dev.jna</groupId>
+                    <artifactId>jna-platform</artifactId>
+                    <version>5.14.0</version>
+                </dependency>
+            </dependencies>
+        </profile>
+    </profiles>
+</project>`
+	require.NoError(t, os.WriteFile(filepath.Join(pomDir, "lib-1.0.pom"), []byte(pomContent), 0644))
+
+	pom, err := repo.GetPOM("com.example", "lib", "1.0")
+	require.NoError(t, err)
+	require.Len(t, pom.Dependencies, 2)
+	assert.Equal(t, "9.7", pom.Dependencies[0].Version)
+	assert.Equal(t, "javax.annotation-api", pom.Dependencies[1].ArtifactID)
+	assert.Equal(t, "1.3.2", pom.Dependencies[1].Version)
+
+	// changing the context loads the POM again
+	repo.SetActivationContext(linuxActivationContext("1.8.0"))
+	pom, err = repo.GetPOM("com.example", "lib", "1.0")
+	require.NoError(t, err)
+	require.Len(t, pom.Dependencies, 1)
+	assert.Equal(t, "9.5", pom.Dependencies[0].Version)
+}
@@ -0,0 +1,257 @@
+package maven
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Remote is a maven repository that artifacts are downloaded from.
+type Remote struct {
+	Name         string
+	URL          string
+	Releases     bool         // serves release versions
+	Snapshots    bool         // serves -SNAPSHOT versions
+	UpdatePolicy UpdatePolicy // how often cached metadata is checked again
+	username     string
+	password     string
+	token        string
+	client       *httpClient // nil for the default timeouts and retries
+	mirrorOf     []*Remote   // the remotes a mirror stands in for
+}
+
+func centralRemote() *Remote {
+	return &Remote{Name: "central", URL: MAVEN_CENTRAL_URL, Releases: true, UpdatePolicy: UpdateDaily}
+}
+
+// NewRemote creates a remote from its configuration, reading any credentials from the
+// environment.
+func NewRemote(config RepositoryConfig) (*Remote, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("repository %s has no name", config.URL)
+	}
+	u, err := url.Parse(config.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("repository %s has an invalid url '%s'", config.Name, config.URL)
+	}
+	remote := &Remote{
+		Name:         config.Name,
+		URL:          config.URL,
+		Releases:     config.Releases == nil || *config.Releases,
+		Snapshots:    config.Snapshots == nil || *config.Snapshots,
+		UpdatePolicy: UpdateDaily,
+	}
+	if config.UpdatePolicy != "" {
+		policy, err := ParseUpdatePolicy(config.UpdatePolicy)
+		if err != nil {
+			return nil, fmt.Errorf("repository %s: %w", config.Name, err)
+		}
+		remote.UpdatePolicy = policy
+	}
+	if config.TokenEnv != "" && (config.UsernameEnv != "" || config.PasswordEnv != "") {
+		return nil, fmt.Errorf("repository %s can use basic auth or a bearer token, not both", config.Name)
+	}
+	remote.username = cmp.Or(credentialFromEnv(config.Name, config.UsernameEnv), config.username)
+	remote.password = cmp.Or(credentialFromEnv(config.Name, config.PasswordEnv), config.password)
+	remote.token = credentialFromEnv(config.Name, config.TokenEnv)
+	return remote, nil
+}
+
+func credentialFromEnv(repository, name string) string {
+	if name == "" {
+		return ""
+	}
+	value := os.Getenv(name)
+	if value == "" {
+		fmt.Fprintf(os.Stderr, "warning: environment variable %s for repository %s is not set\n", name, repository)
+	}
+	return value
+}
+
+// newRemotes creates the remotes for the given configurations in order, followed by maven central
+// unless one of them is named "central" to replace it.
+func newRemotes(configs []RepositoryConfig) ([]*Remote, error) {
+	remotes := make([]*Remote, 0, len(configs)+1)
+	names := make(map[string]bool)
+	for _, config := range configs {
+		if names[config.Name] {
+			continue // first declaration of a name wins
+		}
+		remote, err := NewRemote(config)
+		if err != nil {
+			return nil, err
+		}
+		names[remote.Name] = true
+		remotes = append(remotes, remote)
+	}
+	if !names["central"] {
+		remotes = append(remotes, centralRemote())
+	}
+	return remotes, nil
+}
+
+// Serves returns true if the remote serves the given version.
+func (r *Remote) Serves(version string) bool {
+	if isSnapshot(version) {
+		return r.Snapshots
+	}
+	return r.Releases
+}
+
+// UpdatePolicy controls how often metadata cached from a remote, such as the latest build of a
+// snapshot, is checked against the remote again.
+type UpdatePolicy string
+
+const (
+	UpdateAlways UpdatePolicy = "always"
+	UpdateDaily  UpdatePolicy = "daily"
+	UpdateNever  UpdatePolicy = "never" // only fetched if not cached at all
+)
+
+// ParseUpdatePolicy parses "always", "daily", "never", or "interval:N" to check every N minutes.
+func ParseUpdatePolicy(s string) (UpdatePolicy, error) {
+	policy := UpdatePolicy(strings.ToLower(s))
+	switch policy {
+	case UpdateAlways, UpdateDaily, UpdateNever:
+		return policy, nil
+	}
+	if minutes, found := strings.CutPrefix(string(policy), "interval:"); found {
+		if n, err := strconv.Atoi(minutes); err == nil && n > 0 {
+			return policy, nil
+		}
+	}
+	return "", fmt.Errorf("invalid update policy '%s', must be one of always, daily, never, or interval:N", s)
+}
+
+// isDue returns true if the file at path is missing or was last checked longer ago than the
+// policy allows. The modification time of the cached file records when it was last checked.
+func (p UpdatePolicy) isDue(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return true
+	}
+	return p.isDueSince(info.ModTime())
+}
+
+// isDueSince returns true if something last checked at the given time should be checked again.
+func (p UpdatePolicy) isDueSince(checked time.Time) bool {
+	age := time.Since(checked)
+	switch p {
+	case UpdateAlways:
+		return true
+	case UpdateNever:
+		return false
+	case UpdateDaily, "":
+		return age > 24*time.Hour
+	}
+	minutes, _ := strconv.Atoi(strings.TrimPrefix(string(p), "interval:"))
+	return age > time.Duration(minutes)*time.Minute
+}
+
+func isSnapshot(version string) bool {
+	return strings.HasSuffix(strings.ToUpper(version), "-SNAPSHOT")
+}
+
+// fileURL returns the URL of a file in the maven repository layout.
+func (r *Remote) fileURL(groupID, artifactID, version, file string) (string, error) {
+	groupIDWithSlashes := strings.ReplaceAll(groupID, ".", "/")
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse maven Coordinates: %w", err)
+	}
+	u.Path = path.Join(u.Path, groupIDWithSlashes, artifactID, version, file)
+	return u.String(), nil
+}
+
+// newRequest creates a request to the remote, authenticated if credentials are configured.
+func (r *Remote) newRequest(method, fileURL string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, fileURL, body)
+	if err != nil {
+		return nil, err
+	}
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	} else if r.username != "" || r.password != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	return req, nil
+}
+
+func (r *Remote) httpClient() *httpClient {
+	if r.client != nil {
+		return r.client
+	}
+	return defaultHTTPClient
+}
+
+// fetch downloads a file into out, retrying failures that are likely to be temporary. A download
+// that fails part way through is only retried if out can start over: a bytes.Buffer or a
+// restarter.
+func (r *Remote) fetch(fileURL string, out io.Writer) error {
+	client := r.httpClient()
+	return client.retry(func() error {
+		req, err := r.newRequest(http.MethodGet, fileURL, nil)
+		if err != nil {
+			return fmt.Errorf("error downloading %s: %v", fileURL, err)
+		}
+		resp, err := client.client.Do(req)
+		if err != nil {
+			return transportError(fmt.Errorf("error downloading %s: %w", fileURL, err))
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return statusResult(resp, &statusError{fileURL: fileURL, status: resp.Status, code: resp.StatusCode})
+		}
+		_, err = io.Copy(out, resp.Body)
+		if err != nil {
+			err = fmt.Errorf("error saving %s: %w", fileURL, err)
+			if restart(out) {
+				return &retryableError{err: err}
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+// restarter is implemented by writers that can discard what was written to them, so that a
+// download into them can be retried from the start.
+type restarter interface {
+	restart() error
+}
+
+func restart(out io.Writer) bool {
+	switch out := out.(type) {
+	case *bytes.Buffer:
+		out.Reset()
+		return true
+	case restarter:
+		return out.restart() == nil
+	}
+	return false
+}
+
+// statusError is returned when a remote answers a download with anything but 200 OK.
+type statusError struct {
+	fileURL string
+	status  string
+	code    int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("failed to download %s: %s", e.fileURL, e.status)
+}
+
+func isNotFound(err error) bool {
+	var statusErr *statusError
+	return errors.As(err, &statusErr) && statusErr.code == http.StatusNotFound
+}
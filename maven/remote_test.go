@@ -0,0 +1,126 @@
+package maven
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRemote(t *testing.T) {
+	no := false
+	tests := []struct {
+		name    string
+		config  RepositoryConfig
+		wantErr string
+	}{
+		{name: "valid", config: RepositoryConfig{Name: "nexus", URL: "https://nexus.example.com/maven/", Snapshots: &no}},
+		{name: "no name", config: RepositoryConfig{URL: "https://nexus.example.com/maven/"}, wantErr: "repository https://nexus.example.com/maven/ has no name"},
+		{name: "bad url", config: RepositoryConfig{Name: "nexus", URL: "nexus.example.com"}, wantErr: "repository nexus has an invalid url 'nexus.example.com'"},
+		{name: "both auth", config: RepositoryConfig{Name: "nexus", URL: "https://nexus.example.com/", UsernameEnv: "U", TokenEnv: "T"}, wantErr: "repository nexus can use basic auth or a bearer token, not both"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote, err := NewRemote(tt.config)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, remote.Releases)
+			assert.False(t, remote.Snapshots)
+			assert.True(t, remote.Serves("1.0"))
+			assert.False(t, remote.Serves("1.0-SNAPSHOT"))
+		})
+	}
+}
+
+func TestNewRemotes(t *testing.T) {
+	remotes, err := newRemotes([]RepositoryConfig{
+		{Name: "a", URL: "https://a.example.com/"},
+		{Name: "b", URL: "https://b.example.com/"},
+		{Name: "a", URL: "https://other.example.com/"},
+	})
+	require.NoError(t, err)
+	require.Len(t, remotes, 3)
+	assert.Equal(t, "https://a.example.com/", remotes[0].URL)
+	assert.Equal(t, "b", remotes[1].Name)
+	assert.Equal(t, MAVEN_CENTRAL_URL, remotes[2].URL)
+
+	remotes, err = newRemotes([]RepositoryConfig{{Name: "central", URL: "https://mirror.example.com/"}})
+	require.NoError(t, err)
+	require.Len(t, remotes, 1)
+	assert.Equal(t, "https://mirror.example.com/", remotes[0].URL)
+}
+
+func TestRemote_Credentials(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte("content"))
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("TEST_REPO_USER", "alice")
+	t.Setenv("TEST_REPO_PASSWORD", "secret")
+	t.Setenv("TEST_REPO_TOKEN", "abc123")
+
+	basic, err := NewRemote(RepositoryConfig{Name: "basic", URL: server.URL, UsernameEnv: "TEST_REPO_USER", PasswordEnv: "TEST_REPO_PASSWORD"})
+	require.NoError(t, err)
+	require.NoError(t, fetchFromRemote(basic, "com.example", "lib", "1.0", "lib-1.0.pom", io.Discard))
+	assert.Equal(t, "Basic YWxpY2U6c2VjcmV0", authorization)
+
+	bearer, err := NewRemote(RepositoryConfig{Name: "bearer", URL: server.URL, TokenEnv: "TEST_REPO_TOKEN"})
+	require.NoError(t, err)
+	require.NoError(t, fetchFromRemote(bearer, "com.example", "lib", "1.0", "lib-1.0.pom", io.Discard))
+	assert.Equal(t, "Bearer abc123", authorization)
+
+	anonymous, err := NewRemote(RepositoryConfig{Name: "anonymous", URL: server.URL})
+	require.NoError(t, err)
+	require.NoError(t, fetchFromRemote(anonymous, "com.example", "lib", "1.0", "lib-1.0.pom", io.Discard))
+	assert.Empty(t, authorization)
+}
+
+func TestGetFile_SkipsRemotesNotServingVersion(t *testing.T) {
+	releases := newTestRemote(t, map[string]string{
+		"com/example/lib/1.0-SNAPSHOT/lib-1.0-SNAPSHOT.pom": "from releases",
+	})
+	snapshots := newTestRemote(t, map[string]string{
+		"com/example/lib/1.0-SNAPSHOT/lib-1.0-SNAPSHOT.pom": "from snapshots",
+	})
+	no := false
+	repo := NewLocalRepository(t.TempDir())
+	repo.SetChecksumPolicy(ChecksumIgnore)
+	require.NoError(t, repo.SetRepositories([]RepositoryConfig{
+		{Name: "releases", URL: releases.URL, Snapshots: &no},
+		{Name: "snapshots", URL: snapshots.URL, Releases: &no},
+	}))
+
+	path, err := repo.GetPOMPath("com.example", "lib", "1.0-SNAPSHOT")
+	require.NoError(t, err)
+	assert.FileExists(t, path)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "from snapshots", string(content))
+}
+
+func TestGetFile_ReportsWhyFetchFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	}))
+	t.Cleanup(server.Close)
+	repo := NewLocalRepository(t.TempDir(), server.URL)
+	repo.SetChecksumPolicy(ChecksumIgnore)
+	_, err := repo.GetPOMPath("com.example", "lib", "1.0")
+	assert.ErrorContains(t, err, "401 Unauthorized")
+
+	// a snapshot needs a remote that serves snapshots
+	no := false
+	require.NoError(t, repo.SetRepositories([]RepositoryConfig{{Name: "releases", URL: server.URL, Snapshots: &no}}))
+	_, err = repo.GetPOMPath("com.example", "lib", "1.0-SNAPSHOT")
+	assert.ErrorContains(t, err, "no remote repository serves version 1.0-SNAPSHOT")
+}
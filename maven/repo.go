@@ -1,37 +1,252 @@
 package maven
 
 import (
+	"archive/zip"
+	"bytes"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"golang.org/x/net/html/charset"
 	"io"
-	"net/http"
-	"net/url"
 	"os"
-	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 const MAVEN_CENTRAL_URL = "https://repo.maven.apache.org/maven2/"
 
+// zipHeader is the signature jars and other zip files start with.
+var zipHeader = []byte("PK\x03\x04")
+
+// OfflineEnv is the environment variable that puts repositories opened with
+// OpenLocalRepository in offline mode.
+const OfflineEnv = "JB_OFFLINE"
+
 type LocalRepository struct {
-	baseDir string
-	remotes []string
-	poms    map[string]*POM
+	baseDir          string
+	settings         *Settings
+	remotes          []*Remote
+	checksumPolicy   ChecksumPolicy
+	offline          bool
+	refreshSnapshots bool
+	activation       *ActivationContext
+	javaVersion      int         // Java version that Gradle variants are selected for, 0 for the JDK's
+	client           *httpClient // nil for the default timeouts and retries
+	mavenLocalDir    string      // maven's own local repository, empty for none
+	readMavenLocal   bool        // look for missing files in the maven local repository before downloading
+
+	// the repository is safe for concurrent use, concurrent requests for the same POM or file
+	// share a single load or download
+	mu            sync.Mutex
+	poms          map[string]*POM
+	pomLoads      callGroup[*POM]
+	metadata      map[string]*Metadata
+	metadataLoads callGroup[*Metadata]
+	snapshots     map[string]*snapshotMetadata
+	snapshotLoads callGroup[*snapshotMetadata]
+	downloads     callGroup[string]
+	unreachable   map[*Remote]bool // remotes that couldn't be reached, skipped for the rest of the build
+}
+
+// NotCachedError is returned in offline mode for a file that is not in the local repository.
+type NotCachedError struct {
+	GroupID    string
+	ArtifactID string
+	Version    string
+	File       string
+}
+
+// Coordinates returns the coordinates of the missing file, without a version for files that
+// belong to every version of an artifact.
+func (e *NotCachedError) Coordinates() string {
+	if e.Version == "" {
+		return e.GroupID + ":" + e.ArtifactID
+	}
+	return GAV(e.GroupID, e.ArtifactID, e.Version)
+}
+
+func (e *NotCachedError) Error() string {
+	return fmt.Sprintf("%s (%s) is not in the local repository", e.Coordinates(), e.File)
 }
 
 var mavenVarPattern = regexp.MustCompile(`\$\{([a-zA-Z0-9._-]+)\}`)
 
+// OpenLocalRepository opens the user's repository in ~/.jb/repository, configured by the user's
+// settings in ~/.jb/settings.json. Without any configured repositories it is backed by maven
+// central. Files already in maven's local repository, ~/.m2/repository, are used from there, and
+// the servers, mirrors and proxy in ~/.m2/settings.xml apply too, unless the settings turn that
+// off. The checksum policy can be overridden with the JB_CHECKSUM_POLICY environment variable,
+// setting JB_OFFLINE to true resolves strictly from the local repository, and setting
+// JB_REFRESH_SNAPSHOTS to true checks for new snapshot builds regardless of the update policy.
 func OpenLocalRepository() *LocalRepository {
+	repo := NewLocalRepository("~/.jb/repository")
+	settings, err := LoadSettings(UserSettingsPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: ignoring user settings: %s\n", err)
+		settings = &Settings{}
+	}
+	if settings.MavenSettings == nil || *settings.MavenSettings {
+		mavenSettings, err := ImportMavenSettings(expandHome(MavenSettingsPath))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: ignoring maven settings: %s\n", err)
+		} else {
+			settings.addMavenSettings(mavenSettings)
+		}
+	}
+	repo.settings = settings
+	if err := repo.SetHTTPSettings(settings.HTTP); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: ignoring http settings: %s\n", err)
+	}
+	if err := repo.SetRepositories(nil); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: ignoring repositories in user settings: %s\n", err)
+		repo.remotes = []*Remote{centralRemote()}
+	}
+	if settings.ChecksumPolicy != "" {
+		repo.checksumPolicy, _ = ParseChecksumPolicy(settings.ChecksumPolicy)
+	}
+	if value := os.Getenv("JB_CHECKSUM_POLICY"); value != "" {
+		policy, err := ParseChecksumPolicy(value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: ignoring JB_CHECKSUM_POLICY: %s\n", err)
+		} else {
+			repo.checksumPolicy = policy
+		}
+	}
+	repo.SetMavenLocal(MavenLocalDir, settings.MavenLocal == nil || *settings.MavenLocal)
+	repo.offline = boolFromEnv(OfflineEnv)
+	repo.refreshSnapshots = boolFromEnv(RefreshSnapshotsEnv)
+	return repo
+}
+
+func boolFromEnv(name string) bool {
+	value := os.Getenv(name)
+	if value == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: ignoring %s: invalid boolean '%s'\n", name, value)
+	}
+	return b
+}
+
+// NewLocalRepository creates a repository cached in baseDir that downloads missing artifacts
+// from the given remote URLs, in order.
+func NewLocalRepository(baseDir string, remoteURLs ...string) *LocalRepository {
+	remotes := make([]*Remote, len(remoteURLs))
+	for i, remoteURL := range remoteURLs {
+		remotes[i] = &Remote{Name: remoteURL, URL: remoteURL, Releases: true, Snapshots: true, UpdatePolicy: UpdateDaily}
+	}
 	return &LocalRepository{
-		baseDir: "~/.jb/repository",
-		remotes: []string{MAVEN_CENTRAL_URL},
-		poms:    make(map[string]*POM),
+		baseDir:        baseDir,
+		settings:       &Settings{},
+		remotes:        remotes,
+		poms:           make(map[string]*POM),
+		metadata:       make(map[string]*Metadata),
+		snapshots:      make(map[string]*snapshotMetadata),
+		unreachable:    make(map[*Remote]bool),
+		checksumPolicy: ChecksumFail,
+		activation:     DefaultActivationContext(),
+	}
+}
+
+// SetRepositories configures the remotes to download from: the given repositories (usually
+// from the project file) followed by those in the user's settings and then maven central,
+// unless a repository named "central" replaces it. Mirrors in the user's settings take the
+// place of the repositories they match.
+func (c *LocalRepository) SetRepositories(configs []RepositoryConfig) error {
+	all := make([]RepositoryConfig, 0, len(configs)+len(c.settings.Repositories))
+	for _, config := range slices.Concat(configs, c.settings.Repositories) {
+		all = append(all, c.settings.withServerCredentials(config))
+	}
+	remotes, err := newRemotes(all)
+	if err != nil {
+		return err
+	}
+	for _, remote := range remotes {
+		remote.client = c.client
+	}
+	c.remotes, err = applyMirrors(remotes, c.settings.Mirrors)
+	return err
+}
+
+// SetHTTPSettings sets the timeouts and retries used to reach the remotes.
+func (c *LocalRepository) SetHTTPSettings(settings *HTTPSettings) error {
+	client, err := newHTTPClient(settings)
+	if err != nil {
+		return err
+	}
+	c.client = client
+	for _, remote := range c.remotes {
+		remote.client = client
+	}
+	return nil
+}
+
+// Remotes returns the remotes artifacts are downloaded from, in order.
+func (c *LocalRepository) Remotes() []*Remote {
+	return c.remotes
+}
+
+// SetOffline sets whether missing files may be downloaded. In offline mode they are reported
+// with a NotCachedError instead.
+func (c *LocalRepository) SetOffline(offline bool) {
+	c.offline = offline
+}
+
+// Offline returns true if the repository resolves strictly from the local cache.
+func (c *LocalRepository) Offline() bool {
+	return c.offline
+}
+
+// SetChecksumPolicy sets how downloads that don't match their published checksum are handled.
+func (c *LocalRepository) SetChecksumPolicy(policy ChecksumPolicy) {
+	c.checksumPolicy = policy
+}
+
+// SetActivationContext sets the environment POM profiles are activated by. POMs that were
+// already loaded are loaded again.
+func (c *LocalRepository) SetActivationContext(ctx *ActivationContext) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.activation = ctx
+	c.poms = make(map[string]*POM)
+}
+
+// SetJavaVersion sets the Java version that the code using the artifacts targets, such as the
+// --release of a module, which selects the variants of artifacts published with Gradle Module
+// Metadata. Zero uses the version of the JDK in the activation context. POMs that were already
+// loaded are loaded again if the version changes.
+func (c *LocalRepository) SetJavaVersion(version int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.javaVersion != version {
+		c.javaVersion = version
+		c.poms = make(map[string]*POM)
 	}
 }
 
+// BaseDir returns the absolute path of the repository directory.
+func (c *LocalRepository) BaseDir() string {
+	return expandHome(c.baseDir)
+}
+
+// expandHome replaces a leading ~ with the user's home directory.
+func expandHome(dir string) string {
+	if strings.HasPrefix(dir, "~") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			panic(err)
+		}
+		dir = filepath.Join(homeDir, dir[1:])
+	}
+	return dir
+}
+
 func GAV(groupID, artifactID, version string) string {
 	return groupID + ":" + artifactID + ":" + version
 }
@@ -43,6 +258,39 @@ func jarFile(artifactID, version string) string {
 	)
 }
 
+// artifactTypes maps the dependency types maven defines to the extension and classifier of the
+// file they refer to. Any other type, such as zip, is used as the extension.
+var artifactTypes = map[string]struct{ extension, classifier string }{
+	"jar":          {"jar", ""},
+	"bundle":       {"jar", ""},
+	"ejb":          {"jar", ""},
+	"maven-plugin": {"jar", ""},
+	"test-jar":     {"jar", "tests"},
+	"ejb-client":   {"jar", "client"},
+	"java-source":  {"jar", "sources"},
+	"javadoc":      {"jar", "javadoc"},
+	"pom":          {"pom", ""},
+}
+
+// artifactFile returns the name of the file for a classifier and type, such as
+// lwjgl-3.3.4-natives-linux.jar, or lib-1.0-tests.jar for type test-jar. An empty type means jar.
+func artifactFile(artifactID, version, classifier, typ string) string {
+	extension := typ
+	if extension == "" {
+		extension = "jar"
+	}
+	if known, found := artifactTypes[typ]; found {
+		extension = known.extension
+		if classifier == "" {
+			classifier = known.classifier
+		}
+	}
+	if classifier != "" {
+		return fmt.Sprintf("%s-%s-%s.%s", artifactID, version, classifier, extension)
+	}
+	return fmt.Sprintf("%s-%s.%s", artifactID, version, extension)
+}
+
 func pomFile(artifactID, version string) string {
 	return fmt.Sprintf("%s-%s.pom",
 		artifactID,
@@ -51,18 +299,15 @@ func pomFile(artifactID, version string) string {
 }
 
 func (jc *LocalRepository) artifactDir(groupID, artifactID, version string) string {
+	return repositoryDir(jc.BaseDir(), groupID, artifactID, version)
+}
+
+// repositoryDir returns the directory of a version of an artifact in a repository at baseDir,
+// or of the artifact itself if version is empty.
+func repositoryDir(baseDir, groupID, artifactID, version string) string {
 	groupIDWithSlashes := strings.ReplaceAll(groupID, ".", "/")
 	relPath := filepath.Join(strings.Split(groupIDWithSlashes, "/")...)
 	relPath = filepath.Join(relPath, artifactID, version)
-
-	baseDir := jc.baseDir
-	if strings.HasPrefix(baseDir, "~") {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			panic(err)
-		}
-		baseDir = filepath.Join(homeDir, baseDir[1:])
-	}
 	return filepath.Join(baseDir, relPath)
 }
 
@@ -71,26 +316,34 @@ func (c *LocalRepository) GetPOM(groupID, artifactID, version string) (*POM, err
 		return nil, fmt.Errorf("invalid maven coordinates %s:%s:%s", groupID, artifactID, version)
 	}
 	gav := GAV(groupID, artifactID, version)
+	c.mu.Lock()
 	pom, found := c.poms[gav]
+	c.mu.Unlock()
 	if found {
 		return pom, nil
 	}
-	pom = &POM{}
-	pomFile := pomFile(artifactID, version)
-	path, err := c.getFile(groupID, artifactID, version, pomFile)
+	return c.pomLoads.do(gav, func() (*POM, error) {
+		return c.loadPOM(groupID, artifactID, version)
+	})
+}
+
+// loadPOM reads a POM and merges in its active profiles, its parents and imported dependency
+// management. The POM is only cached once it is complete.
+func (c *LocalRepository) loadPOM(groupID, artifactID, version string) (*POM, error) {
+	pom := &POM{}
+	path, err := c.GetPOMPath(groupID, artifactID, version)
 	if err != nil {
 		return pom, err
 	}
-	xmlFile, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return pom, err
 	}
-	defer xmlFile.Close()
-	decoder := xml.NewDecoder(xmlFile)
+	decoder := xml.NewDecoder(bytes.NewReader(data))
 	decoder.CharsetReader = charset.NewReaderLabel
 	err = decoder.Decode(&pom)
-	if err == nil {
-		c.poms[gav] = pom
+	if err != nil {
+		return pom, err
 	}
 
 	if pom.DependencyManagement == nil {
@@ -101,6 +354,12 @@ func (c *LocalRepository) GetPOM(groupID, artifactID, version string) (*POM, err
 	if pom.Dependencies == nil {
 		pom.Dependencies = make([]Dependency, 0)
 	}
+	c.mu.Lock()
+	activation := c.activation
+	c.mu.Unlock()
+	if activation != nil {
+		pom.applyProfiles(activation)
+	}
 
 	if pom.Parent != nil {
 		err = c.expandParentProperties(pom)
@@ -123,11 +382,15 @@ func (c *LocalRepository) GetPOM(groupID, artifactID, version string) (*POM, err
 		}
 	}
 	for i := range pom.Dependencies {
+		dmDep := pom.findDependency(pom.Dependencies[i].GroupID, pom.Dependencies[i].ArtifactID)
+		if dmDep == nil {
+			continue
+		}
 		if pom.Dependencies[i].Version == "" {
-			dmDep := pom.findDependency(pom.Dependencies[i].GroupID, pom.Dependencies[i].ArtifactID)
-			if dmDep != nil {
-				pom.Dependencies[i].Version = dmDep.Version
-			}
+			pom.Dependencies[i].Version = dmDep.Version
+		}
+		if len(pom.Dependencies[i].Exclusions) == 0 {
+			pom.Dependencies[i].Exclusions = dmDep.Exclusions
 		}
 	}
 	for i := range pom.Dependencies {
@@ -136,12 +399,19 @@ func (c *LocalRepository) GetPOM(groupID, artifactID, version string) (*POM, err
 		}
 	}
 
+	if bytes.Contains(data, []byte(gradleMetadataMarker)) {
+		c.applyGradleMetadata(pom, groupID, artifactID, version)
+	}
+
 	// todo sanity check, make sure POM has version and all
 
 	// Pretty print the POM to the terminal
 	//dump(pom)
 
-	return pom, err
+	c.mu.Lock()
+	c.poms[GAV(groupID, artifactID, version)] = pom
+	c.mu.Unlock()
+	return pom, nil
 }
 
 func (c *LocalRepository) expandParentProperties(pom *POM) error {
@@ -230,44 +500,224 @@ func mergeParentDeps(child *DependencyManagement, parent *DependencyManagement)
 	}
 }
 
+// GetPOMPath returns the path of the POM file in the local repository, downloading it if needed.
+func (c *LocalRepository) GetPOMPath(groupID, artifactID, version string) (string, error) {
+	return c.getFile(groupID, artifactID, version, pomFile(artifactID, version))
+}
+
+// GetJAR returns the path of the jar in the local repository, downloading it if needed. For an
+// artifact published with Gradle Module Metadata whose POM was already loaded, it is the file of
+// the selected variant.
 func (c *LocalRepository) GetJAR(groupID, artifactID, version string) (string, error) {
-	return c.getFile(groupID, artifactID, version, jarFile(artifactID, version))
+	file := jarFile(artifactID, version)
+	c.mu.Lock()
+	pom, found := c.poms[GAV(groupID, artifactID, version)]
+	c.mu.Unlock()
+	if found && pom.variantFile != "" {
+		file = pom.variantFile
+	}
+	return c.getFile(groupID, artifactID, version, file)
+}
+
+// GetArtifact returns the path of the file with the given classifier and type in the local
+// repository, downloading it if needed. Empty classifier and type give the same file as GetJAR.
+func (c *LocalRepository) GetArtifact(groupID, artifactID, version, classifier, typ string) (string, error) {
+	if classifier == "" && (typ == "" || typ == "jar") {
+		return c.GetJAR(groupID, artifactID, version)
+	}
+	return c.getFile(groupID, artifactID, version, artifactFile(artifactID, version, classifier, typ))
 }
 
 func (c *LocalRepository) getFile(groupID, artifactID, version, file string) (string, error) {
+	if isSnapshot(version) {
+		return c.getSnapshotFile(groupID, artifactID, version, file)
+	}
+	return c.getCachedFile(c.remotes, groupID, artifactID, version, file)
+}
+
+// getCachedFile returns the path of a file in the local repository, downloading it from the
+// first of the remotes that has it if it isn't there yet.
+func (c *LocalRepository) getCachedFile(remotes []*Remote, groupID, artifactID, version, file string) (string, error) {
 	artifactPath := filepath.Join(c.artifactDir(groupID, artifactID, version), file)
-	if fileExists(artifactPath) {
+	return c.downloads.do(artifactPath, func() (string, error) {
+		return c.fetchFile(remotes, groupID, artifactID, version, file, artifactPath)
+	})
+}
+
+func (c *LocalRepository) fetchFile(remotes []*Remote, groupID, artifactID, version, file, artifactPath string) (string, error) {
+	if fileExists(artifactPath) && incompleteReason(artifactPath) == "" {
 		return artifactPath, nil
 	}
-	err := os.MkdirAll(filepath.Dir(artifactPath), 0755)
-	//fmt.Printf("mkdir -p %s\n", filepath.Dir(artifactPath))
-	if err != nil {
-		return "", err
+	if path := c.mavenLocalPath(groupID, artifactID, version, file); path != "" {
+		return path, nil
+	}
+	if c.offline {
+		return artifactPath, &NotCachedError{GroupID: groupID, ArtifactID: artifactID, Version: version, File: file}
 	}
-	outFile, err := os.OpenFile(artifactPath, os.O_CREATE|os.O_WRONLY, 0600)
+	unlock, err := lockDir(filepath.Dir(artifactPath))
 	if err != nil {
 		return artifactPath, err
 	}
-	defer outFile.Close()
-	for _, remote := range c.remotes {
-		err = fetchFromRemote(remote, groupID, artifactID, version, file, outFile)
+	defer unlock()
+	// another process may have downloaded the file while we were waiting for the lock
+	if fileExists(artifactPath) {
+		reason := incompleteReason(artifactPath)
+		if reason == "" {
+			return artifactPath, nil
+		}
+		fmt.Fprintf(os.Stderr, "warning: downloading %s again: %s\n", artifactPath, reason)
+		if err := removeCachedFile(artifactPath); err != nil {
+			return artifactPath, err
+		}
+	}
+	// report why the last remote asked failed, and why the others weren't asked, so that a file
+	// that isn't published can be told apart from a remote that refused the credentials
+	err = nil
+	skipped := make([]string, 0)
+	for _, remote := range remotes {
+		if !remote.Serves(version) {
+			continue
+		}
+		if reason := c.skipReason(remote, artifactPath); reason != "" {
+			skipped = append(skipped, fmt.Sprintf("skipped %s, %s", remote.Name, reason))
+			continue
+		}
+		err = c.download(remote, groupID, artifactID, version, file, artifactPath)
 		if err == nil {
-			break
+			return artifactPath, nil
+		}
+		fmt.Fprintf(os.Stderr, "error fetching from maven %s: %s\n", remote.Name, err.Error())
+	}
+	switch {
+	case err == nil && len(skipped) == 0:
+		err = fmt.Errorf("no remote repository serves version %s", version)
+	case err == nil:
+		err = errors.New(strings.Join(skipped, "; "))
+	case len(skipped) > 0:
+		err = fmt.Errorf("%w; %s", err, strings.Join(skipped, "; "))
+	}
+	return artifactPath, fmt.Errorf("failed to fetch %s from any remote: %w", artifactPath, err)
+}
+
+// download fetches a file from a remote into path and verifies it against the checksum the remote
+// publishes for it. The verified checksum is stored next to the file in the cache, and a file
+// the remote doesn't have is recorded so that it isn't asked for it again too soon. The file is
+// downloaded to a temporary file that is only renamed to path once it is verified, so path never
+// holds part of a download and a copy already there survives a failed one.
+func (c *LocalRepository) download(remote *Remote, groupID, artifactID, version, file, path string) error {
+	algorithm, checksum := "", ""
+	err := writeFileAtomic(path, 0600, func(out *os.File) error {
+		hashers := newChecksumHashers()
+		err := fetchFromRemote(remote, groupID, artifactID, version, file, &downloadWriter{file: out, hashers: hashers})
+		if err != nil || c.checksumPolicy == ChecksumIgnore {
+			return err
 		}
-		fmt.Printf("error fetching from maven %s: %s\n", remote, err.Error())
+		algorithm, checksum, err = verifyRemoteChecksum(remote, groupID, artifactID, version, file, hashers)
+		if err != nil {
+			if c.checksumPolicy == ChecksumFail {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+			algorithm = ""
+			return nil
+		}
+		if algorithm == "" {
+			fmt.Fprintf(os.Stderr, "warning: %s does not publish a checksum for %s\n", remote.Name, file)
+		}
+		return nil
+	})
+	c.recordFetch(remote, path, err)
+	if err != nil || algorithm == "" {
+		return err
 	}
-	// not found ... delete empty file and return error
+	return writeFileAtomic(path+"."+algorithm, 0600, func(out *os.File) error {
+		_, err := out.WriteString(checksum)
+		return err
+	})
+}
+
+// downloadWriter writes a download to a file while hashing it, and starts over if the download
+// is retried.
+type downloadWriter struct {
+	file    *os.File
+	hashers checksumHashers
+}
+
+func (w *downloadWriter) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	w.hashers.Write(p[:n])
+	return n, err
+}
+
+func (w *downloadWriter) restart() error {
+	for _, hasher := range w.hashers {
+		hasher.Reset()
+	}
+	if err := w.file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := w.file.Seek(0, io.SeekStart)
+	return err
+}
+
+// incompleteReason returns why a file in the local repository can't be used, such as an empty
+// file or a truncated jar left behind by an interrupted download, or an empty string if it looks
+// complete. It is cheap enough to check on every use, VerifyCache does the thorough checks.
+func incompleteReason(path string) string {
+	file, err := os.Open(path)
 	if err != nil {
-		outFile.Close()
-		os.Remove(artifactPath)
-		return artifactPath, fmt.Errorf("failed to fetch %s from any remote", artifactPath)
+		return err.Error()
+	}
+	defer file.Close()
+	header := make([]byte, len(zipHeader))
+	n, err := io.ReadFull(file, header)
+	if n == 0 {
+		return "file is empty"
+	}
+	// a jar that starts like a zip but whose central directory, at the end, can't be read was cut short
+	if err == nil && bytes.Equal(header, zipHeader) && strings.HasSuffix(path, ".jar") {
+		info, err := file.Stat()
+		if err != nil {
+			return err.Error()
+		}
+		if _, err := zip.NewReader(file, info.Size()); err != nil {
+			return fmt.Sprintf("jar is truncated or corrupt: %s", err)
+		}
+	}
+	return ""
+}
+
+// removeCachedFile removes a file from the local repository along with the checksums stored
+// next to it.
+func removeCachedFile(path string) error {
+	for _, algorithm := range checksumAlgorithms {
+		if err := os.Remove(path + "." + algorithm); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Remove(path)
+}
+
+// ArtifactFile is a file published as part of a version of an artifact, such as its jar, POM,
+// or sources jar.
+type ArtifactFile struct {
+	Path       string
+	Classifier string // empty for the main artifact
+	Extension  string // jar, pom, ...
+}
+
+// name returns the file name in the repository layout.
+func (f ArtifactFile) name(artifactID, version string) string {
+	name := artifactID + "-" + version
+	if f.Classifier != "" {
+		name += "-" + f.Classifier
 	}
-	return artifactPath, err
+	return name + "." + f.Extension
 }
 
-func (c *LocalRepository) InstallPackage(groupID, artifactID, version, jarPath, pomPath string) error {
-	pomFileName := fmt.Sprintf("%s-%s.pom", artifactID, version)
-	jarFileName := fmt.Sprintf("%s-%s.jar", artifactID, version)
+// InstallPackage copies the files of a version of an artifact into the local repository. Files
+// of a release that is already installed are never replaced.
+func (c *LocalRepository) InstallPackage(groupID, artifactID, version string, files []ArtifactFile) error {
 	artifactDir := c.artifactDir(groupID, artifactID, version)
 	preRelease := strings.Contains(version, "-")
 
@@ -276,25 +726,21 @@ func (c *LocalRepository) InstallPackage(groupID, artifactID, version, jarPath,
 	if err != nil {
 		return fmt.Errorf("failed to create maven directory: %w", err)
 	}
-
-	// Copy POM file
-	destPomPath := filepath.Join(artifactDir, pomFileName)
-	if fileExists(destPomPath) && !preRelease {
-		return fmt.Errorf("POM file already exists at %s and version is not a pre-release", destPomPath)
-	}
-	err = copyFile(pomPath, destPomPath)
+	unlock, err := lockDir(artifactDir)
 	if err != nil {
-		return fmt.Errorf("failed to copy POM file: %w", err)
+		return err
 	}
+	defer unlock()
 
-	// Copy JAR file
-	destJarPath := filepath.Join(artifactDir, jarFileName)
-	if fileExists(destJarPath) && !preRelease {
-		return fmt.Errorf("JAR file already exists at %s and version is not a pre-release", destJarPath)
-	}
-	err = copyFile(jarPath, destJarPath)
-	if err != nil {
-		return fmt.Errorf("failed to copy JAR file: %w", err)
+	for _, file := range files {
+		destPath := filepath.Join(artifactDir, file.name(artifactID, version))
+		if fileExists(destPath) && !preRelease {
+			return fmt.Errorf("%s already exists and version is not a pre-release", destPath)
+		}
+		err = copyFile(file.Path, destPath)
+		if err != nil {
+			return fmt.Errorf("failed to copy %s: %w", file.Path, err)
+		}
 	}
 	fmt.Printf("Successfully published %s:%s:%s to local repository\n", groupID, artifactID, version)
 	return nil
@@ -322,39 +768,43 @@ func copyFile(src, dst string) error {
 		return fmt.Errorf("failed to open source file: %w", err)
 	}
 	defer srcFile.Close()
-	dstFile, err := os.Create(dst)
-	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
-	}
-	defer dstFile.Close()
-	_, err = io.Copy(dstFile, srcFile)
-	if err != nil {
-		return fmt.Errorf("failed to copy file content: %w", err)
-	}
-	return nil
+	return writeFileAtomic(dst, 0644, func(out *os.File) error {
+		if _, err := io.Copy(out, srcFile); err != nil {
+			return fmt.Errorf("failed to copy file content: %w", err)
+		}
+		return nil
+	})
 }
 
-func fetchFromRemote(mavenBaseURL string, groupID, artifactID, version, file string, out io.Writer) error {
-	groupIDWithSlashes := strings.ReplaceAll(groupID, ".", "/")
-	u, err := url.Parse(mavenBaseURL)
+// writeFileAtomic creates a temporary file next to path, writes it with write and then renames it
+// to path, so that readers see either the previous file or the complete new one. The temporary
+// file is removed if writing fails.
+func writeFileAtomic(path string, perm os.FileMode, write func(out *os.File) error) error {
+	out, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.part")
 	if err != nil {
-		return fmt.Errorf("failed to parse maven Coordinates: %w", err)
+		return err
 	}
-	u.Path = path.Join(u.Path, groupIDWithSlashes, artifactID, version, file)
-	fileURL := u.String()
-	fmt.Printf("Fetching %s\n", fileURL)
-	resp, err := http.Get(fileURL)
-	if err != nil {
-		return fmt.Errorf("error downloading %s: %v", fileURL, err)
+	err = write(out)
+	if err == nil {
+		err = out.Chmod(perm)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download %s: %s", fileURL, resp.Status)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(out.Name(), path)
 	}
-	_, err = io.Copy(out, resp.Body)
 	if err != nil {
-		return fmt.Errorf("error saving %s: %v", fileURL, err)
+		os.Remove(out.Name())
 	}
-	//fmt.Printf("Successfully downloaded %s\n", fileURL)
-	return nil
+	return err
+}
+
+func fetchFromRemote(remote *Remote, groupID, artifactID, version, file string, out io.Writer) error {
+	fileURL, err := remote.fileURL(groupID, artifactID, version, file)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Fetching %s\n", fileURL)
+	return remote.fetch(fileURL, out)
 }
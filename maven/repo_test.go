@@ -1,10 +1,17 @@
 package maven
 
 import (
+	"archive/zip"
+	"bytes"
 	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -122,11 +129,14 @@ func TestResolveMavenFields(t *testing.T) {
 }
 
 func TestOpenLocalRepository(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
 	repo := OpenLocalRepository()
 	assert.NotNil(t, repo)
 	assert.Equal(t, "~/.jb/repository", repo.baseDir)
-	assert.NotNil(t, repo.remotes)
-	assert.Contains(t, repo.remotes, MAVEN_CENTRAL_URL)
+	assert.Equal(t, MavenLocalDir, repo.mavenLocalDir)
+	assert.True(t, repo.readMavenLocal)
+	require.Len(t, repo.remotes, 1)
+	assert.Equal(t, MAVEN_CENTRAL_URL, repo.remotes[0].URL)
 	assert.NotNil(t, repo.poms)
 }
 
@@ -140,6 +150,15 @@ func TestJarFile(t *testing.T) {
 	assert.Equal(t, "my-lib-1.0.0.jar", jarName)
 }
 
+func TestArtifactFile(t *testing.T) {
+	assert.Equal(t, "my-lib-1.0.0.jar", artifactFile("my-lib", "1.0.0", "", ""))
+	assert.Equal(t, "my-lib-1.0.0-natives-linux.jar", artifactFile("my-lib", "1.0.0", "natives-linux", ""))
+	assert.Equal(t, "my-lib-1.0.0-tests.jar", artifactFile("my-lib", "1.0.0", "", "test-jar"))
+	assert.Equal(t, "my-lib-1.0.0-jdk8.jar", artifactFile("my-lib", "1.0.0", "jdk8", "test-jar"))
+	assert.Equal(t, "my-lib-1.0.0-dist.zip", artifactFile("my-lib", "1.0.0", "dist", "zip"))
+	assert.Equal(t, "my-lib-1.0.0.pom", artifactFile("my-lib", "1.0.0", "", "pom"))
+}
+
 func TestPomFile(t *testing.T) {
 	pomName := pomFile("my-lib", "1.0.0")
 	assert.Equal(t, "my-lib-1.0.0.pom", pomName)
@@ -380,7 +399,7 @@ func TestGetPOM(t *testing.T) {
 	// Create repository with custom base dir
 	repo := &LocalRepository{
 		baseDir: tempDir,
-		remotes: []string{MAVEN_CENTRAL_URL},
+		remotes: []*Remote{centralRemote()},
 		poms:    make(map[string]*POM),
 	}
 
@@ -415,3 +434,254 @@ func TestGetPOM(t *testing.T) {
 	_, err = repo.GetPOM("com.nonexistent", "lib", "1.0.0")
 	assert.Error(t, err)
 }
+
+func TestPOMWithExclusions(t *testing.T) {
+	tempDir := t.TempDir()
+	repo := &LocalRepository{
+		baseDir: tempDir,
+		remotes: []*Remote{centralRemote()},
+		poms:    make(map[string]*POM),
+	}
+	pomDir := filepath.Join(tempDir, "com", "example", "app", "1.0.0")
+	require.NoError(t, os.MkdirAll(pomDir, 0755))
+	pomContent := `<?xml version="1.0" encoding="UTF-8"?>
+<project>
+    <groupId>com.example</groupId>
+    <artifactId>app</artifactId>
+    <version>1.0.0</version>
+    <dependencyManagement>
+        <dependencies>
+            <dependency>
+                <groupId>org.apache.httpcomponents</groupId>
+                <artifactId>httpclient</artifactId>
+                <version>4.5.14</version>
+                <exclusions>
+                    <exclusion>
+                        <groupId>commons-logging</groupId>
+                        <artifactId>commons-logging</artifactId>
+                    </exclusion>
+                </exclusions>
+            </dependency>
+        </dependencies>
+    </dependencyManagement>
+    <dependencies>
+        <dependency>
+            <groupId>org.apache.httpcomponents</groupId>
+            <artifactId>httpclient</artifactId>
+        </dependency>
+        <dependency>
+            <groupId>org.example</groupId>
+            <artifactId>everything</artifactId>
+            <version>2.0</version>
+            <exclusions>
+                <exclusion>
+                    <groupId>*</groupId>
+                    <artifactId>*</artifactId>
+                </exclusion>
+            </exclusions>
+        </dependency>
+    </dependencies>
+</project>`
+	require.NoError(t, os.WriteFile(filepath.Join(pomDir, "app-1.0.0.pom"), []byte(pomContent), 0644))
+
+	pom, err := repo.GetPOM("com.example", "app", "1.0.0")
+	require.NoError(t, err)
+	require.Len(t, pom.Dependencies, 2)
+
+	// managed exclusions are applied along with the managed version
+	httpclient := pom.Dependencies[0]
+	assert.Equal(t, "4.5.14", httpclient.Version)
+	assert.Equal(t, []Exclusion{{GroupID: "commons-logging", ArtifactID: "commons-logging"}}, httpclient.Exclusions)
+
+	wildcard := pom.Dependencies[1].Exclusions[0]
+	assert.True(t, wildcard.Matches("any.group", "any-artifact"))
+	assert.True(t, httpclient.Exclusions[0].Matches("commons-logging", "commons-logging"))
+	assert.False(t, httpclient.Exclusions[0].Matches("commons-logging", "other"))
+}
+
+func TestGetFile_Offline(t *testing.T) {
+	server := newTestRemote(t, map[string]string{"com/example/lib/1.0/lib-1.0.pom": "<project/>"})
+	repo := NewLocalRepository(t.TempDir(), server.URL)
+	repo.SetOffline(true)
+
+	_, err := repo.GetPOMPath("com.example", "lib", "1.0")
+	var notCached *NotCachedError
+	require.ErrorAs(t, err, &notCached)
+	assert.Equal(t, "lib-1.0.pom", notCached.File)
+	assert.EqualError(t, err, "com.example:lib:1.0 (lib-1.0.pom) is not in the local repository")
+
+	repo.SetOffline(false)
+	repo.SetChecksumPolicy(ChecksumIgnore)
+	path, err := repo.GetPOMPath("com.example", "lib", "1.0")
+	require.NoError(t, err)
+
+	repo.SetOffline(true)
+	cached, err := repo.GetPOMPath("com.example", "lib", "1.0")
+	require.NoError(t, err)
+	assert.Equal(t, path, cached)
+}
+
+func TestOpenLocalRepository_OfflineEnv(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(OfflineEnv, "true")
+	assert.True(t, OpenLocalRepository().Offline())
+	t.Setenv(OfflineEnv, "0")
+	assert.False(t, OpenLocalRepository().Offline())
+}
+
+func TestGetJAR_ConcurrentFetchesShareDownload(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/com/example/lib/1.0/lib-1.0.jar" {
+			http.NotFound(w, r)
+			return
+		}
+		requests.Add(1)
+		<-release
+		_, _ = w.Write([]byte("jar content"))
+	}))
+	t.Cleanup(server.Close)
+	repo := NewLocalRepository(t.TempDir(), server.URL)
+	repo.SetChecksumPolicy(ChecksumIgnore)
+
+	var wg sync.WaitGroup
+	paths := make([]string, 4)
+	for i := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			paths[i], err = repo.GetJAR("com.example", "lib", "1.0")
+			assert.NoError(t, err)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), requests.Load())
+	for _, path := range paths {
+		assert.Equal(t, paths[0], path)
+	}
+}
+
+func TestInstallPackage(t *testing.T) {
+	srcDir := t.TempDir()
+	files := make([]ArtifactFile, 0)
+	for _, file := range []ArtifactFile{
+		{Extension: "jar"},
+		{Extension: "pom"},
+		{Classifier: "sources", Extension: "jar"},
+		{Classifier: "javadoc", Extension: "jar"},
+	} {
+		file.Path = filepath.Join(srcDir, file.Classifier+"."+file.Extension)
+		require.NoError(t, os.WriteFile(file.Path, []byte(file.Classifier+file.Extension), 0644))
+		files = append(files, file)
+	}
+	repo := NewLocalRepository(t.TempDir())
+
+	require.NoError(t, repo.InstallPackage("com.example", "lib", "1.0", files))
+
+	dir := repo.artifactDir("com.example", "lib", "1.0")
+	for name, content := range map[string]string{
+		"lib-1.0.jar":         "jar",
+		"lib-1.0.pom":         "pom",
+		"lib-1.0-sources.jar": "sourcesjar",
+		"lib-1.0-javadoc.jar": "javadocjar",
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+	}
+
+	// releases are never replaced, snapshots are
+	err := repo.InstallPackage("com.example", "lib", "1.0", files)
+	assert.ErrorContains(t, err, "already exists and version is not a pre-release")
+	require.NoError(t, repo.InstallPackage("com.example", "lib", "1.1-SNAPSHOT", files))
+	require.NoError(t, repo.InstallPackage("com.example", "lib", "1.1-SNAPSHOT", files))
+}
+
+func TestGetJAR_RepairsIncompleteFiles(t *testing.T) {
+	var jar bytes.Buffer
+	zw := zip.NewWriter(&jar)
+	_, err := zw.Create("META-INF/MANIFEST.MF")
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	server := newTestRemote(t, map[string]string{
+		"com/example/lib/1.0/lib-1.0.jar": jar.String(),
+		"com/example/lib/1.0/lib-1.0.pom": "<project/>",
+	})
+	repo := NewLocalRepository(t.TempDir(), server.URL)
+	repo.SetChecksumPolicy(ChecksumIgnore)
+
+	// left behind by an interrupted download
+	dir := repo.artifactDir("com.example", "lib", "1.0")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib-1.0.jar"), jar.Bytes()[:jar.Len()/2], 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib-1.0.pom"), nil, 0644))
+
+	repo.SetOffline(true)
+	_, err = repo.GetJAR("com.example", "lib", "1.0")
+	var notCached *NotCachedError
+	require.ErrorAs(t, err, &notCached)
+
+	repo.SetOffline(false)
+	path, err := repo.GetJAR("com.example", "lib", "1.0")
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, jar.Bytes(), data)
+	path, err = repo.GetPOMPath("com.example", "lib", "1.0")
+	require.NoError(t, err)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "<project/>", string(data))
+}
+
+func TestGetJAR_InterruptedDownload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the connection drops after part of the jar
+		w.Header().Set("Content-Length", "100")
+		_, _ = w.Write([]byte("partial"))
+	}))
+	t.Cleanup(server.Close)
+	repo := NewLocalRepository(t.TempDir(), server.URL)
+	repo.SetChecksumPolicy(ChecksumIgnore)
+	repo.remotes[0].client = newTestHTTPClient(t, &HTTPSettings{})
+
+	path, err := repo.GetJAR("com.example", "lib", "1.0")
+	require.Error(t, err)
+	assert.False(t, fileExists(path))
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.Equal(t, lockFileName, entry.Name())
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lib-1.0.jar")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0644))
+
+	// a failed write keeps the previous file
+	err := writeFileAtomic(path, 0644, func(out *os.File) error {
+		_, _ = out.WriteString("new but incomplete")
+		return os.ErrDeadlineExceeded
+	})
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(data))
+
+	require.NoError(t, writeFileAtomic(path, 0644, func(out *os.File) error {
+		_, err := out.WriteString("new")
+		return err
+	}))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
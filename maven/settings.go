@@ -0,0 +1,76 @@
+package maven
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const SettingsFilename = "settings.json"
+
+// Settings is the user level configuration read from ~/.jb/settings.json.
+type Settings struct {
+	ChecksumPolicy string                 `json:"checksum_policy,omitempty"`
+	Repositories   []RepositoryConfig     `json:"repositories,omitempty"`
+	MavenLocal     *bool                  `json:"maven_local,omitempty"` // read from ~/.m2/repository, defaults to true
+	HTTP           *HTTPSettings          `json:"http,omitempty"`
+	Mirrors        []MirrorConfig         `json:"mirrors,omitempty"`
+	MavenSettings  *bool                  `json:"maven_settings,omitempty"` // also use servers, mirrors and proxies from ~/.m2/settings.xml, defaults to true
+	servers        map[string]mavenServer // credentials imported from maven's settings.xml by id
+}
+
+// RepositoryConfig declares a remote maven repository in the user settings or a project file.
+// Credentials are never stored in the file itself, only the names of the environment
+// variables holding them.
+type RepositoryConfig struct {
+	Name         string `json:"name"`
+	URL          string `json:"url"`
+	Releases     *bool  `json:"releases,omitempty"`      // defaults to true
+	Snapshots    *bool  `json:"snapshots,omitempty"`     // defaults to true
+	UpdatePolicy string `json:"update_policy,omitempty"` // always, daily (default), never, or interval:N
+	UsernameEnv  string `json:"username_env,omitempty"`  // basic auth
+	PasswordEnv  string `json:"password_env,omitempty"`  // basic auth
+	TokenEnv     string `json:"token_env,omitempty"`     // bearer token
+	username     string // from maven's settings.xml, never written to settings.json
+	password     string
+}
+
+// UserSettingsPath returns the path of the user's settings file.
+func UserSettingsPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		panic(err)
+	}
+	return filepath.Join(homeDir, ".jb", SettingsFilename)
+}
+
+// LoadSettings reads a settings file, returning empty settings if it doesn't exist.
+func LoadSettings(path string) (*Settings, error) {
+	settings := &Settings{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, settings); err != nil {
+		return nil, fmt.Errorf("invalid settings file %s: %w", path, err)
+	}
+	if settings.ChecksumPolicy != "" {
+		if _, err := ParseChecksumPolicy(settings.ChecksumPolicy); err != nil {
+			return nil, fmt.Errorf("invalid settings file %s: %w", path, err)
+		}
+	}
+	if _, err := newHTTPClient(settings.HTTP); err != nil {
+		return nil, fmt.Errorf("invalid settings file %s: %w", path, err)
+	}
+	for _, mirror := range settings.Mirrors {
+		if mirror.Name == "" || mirror.URL == "" || mirror.MirrorOf == "" {
+			return nil, fmt.Errorf("invalid settings file %s: a mirror needs a name, url and mirror_of", path)
+		}
+	}
+	return settings, nil
+}
@@ -0,0 +1,66 @@
+package maven
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSettings(t *testing.T) {
+	dir := t.TempDir()
+
+	settings, err := LoadSettings(filepath.Join(dir, "missing.json"))
+	require.NoError(t, err)
+	assert.Empty(t, settings.Repositories)
+
+	path := filepath.Join(dir, SettingsFilename)
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"checksum_policy": "warn",
+		"repositories": [
+			{"name": "nexus", "url": "https://nexus.example.com/maven/", "snapshots": false, "token_env": "NEXUS_TOKEN"}
+		]
+	}`), 0644))
+	settings, err = LoadSettings(path)
+	require.NoError(t, err)
+	assert.Equal(t, "warn", settings.ChecksumPolicy)
+	require.Len(t, settings.Repositories, 1)
+	assert.Equal(t, "nexus", settings.Repositories[0].Name)
+	assert.Nil(t, settings.Repositories[0].Releases)
+	assert.False(t, *settings.Repositories[0].Snapshots)
+	assert.Equal(t, "NEXUS_TOKEN", settings.Repositories[0].TokenEnv)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"checksum_policy": "strict"}`), 0644))
+	_, err = LoadSettings(path)
+	assert.ErrorContains(t, err, "invalid checksum policy 'strict'")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"http": {"connect_timeout": "5s", "retries": 1}}`), 0644))
+	settings, err = LoadSettings(path)
+	require.NoError(t, err)
+	assert.Equal(t, "5s", settings.HTTP.ConnectTimeout)
+	assert.Equal(t, 1, *settings.HTTP.Retries)
+	require.NoError(t, os.WriteFile(path, []byte(`{"http": {"connect_timeout": "5"}}`), 0644))
+	_, err = LoadSettings(path)
+	assert.ErrorContains(t, err, "invalid connect_timeout '5'")
+}
+
+func TestOpenLocalRepository_UserSettings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".jb"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".jb", SettingsFilename), []byte(`{
+		"checksum_policy": "ignore",
+		"repositories": [{"name": "user", "url": "https://user.example.com/maven/"}]
+	}`), 0644))
+
+	repo := OpenLocalRepository()
+	assert.Equal(t, ChecksumIgnore, repo.checksumPolicy)
+	require.NoError(t, repo.SetRepositories([]RepositoryConfig{{Name: "project", URL: "https://project.example.com/maven/"}}))
+	var names []string
+	for _, remote := range repo.Remotes() {
+		names = append(names, remote.Name)
+	}
+	assert.Equal(t, []string{"project", "user", "central"}, names)
+}
@@ -0,0 +1,213 @@
+package maven
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+// Environment variables holding the key used to sign published artifacts.
+const (
+	SigningKeyEnv        = "JB_SIGNING_KEY"        // the armored private key itself
+	SigningKeyFileEnv    = "JB_SIGNING_KEY_FILE"   // path of a file with the armored private key
+	SigningPassphraseEnv = "JB_SIGNING_PASSPHRASE" // passphrase of a protected key
+)
+
+// signatureExtension is appended to the name of a file to get the name of its detached signature.
+const signatureExtension = ".asc"
+
+// Signer creates detached OpenPGP signatures for the files of an artifact, as required to
+// publish to maven central.
+type Signer struct {
+	entity *openpgp.Entity
+}
+
+// NewSigner creates a signer from an armored private key, decrypting it with passphrase if it
+// is protected.
+func NewSigner(armoredKey []byte, passphrase string) (*Signer, error) {
+	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(armoredKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key: %w", err)
+	}
+	var entity *openpgp.Entity
+	for _, candidate := range keyring {
+		if candidate.PrivateKey != nil {
+			entity = candidate
+			break
+		}
+	}
+	if entity == nil {
+		return nil, fmt.Errorf("invalid signing key: no private key found")
+	}
+	// sign with the key openpgp picks, which is a signing subkey when there is one, so check
+	// that one rather than the primary key, which may be a stub kept offline
+	key, ok := entity.SigningKey(time.Now())
+	if !ok {
+		return nil, fmt.Errorf("invalid signing key: no valid key for signing")
+	}
+	if key.PrivateKey == nil || key.PrivateKey.Dummy() {
+		return nil, fmt.Errorf("invalid signing key: the private part of key %s is missing", key.PublicKey.KeyIdString())
+	}
+	if key.PrivateKey.Encrypted {
+		if passphrase == "" {
+			return nil, fmt.Errorf("signing key is protected by a passphrase, set %s", SigningPassphraseEnv)
+		}
+		if err := key.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+			return nil, fmt.Errorf("failed to decrypt signing key: %w", err)
+		}
+	}
+	return &Signer{entity: entity}, nil
+}
+
+// LoadSigner reads the armored private key from keyFile, or if that is empty from the file
+// named by JB_SIGNING_KEY_FILE or the key in JB_SIGNING_KEY. The passphrase of a protected key
+// is read from JB_SIGNING_PASSPHRASE.
+func LoadSigner(keyFile string) (*Signer, error) {
+	if keyFile == "" {
+		keyFile = os.Getenv(SigningKeyFileEnv)
+	}
+	var key []byte
+	if keyFile != "" {
+		var err error
+		key, err = os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signing key: %w", err)
+		}
+	} else if value := os.Getenv(SigningKeyEnv); value != "" {
+		key = []byte(value)
+	} else {
+		return nil, fmt.Errorf("no signing key, set %s or %s", SigningKeyFileEnv, SigningKeyEnv)
+	}
+	return NewSigner(key, os.Getenv(SigningPassphraseEnv))
+}
+
+// KeyID returns the id of the signing key in hex.
+func (s *Signer) KeyID() string {
+	return s.entity.PrimaryKey.KeyIdString()
+}
+
+// PublicKeys returns the public part of the signing key, for verifying its signatures.
+func (s *Signer) PublicKeys() openpgp.EntityList {
+	return openpgp.EntityList{s.entity}
+}
+
+// SignFile writes an armored detached signature of the file at path to path.asc.
+func (s *Signer) SignFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	var signature bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&signature, s.entity, file, nil); err != nil {
+		return "", fmt.Errorf("failed to sign %s: %w", path, err)
+	}
+	signature.WriteString("\n")
+	signaturePath := path + signatureExtension
+	if err := os.WriteFile(signaturePath, signature.Bytes(), 0644); err != nil {
+		return "", err
+	}
+	return signaturePath, nil
+}
+
+// SignFiles signs each file and returns the files followed by their signatures.
+func (s *Signer) SignFiles(files []ArtifactFile) ([]ArtifactFile, error) {
+	signed := slices.Clone(files)
+	for _, file := range files {
+		signaturePath, err := s.SignFile(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		signed = append(signed, ArtifactFile{
+			Path:       signaturePath,
+			Classifier: file.Classifier,
+			Extension:  file.Extension + signatureExtension,
+		})
+	}
+	return signed, nil
+}
+
+// ReadPublicKeys reads an armored key ring such as the output of 'gpg --export --armor'.
+func ReadPublicKeys(path string) (openpgp.EntityList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	keyring, err := openpgp.ReadArmoredKeyRing(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key ring %s: %w", path, err)
+	}
+	return keyring, nil
+}
+
+// SignatureProblem describes a file with a missing or bad signature.
+type SignatureProblem struct {
+	Path   string
+	Reason string
+}
+
+// VerifySignatures checks the detached signature of every artifact file under dir against the
+// keys in keyring. Checksums, signatures and repository metadata are not artifacts themselves.
+// It returns the number of files checked and the problems found.
+func VerifySignatures(dir string, keyring openpgp.KeyRing) (int, []SignatureProblem, error) {
+	checked := 0
+	problems := make([]SignatureProblem, 0)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isSignedFile(d.Name()) {
+			return nil
+		}
+		checked++
+		if err := verifySignature(path, keyring); err != nil {
+			problems = append(problems, SignatureProblem{Path: path, Reason: err.Error()})
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return checked, problems, nil
+}
+
+func isSignedFile(name string) bool {
+	if strings.HasPrefix(name, "maven-metadata") || strings.HasSuffix(name, signatureExtension) || name == lockFileName || name == remoteRepositoriesFile {
+		return false
+	}
+	for _, suffix := range []string{".md5", ".sha1", ".sha256", ".sha512", ".part", ".lastUpdated"} {
+		if strings.HasSuffix(name, suffix) {
+			return false
+		}
+	}
+	return true
+}
+
+func verifySignature(path string, keyring openpgp.KeyRing) error {
+	signature, err := os.Open(path + signatureExtension)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no signature")
+	}
+	if err != nil {
+		return err
+	}
+	defer signature.Close()
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := openpgp.CheckArmoredDetachedSignature(keyring, file, signature, nil); err != nil {
+		return fmt.Errorf("bad signature: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package maven
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSigningKey generates an armored private key, protected by passphrase if it isn't empty.
+func newTestSigningKey(t *testing.T, passphrase string) []byte {
+	t.Helper()
+	config := &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA}
+	entity, err := openpgp.NewEntity("Test", "", "test@example.com", config)
+	require.NoError(t, err)
+	if passphrase != "" {
+		require.NoError(t, entity.EncryptPrivateKeys([]byte(passphrase), config))
+	}
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PrivateKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.SerializePrivateWithoutSigning(w, config))
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestSigner_SignAndVerify(t *testing.T) {
+	signer, err := NewSigner(newTestSigningKey(t, ""), "")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	files := make([]ArtifactFile, 0)
+	for _, file := range []ArtifactFile{{Extension: "jar"}, {Extension: "pom"}, {Classifier: "sources", Extension: "jar"}} {
+		file.Path = filepath.Join(dir, file.name("lib", "1.0"))
+		require.NoError(t, os.WriteFile(file.Path, []byte("content of "+file.Path), 0644))
+		require.NoError(t, os.WriteFile(file.Path+".sha1", []byte("not an artifact"), 0644))
+		files = append(files, file)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "maven-metadata.xml"), []byte("<metadata/>"), 0644))
+	// written by jb publish --m2 next to the files
+	require.NoError(t, os.WriteFile(filepath.Join(dir, remoteRepositoriesFile), []byte("lib-1.0.jar>=\n"), 0644))
+
+	signed, err := signer.SignFiles(files)
+	require.NoError(t, err)
+	require.Len(t, signed, 6)
+	assert.Equal(t, ArtifactFile{Path: files[2].Path + ".asc", Classifier: "sources", Extension: "jar.asc"}, signed[5])
+	assert.Equal(t, "lib-1.0-sources.jar.asc", signed[5].name("lib", "1.0"))
+
+	checked, problems, err := VerifySignatures(dir, signer.PublicKeys())
+	require.NoError(t, err)
+	assert.Equal(t, 3, checked)
+	assert.Empty(t, problems)
+
+	// a changed file, a missing signature, and a signature by another key are all reported
+	require.NoError(t, os.WriteFile(files[0].Path, []byte("tampered"), 0644))
+	require.NoError(t, os.Remove(files[1].Path+".asc"))
+	other, err := NewSigner(newTestSigningKey(t, ""), "")
+	require.NoError(t, err)
+	_, err = other.SignFile(files[2].Path)
+	require.NoError(t, err)
+
+	checked, problems, err = VerifySignatures(dir, signer.PublicKeys())
+	require.NoError(t, err)
+	assert.Equal(t, 3, checked)
+	require.Len(t, problems, 3)
+	reasons := make(map[string]string)
+	for _, problem := range problems {
+		reasons[filepath.Base(problem.Path)] = problem.Reason
+	}
+	assert.Contains(t, reasons["lib-1.0.jar"], "bad signature")
+	assert.Equal(t, "no signature", reasons["lib-1.0.pom"])
+	assert.Contains(t, reasons["lib-1.0-sources.jar"], "bad signature")
+}
+
+func TestNewSigner_Passphrase(t *testing.T) {
+	key := newTestSigningKey(t, "secret")
+
+	_, err := NewSigner(key, "")
+	assert.EqualError(t, err, "signing key is protected by a passphrase, set JB_SIGNING_PASSPHRASE")
+	_, err = NewSigner(key, "wrong")
+	assert.ErrorContains(t, err, "failed to decrypt signing key")
+
+	signer, err := NewSigner(key, "secret")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "lib-1.0.jar")
+	require.NoError(t, os.WriteFile(path, []byte("jar"), 0644))
+	_, err = signer.SignFile(path)
+	require.NoError(t, err)
+	_, problems, err := VerifySignatures(filepath.Dir(path), signer.PublicKeys())
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+
+	_, err = NewSigner([]byte("not a key"), "")
+	assert.ErrorContains(t, err, "invalid signing key")
+}
+
+func TestNewSigner_EncryptedSubkey(t *testing.T) {
+	// only the signing subkey is protected, as when the primary key is kept offline
+	config := &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA}
+	entity, err := openpgp.NewEntity("Test", "", "test@example.com", config)
+	require.NoError(t, err)
+	require.NoError(t, entity.AddSigningSubkey(config))
+	subkey := entity.Subkeys[len(entity.Subkeys)-1]
+	require.NoError(t, subkey.PrivateKey.Encrypt([]byte("secret")))
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PrivateKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.SerializePrivateWithoutSigning(w, config))
+	require.NoError(t, w.Close())
+
+	_, err = NewSigner(buf.Bytes(), "")
+	assert.EqualError(t, err, "signing key is protected by a passphrase, set JB_SIGNING_PASSPHRASE")
+
+	signer, err := NewSigner(buf.Bytes(), "secret")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "lib-1.0.jar")
+	require.NoError(t, os.WriteFile(path, []byte("jar"), 0644))
+	_, err = signer.SignFile(path)
+	require.NoError(t, err)
+	_, problems, err := VerifySignatures(filepath.Dir(path), signer.PublicKeys())
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
+
+func TestLoadSigner(t *testing.T) {
+	key := newTestSigningKey(t, "")
+	keyFile := filepath.Join(t.TempDir(), "key.asc")
+	require.NoError(t, os.WriteFile(keyFile, key, 0600))
+
+	t.Setenv(SigningKeyEnv, "")
+	t.Setenv(SigningKeyFileEnv, "")
+	_, err := LoadSigner("")
+	assert.EqualError(t, err, "no signing key, set JB_SIGNING_KEY_FILE or JB_SIGNING_KEY")
+
+	fromFile, err := LoadSigner(keyFile)
+	require.NoError(t, err)
+
+	t.Setenv(SigningKeyEnv, string(key))
+	fromEnv, err := LoadSigner("")
+	require.NoError(t, err)
+	assert.Equal(t, fromFile.KeyID(), fromEnv.KeyID())
+
+	t.Setenv(SigningKeyFileEnv, filepath.Join(t.TempDir(), "missing.asc"))
+	_, err = LoadSigner("")
+	assert.ErrorContains(t, err, "failed to read signing key")
+}
@@ -0,0 +1,150 @@
+package maven
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RefreshSnapshotsEnv is the environment variable that makes repositories opened with
+// OpenLocalRepository check for new snapshot builds regardless of the update policy.
+const RefreshSnapshotsEnv = "JB_REFRESH_SNAPSHOTS"
+
+// snapshotMetadata is the newest build of a snapshot version found on any remote.
+type snapshotMetadata struct {
+	remote   *Remote
+	metadata *Metadata
+}
+
+// SetRefreshSnapshots sets whether snapshot versions are checked for new builds on every remote
+// regardless of their update policy.
+func (c *LocalRepository) SetRefreshSnapshots(refresh bool) {
+	c.refreshSnapshots = refresh
+}
+
+// getSnapshotFile returns a file of a snapshot version. Remote repositories publish each build
+// of a snapshot under a timestamped version listed in the version's maven-metadata.xml, such as
+// lib-1.0-20260101.120000-3.jar for lib-1.0-SNAPSHOT.jar. The timestamped file of the latest
+// build is cached and returned, unless a file installed locally under the plain snapshot name,
+// here or in the maven local repository, is newer.
+func (c *LocalRepository) getSnapshotFile(groupID, artifactID, version, file string) (string, error) {
+	snapshot, err := c.getSnapshotMetadata(groupID, artifactID, version)
+	if err != nil {
+		return "", err
+	}
+	installed := c.installedSnapshot(groupID, artifactID, version, file)
+	if snapshot != nil {
+		classifier, extension := splitFileName(artifactID, version, file)
+		value := snapshot.metadata.snapshotValue(version, classifier, extension)
+		if value != "" && !modifiedAfter(installed, snapshot.metadata.snapshotTime()) {
+			timestamped := strings.Replace(file, version, value, 1)
+			return c.getCachedFile([]*Remote{snapshot.remote}, groupID, artifactID, version, timestamped)
+		}
+	}
+	if installed != "" {
+		return installed, nil
+	}
+	return c.getCachedFile(c.remotes, groupID, artifactID, version, file)
+}
+
+// getSnapshotMetadata returns the newest build of a snapshot version across the remotes, or nil
+// if no remote publishes metadata for it. Each remote's metadata is cached in the version
+// directory and checked again according to the remote's update policy.
+func (c *LocalRepository) getSnapshotMetadata(groupID, artifactID, version string) (*snapshotMetadata, error) {
+	gav := GAV(groupID, artifactID, version)
+	c.mu.Lock()
+	snapshot, found := c.snapshots[gav]
+	c.mu.Unlock()
+	if found {
+		return snapshot, nil
+	}
+	return c.snapshotLoads.do(gav, func() (*snapshotMetadata, error) {
+		snapshot, err := c.loadSnapshotMetadata(groupID, artifactID, version)
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.snapshots[gav] = snapshot
+		c.mu.Unlock()
+		return snapshot, nil
+	})
+}
+
+func (c *LocalRepository) loadSnapshotMetadata(groupID, artifactID, version string) (*snapshotMetadata, error) {
+	var newest *snapshotMetadata
+	dir := c.artifactDir(groupID, artifactID, version)
+	for _, remote := range c.remotes {
+		if !remote.Snapshots {
+			continue
+		}
+		path := filepath.Join(dir, metadataCacheFile(remote))
+		if !c.offline && (c.refreshSnapshots || remote.UpdatePolicy.isDue(path)) {
+			// most remotes won't have a given snapshot, so a failure here isn't worth reporting
+			_ = c.refreshFile(remote, groupID, artifactID, version, metadataFile, path)
+		}
+		if !fileExists(path) {
+			continue
+		}
+		metadata, err := readMetadata(path)
+		if err != nil {
+			return nil, err
+		}
+		if newest == nil || metadata.snapshotTimestamp() > newest.metadata.snapshotTimestamp() {
+			newest = &snapshotMetadata{remote: remote, metadata: metadata}
+		}
+	}
+	return newest, nil
+}
+
+// splitFileName returns the classifier and extension of a file name such as
+// lib-1.0-SNAPSHOT-sources.jar.
+func splitFileName(artifactID, version, file string) (string, string) {
+	suffix := strings.TrimPrefix(file, artifactID+"-"+version)
+	classifier := ""
+	if strings.HasPrefix(suffix, "-") {
+		classifier, suffix, _ = strings.Cut(suffix[1:], ".")
+		return classifier, suffix
+	}
+	return classifier, strings.TrimPrefix(suffix, ".")
+}
+
+// snapshotValue returns the timestamped version of the file with the given classifier and
+// extension in the latest build, or an empty string if the files are published under the
+// plain snapshot version.
+func (m *Metadata) snapshotValue(version, classifier, extension string) string {
+	for _, snapshotVersion := range m.Versioning.SnapshotVersions {
+		if snapshotVersion.Classifier == classifier && snapshotVersion.Extension == extension {
+			return snapshotVersion.Value
+		}
+	}
+	snapshot := m.Versioning.Snapshot
+	if snapshot == nil || snapshot.LocalCopy || snapshot.Timestamp == "" {
+		return ""
+	}
+	base := version[:len(version)-len("SNAPSHOT")]
+	return fmt.Sprintf("%s%s-%d", base, snapshot.Timestamp, snapshot.BuildNumber)
+}
+
+// snapshotTimestamp returns when the latest build was published as yyyyMMddHHmmss, so that
+// builds on different remotes can be compared as strings.
+func (m *Metadata) snapshotTimestamp() string {
+	if m.Versioning.Snapshot != nil && m.Versioning.Snapshot.Timestamp != "" {
+		return strings.ReplaceAll(m.Versioning.Snapshot.Timestamp, ".", "")
+	}
+	return m.Versioning.LastUpdated
+}
+
+func (m *Metadata) snapshotTime() time.Time {
+	t, err := time.Parse("20060102150405", m.snapshotTimestamp())
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func modifiedAfter(path string, t time.Time) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.ModTime().After(t)
+}
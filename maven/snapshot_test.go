@@ -0,0 +1,125 @@
+package maven
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const snapshotDir = "com/example/lib/1.0-SNAPSHOT/"
+
+func snapshotMetadataXML(timestamp string, buildNumber int) string {
+	value := fmt.Sprintf("1.0-%s-%d", timestamp, buildNumber)
+	return fmt.Sprintf(`<metadata><groupId>com.example</groupId><artifactId>lib</artifactId><version>1.0-SNAPSHOT</version>
+<versioning><snapshot><timestamp>%s</timestamp><buildNumber>%d</buildNumber></snapshot><lastUpdated>20260101120000</lastUpdated>
+<snapshotVersions>
+<snapshotVersion><extension>jar</extension><value>%s</value></snapshotVersion>
+<snapshotVersion><classifier>sources</classifier><extension>jar</extension><value>%s</value></snapshotVersion>
+</snapshotVersions></versioning></metadata>`, timestamp, buildNumber, value, value)
+}
+
+func TestGetJAR_Snapshot(t *testing.T) {
+	files := map[string]string{
+		snapshotDir + "maven-metadata.xml":            snapshotMetadataXML("20260101.120000", 3),
+		snapshotDir + "lib-1.0-20260101.120000-3.jar": "build 3",
+		snapshotDir + "lib-1.0-20260102.090000-4.jar": "build 4",
+	}
+	server := newTestRemote(t, files)
+	baseDir := t.TempDir()
+	open := func() *LocalRepository {
+		repo := NewLocalRepository(baseDir, server.URL)
+		repo.SetChecksumPolicy(ChecksumIgnore)
+		return repo
+	}
+
+	path, err := open().GetJAR("com.example", "lib", "1.0-SNAPSHOT")
+	require.NoError(t, err)
+	assert.Equal(t, "lib-1.0-20260101.120000-3.jar", filepath.Base(path))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "build 3", string(content))
+
+	// a new build is only picked up once the update policy says so, or when refreshing
+	files[snapshotDir+"maven-metadata.xml"] = snapshotMetadataXML("20260102.090000", 4)
+	path, err = open().GetJAR("com.example", "lib", "1.0-SNAPSHOT")
+	require.NoError(t, err)
+	assert.Equal(t, "lib-1.0-20260101.120000-3.jar", filepath.Base(path))
+
+	repo := open()
+	repo.SetRefreshSnapshots(true)
+	path, err = repo.GetJAR("com.example", "lib", "1.0-SNAPSHOT")
+	require.NoError(t, err)
+	assert.Equal(t, "lib-1.0-20260102.090000-4.jar", filepath.Base(path))
+
+	// offline uses the cached metadata
+	repo = open()
+	repo.SetOffline(true)
+	path, err = repo.GetJAR("com.example", "lib", "1.0-SNAPSHOT")
+	require.NoError(t, err)
+	assert.Equal(t, "lib-1.0-20260102.090000-4.jar", filepath.Base(path))
+	_, err = repo.GetJAR("com.example", "lib", "2.0-SNAPSHOT")
+	assert.EqualError(t, err, "com.example:lib:2.0-SNAPSHOT (lib-2.0-SNAPSHOT.jar) is not in the local repository")
+}
+
+func TestGetJAR_SnapshotInstalledLocally(t *testing.T) {
+	server := newTestRemote(t, map[string]string{
+		snapshotDir + "maven-metadata.xml":            snapshotMetadataXML("20260101.120000", 3),
+		snapshotDir + "lib-1.0-20260101.120000-3.jar": "build 3",
+	})
+	repo := NewLocalRepository(t.TempDir(), server.URL)
+	repo.SetChecksumPolicy(ChecksumIgnore)
+	dir := repo.artifactDir("com.example", "lib", "1.0-SNAPSHOT")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	plain := filepath.Join(dir, "lib-1.0-SNAPSHOT.jar")
+	require.NoError(t, os.WriteFile(plain, []byte("local build"), 0644))
+
+	// installed after the remote build was published
+	path, err := repo.GetJAR("com.example", "lib", "1.0-SNAPSHOT")
+	require.NoError(t, err)
+	assert.Equal(t, plain, path)
+
+	// installed before it
+	old := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(plain, old, old))
+	path, err = repo.GetJAR("com.example", "lib", "1.0-SNAPSHOT")
+	require.NoError(t, err)
+	assert.Equal(t, "lib-1.0-20260101.120000-3.jar", filepath.Base(path))
+}
+
+func TestSnapshotValue(t *testing.T) {
+	metadata := &Metadata{Versioning: Versioning{Snapshot: &Snapshot{Timestamp: "20260101.120000", BuildNumber: 7}}}
+	assert.Equal(t, "1.0-20260101.120000-7", metadata.snapshotValue("1.0-SNAPSHOT", "", "pom"))
+	metadata.Versioning.Snapshot.LocalCopy = true
+	assert.Equal(t, "", metadata.snapshotValue("1.0-SNAPSHOT", "", "pom"))
+
+	classifier, extension := splitFileName("lib", "1.0-SNAPSHOT", "lib-1.0-SNAPSHOT-sources.jar")
+	assert.Equal(t, "sources", classifier)
+	assert.Equal(t, "jar", extension)
+	classifier, extension = splitFileName("lib", "1.0-SNAPSHOT", "lib-1.0-SNAPSHOT.tar.gz")
+	assert.Equal(t, "", classifier)
+	assert.Equal(t, "tar.gz", extension)
+}
+
+func TestUpdatePolicy(t *testing.T) {
+	for _, value := range []string{"always", "Daily", "never", "interval:30"} {
+		_, err := ParseUpdatePolicy(value)
+		assert.NoError(t, err)
+	}
+	_, err := ParseUpdatePolicy("interval:x")
+	assert.EqualError(t, err, "invalid update policy 'interval:x', must be one of always, daily, never, or interval:N")
+
+	path := writeTemp(t, "cached")
+	assert.True(t, UpdateAlways.isDue(path))
+	assert.False(t, UpdateDaily.isDue(path))
+	assert.False(t, UpdateNever.isDue(path))
+	assert.True(t, UpdateNever.isDue(path+".missing"))
+	hourAgo := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(path, hourAgo, hourAgo))
+	assert.True(t, UpdatePolicy("interval:30").isDue(path))
+	assert.False(t, UpdatePolicy("interval:90").isDue(path))
+}
@@ -0,0 +1,439 @@
+package maven
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Version is a maven version that orders the way maven's ComparableVersion does: numbers
+// compare numerically, "1.0-alpha" < "1.0-beta" < "1.0-rc" < "1.0-SNAPSHOT" < "1.0" < "1.0-sp",
+// trailing zeros are ignored so "1" == "1.0.0", and unknown qualifiers sort after the known
+// ones, alphabetically.
+type Version struct {
+	raw   string
+	items *listItem
+}
+
+// versionItem is one part of a parsed version. A nil item stands for a missing part when
+// comparing versions of different lengths.
+type versionItem interface {
+	compare(other versionItem) int
+	isNull() bool
+}
+
+// intItem is a number, kept as a string of digits without leading zeros so that it can be
+// arbitrarily long.
+type intItem string
+
+// stringItem is a qualifier.
+type stringItem string
+
+// listItem is a sub-list of items started by '-' or by a switch between digits and letters.
+type listItem []versionItem
+
+var qualifiers = []string{"alpha", "beta", "milestone", "rc", "snapshot", "", "sp"}
+
+var qualifierAliases = map[string]string{
+	"ga":      "",
+	"final":   "",
+	"release": "",
+	"cr":      "rc",
+}
+
+// releaseQualifier is the comparable form of the empty qualifier of a release.
+var releaseQualifier = comparableQualifier("")
+
+// ParseVersion parses a version string. Every string is a valid version.
+func ParseVersion(s string) Version {
+	return Version{raw: s, items: parseVersionItems(strings.ToLower(s))}
+}
+
+func (v Version) String() string {
+	return v.raw
+}
+
+// Compare returns -1, 0, or 1 depending on whether v is older than, equal to, or newer than other.
+func (v Version) Compare(other Version) int {
+	return v.items.compare(other.items)
+}
+
+// IsSnapshot returns true for -SNAPSHOT versions.
+func (v Version) IsSnapshot() bool {
+	return isSnapshot(v.raw)
+}
+
+// IsPreRelease returns true for snapshots and versions with a qualifier that sorts before a
+// release, such as alpha, beta, milestone and rc. Other qualifiers such as "jre" don't count.
+func (v Version) IsPreRelease() bool {
+	return v.IsSnapshot() || hasPreReleaseQualifier(v.items)
+}
+
+func hasPreReleaseQualifier(l *listItem) bool {
+	for _, item := range *l {
+		switch i := item.(type) {
+		case stringItem:
+			if i.compare(nil) < 0 {
+				return true
+			}
+		case *listItem:
+			if hasPreReleaseQualifier(i) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Numbers returns the leading numeric components of the version, such as [1 2 3] for
+// "1.2.3-jre".
+func (v Version) Numbers() []int {
+	numbers := make([]int, 0, 3)
+	for _, part := range strings.Split(v.raw, ".") {
+		digits := part
+		end := strings.IndexFunc(part, func(r rune) bool { return r < '0' || r > '9' })
+		if end >= 0 {
+			digits = part[:end]
+		}
+		n, err := strconv.Atoi(digits)
+		if err != nil {
+			break
+		}
+		numbers = append(numbers, n)
+		if end >= 0 {
+			break
+		}
+	}
+	return numbers
+}
+
+// CompareVersions compares two version strings, see Version.Compare.
+func CompareVersions(a, b string) int {
+	return ParseVersion(a).Compare(ParseVersion(b))
+}
+
+func parseVersionItems(version string) *listItem {
+	root := &listItem{}
+	list := root
+	stack := []*listItem{root}
+	isDigit := false
+	start := 0
+
+	startSubList := func() {
+		sub := &listItem{}
+		*list = append(*list, sub)
+		list = sub
+		stack = append(stack, sub)
+	}
+
+	for i := 0; i < len(version); i++ {
+		c := version[i]
+		switch {
+		case c == '.':
+			if i == start {
+				*list = append(*list, intItem(""))
+			} else {
+				*list = append(*list, parseVersionItem(isDigit, version[start:i]))
+			}
+			start = i + 1
+		case c == '-':
+			if i == start {
+				*list = append(*list, intItem(""))
+			} else {
+				*list = append(*list, parseVersionItem(isDigit, version[start:i]))
+			}
+			start = i + 1
+			startSubList()
+		case c >= '0' && c <= '9':
+			if !isDigit && i > start {
+				*list = append(*list, newStringItem(version[start:i], true))
+				start = i
+				startSubList()
+			}
+			isDigit = true
+		default:
+			if isDigit && i > start {
+				*list = append(*list, parseVersionItem(true, version[start:i]))
+				start = i
+				startSubList()
+			}
+			isDigit = false
+		}
+	}
+	if len(version) > start {
+		*list = append(*list, parseVersionItem(isDigit, version[start:]))
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		stack[i].normalize()
+	}
+	return root
+}
+
+func parseVersionItem(isDigit bool, s string) versionItem {
+	if isDigit {
+		return intItem(strings.TrimLeft(s, "0"))
+	}
+	return newStringItem(s, false)
+}
+
+func newStringItem(s string, followedByDigit bool) stringItem {
+	if followedByDigit && len(s) == 1 {
+		// 1.0a1 is short for 1.0-alpha-1
+		switch s {
+		case "a":
+			s = "alpha"
+		case "b":
+			s = "beta"
+		case "m":
+			s = "milestone"
+		}
+	}
+	if alias, found := qualifierAliases[s]; found {
+		s = alias
+	}
+	return stringItem(s)
+}
+
+// normalize removes trailing null items, stopping at the first item that isn't null or a list.
+func (l *listItem) normalize() {
+	for i := len(*l) - 1; i >= 0; i-- {
+		item := (*l)[i]
+		if item.isNull() {
+			*l = slices.Delete(*l, i, i+1)
+		} else if _, ok := item.(*listItem); !ok {
+			break
+		}
+	}
+}
+
+func (i intItem) isNull() bool { return i == "" }
+
+func (i intItem) compare(other versionItem) int {
+	switch o := other.(type) {
+	case nil:
+		if i == "" {
+			return 0
+		}
+		return 1
+	case intItem:
+		if len(i) != len(o) {
+			return cmpInt(len(i), len(o))
+		}
+		return strings.Compare(string(i), string(o))
+	default:
+		return 1 // 1.1 > 1-sp and 1.1 > 1-1
+	}
+}
+
+func (s stringItem) isNull() bool { return s == "" }
+
+func (s stringItem) compare(other versionItem) int {
+	switch o := other.(type) {
+	case nil:
+		// 1-rc < 1, 1-ga == 1, 1-sp > 1
+		return strings.Compare(comparableQualifier(string(s)), releaseQualifier)
+	case stringItem:
+		return strings.Compare(comparableQualifier(string(s)), comparableQualifier(string(o)))
+	default:
+		return -1 // 1.any < 1.1 and 1.any < 1-1
+	}
+}
+
+func (l *listItem) isNull() bool { return len(*l) == 0 }
+
+func (l *listItem) compare(other versionItem) int {
+	switch o := other.(type) {
+	case nil:
+		if len(*l) == 0 {
+			return 0
+		}
+		return (*l)[0].compare(nil)
+	case intItem:
+		return -1 // 1-1 < 1.0.x
+	case stringItem:
+		return 1 // 1-1 > 1-sp
+	case *listItem:
+		for i := 0; i < max(len(*l), len(*o)); i++ {
+			var left, right versionItem
+			if i < len(*l) {
+				left = (*l)[i]
+			}
+			if i < len(*o) {
+				right = (*o)[i]
+			}
+			if result := compareItem(left, right); result != 0 {
+				return result
+			}
+		}
+		return 0
+	}
+	panic(fmt.Sprintf("unexpected version item %T", other))
+}
+
+// compareItem compares two items, either of which may be nil.
+func compareItem(item, other versionItem) int {
+	if item == nil {
+		if other == nil {
+			return 0
+		}
+		return -other.compare(nil)
+	}
+	return item.compare(other)
+}
+
+// comparableQualifier maps known qualifiers to their position so that they sort in release
+// order, and puts unknown qualifiers after them in alphabetical order.
+func comparableQualifier(qualifier string) string {
+	if i := slices.Index(qualifiers, qualifier); i >= 0 {
+		return fmt.Sprint(i)
+	}
+	return fmt.Sprintf("%d-%s", len(qualifiers), qualifier)
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// VersionRange is a set of version restrictions such as "[1.2,2.0)" or "(,1.0],[1.2,)".
+type VersionRange struct {
+	spec         string
+	restrictions []restriction
+}
+
+type restriction struct {
+	lower          *Version // nil if unbounded
+	lowerInclusive bool
+	upper          *Version // nil if unbounded
+	upperInclusive bool
+}
+
+// IsVersionRange returns true if spec uses range syntax rather than naming a single version.
+func IsVersionRange(spec string) bool {
+	return strings.HasPrefix(spec, "[") || strings.HasPrefix(spec, "(")
+}
+
+// ParseVersionRange parses range syntax:
+//
+//	[1.0]          exactly 1.0
+//	[1.2,2.0)      1.2 <= v < 2.0
+//	(,1.0]         v <= 1.0
+//	[1.5,)         v >= 1.5
+//	(,1.0],[1.2,)  v <= 1.0 or v >= 1.2
+func ParseVersionRange(spec string) (*VersionRange, error) {
+	r := &VersionRange{spec: spec}
+	process := strings.TrimSpace(spec)
+	if !IsVersionRange(process) {
+		return nil, fmt.Errorf("invalid version range '%s', must start with '[' or '('", spec)
+	}
+	for IsVersionRange(process) {
+		end := strings.IndexAny(process, ")]")
+		if end < 0 {
+			return nil, fmt.Errorf("invalid version range '%s', unbounded range", spec)
+		}
+		rest, err := parseRestriction(process[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid version range '%s', %w", spec, err)
+		}
+		if len(r.restrictions) > 0 {
+			previous := r.restrictions[len(r.restrictions)-1]
+			if previous.upper == nil || rest.lower == nil || previous.upper.Compare(*rest.lower) >= 0 {
+				return nil, fmt.Errorf("invalid version range '%s', ranges overlap", spec)
+			}
+		}
+		r.restrictions = append(r.restrictions, rest)
+		process = strings.TrimSpace(process[end+1:])
+		if strings.HasPrefix(process, ",") {
+			process = strings.TrimSpace(process[1:])
+		}
+	}
+	if process != "" {
+		return nil, fmt.Errorf("invalid version range '%s', only fully qualified sets are allowed", spec)
+	}
+	return r, nil
+}
+
+func parseRestriction(spec string) (restriction, error) {
+	r := restriction{
+		lowerInclusive: strings.HasPrefix(spec, "["),
+		upperInclusive: strings.HasSuffix(spec, "]"),
+	}
+	inner := strings.TrimSpace(spec[1 : len(spec)-1])
+	lower, upper, isRange := strings.Cut(inner, ",")
+	if !isRange {
+		if !r.lowerInclusive || !r.upperInclusive {
+			return r, fmt.Errorf("single version %s must be surrounded by []", spec)
+		}
+		if inner == "" {
+			return r, fmt.Errorf("%s has no version", spec)
+		}
+		version := ParseVersion(inner)
+		r.lower, r.upper = &version, &version
+		return r, nil
+	}
+	lower, upper = strings.TrimSpace(lower), strings.TrimSpace(upper)
+	if lower != "" {
+		version := ParseVersion(lower)
+		r.lower = &version
+	}
+	if upper != "" {
+		version := ParseVersion(upper)
+		r.upper = &version
+	}
+	if r.lower != nil && r.upper != nil {
+		switch cmp := r.upper.Compare(*r.lower); {
+		case cmp < 0:
+			return r, fmt.Errorf("%s defies version ordering", spec)
+		case cmp == 0:
+			return r, fmt.Errorf("%s cannot have identical boundaries", spec)
+		}
+	}
+	return r, nil
+}
+
+func (r restriction) contains(v Version) bool {
+	if r.lower != nil {
+		cmp := r.lower.Compare(v)
+		if cmp > 0 || (cmp == 0 && !r.lowerInclusive) {
+			return false
+		}
+	}
+	if r.upper != nil {
+		cmp := r.upper.Compare(v)
+		if cmp < 0 || (cmp == 0 && !r.upperInclusive) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *VersionRange) String() string {
+	return r.spec
+}
+
+// Contains returns true if the version satisfies any of the range's restrictions.
+func (r *VersionRange) Contains(v Version) bool {
+	for _, rest := range r.restrictions {
+		if rest.contains(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsSnapshots returns true if one of the range's boundaries is a snapshot, in which case
+// snapshots may be selected from it.
+func (r *VersionRange) allowsSnapshots() bool {
+	for _, rest := range r.restrictions {
+		if (rest.lower != nil && rest.lower.IsSnapshot()) || (rest.upper != nil && rest.upper.IsSnapshot()) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package maven
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func assertVersionOrder(t *testing.T, versions []string) {
+	t.Helper()
+	for i := range versions {
+		for j := range versions {
+			expected := cmpInt(i, j)
+			assert.Equal(t, expected, CompareVersions(versions[i], versions[j]), "%s vs %s", versions[i], versions[j])
+		}
+	}
+}
+
+func TestCompareVersions_Qualifiers(t *testing.T) {
+	assertVersionOrder(t, []string{
+		"1-alpha2snapshot", "1-alpha2", "1-alpha-123", "1-beta-2", "1-beta123", "1-m2", "1-m11", "1-rc", "1-cr2",
+		"1-rc123", "1-SNAPSHOT", "1", "1-sp", "1-sp2", "1-sp123", "1-abc", "1-def", "1-pom-1", "1-1-snapshot",
+		"1-1", "1-2", "1-123",
+	})
+}
+
+func TestCompareVersions_Numbers(t *testing.T) {
+	assertVersionOrder(t, []string{
+		"2.0", "2-1", "2.0.a", "2.0.0.a", "2.0.2", "2.0.123", "2.1.0", "2.1-a", "2.1b", "2.1-c", "2.1-1", "2.1.0.1",
+		"2.2", "2.123", "11.a2", "11.a11", "11.b2", "11.b11", "11.m2", "11.m11", "11", "11.a", "11b", "11c", "11m",
+	})
+}
+
+func TestCompareVersions_Equal(t *testing.T) {
+	equal := [][]string{
+		{"1", "1.0", "1.0.0", "1-0", "1.0-0", "1-ga", "1.0.0-final", "1-release", "1.0-GA"},
+		{"1a", "1-a", "1.0-a", "1.0.0-a"},
+		{"1alpha", "1-alpha", "1.0-ALPHA"},
+		{"1a1", "1alpha1", "1-alpha-1", "1-alpha1"},
+		{"1b2", "1beta2", "1-beta-2"},
+		{"1m3", "1milestone3", "1-milestone-3"},
+		{"1rc", "1cr", "1-rc", "1-CR"},
+		{"1x", "1-x", "1.0.0-x"},
+		{"9223372036854775807000", "9223372036854775807000.0"},
+	}
+	for _, versions := range equal {
+		for _, v := range versions {
+			assert.Equal(t, 0, CompareVersions(versions[0], v), "%s vs %s", versions[0], v)
+		}
+	}
+	assert.Equal(t, 1, CompareVersions("9223372036854775807001", "9223372036854775807000"))
+}
+
+func TestParseVersionRange(t *testing.T) {
+	tests := []struct {
+		spec     string
+		contains []string
+		excludes []string
+	}{
+		{spec: "[1.0]", contains: []string{"1.0", "1"}, excludes: []string{"1.0.1", "0.9"}},
+		{spec: "[1.2,2.0)", contains: []string{"1.2", "1.5", "1.99"}, excludes: []string{"1.1", "2.0", "2.0.1"}},
+		{spec: "(1.2,2.0]", contains: []string{"1.2.1", "2.0"}, excludes: []string{"1.2", "2.0.1"}},
+		{spec: "(,1.0]", contains: []string{"0.1", "1.0"}, excludes: []string{"1.0.1"}},
+		{spec: "[1.5,)", contains: []string{"1.5", "100"}, excludes: []string{"1.4"}},
+		{spec: "(,1.0],[1.2,)", contains: []string{"1.0", "1.2", "3"}, excludes: []string{"1.1"}},
+		{spec: "[1.0, 2.0)", contains: []string{"1.0"}, excludes: []string{"2.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			r, err := ParseVersionRange(tt.spec)
+			require.NoError(t, err)
+			for _, v := range tt.contains {
+				assert.True(t, r.Contains(ParseVersion(v)), v)
+			}
+			for _, v := range tt.excludes {
+				assert.False(t, r.Contains(ParseVersion(v)), v)
+			}
+		})
+	}
+}
+
+func TestParseVersionRange_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"1.0":                 "invalid version range '1.0', must start with '[' or '('",
+		"[1.0":                "invalid version range '[1.0', unbounded range",
+		"(1.0)":               "invalid version range '(1.0)', single version (1.0) must be surrounded by []",
+		"[2.0,1.0]":           "invalid version range '[2.0,1.0]', [2.0,1.0] defies version ordering",
+		"[1.0,1.0]":           "invalid version range '[1.0,1.0]', [1.0,1.0] cannot have identical boundaries",
+		"[1.0,2.0],1.5":       "invalid version range '[1.0,2.0],1.5', only fully qualified sets are allowed",
+		"[1.0,2.0],[1.5,3.0]": "invalid version range '[1.0,2.0],[1.5,3.0]', ranges overlap",
+	}
+	for spec, expected := range tests {
+		_, err := ParseVersionRange(spec)
+		assert.EqualError(t, err, expected)
+	}
+}
+
+func TestVersion_IsPreRelease(t *testing.T) {
+	for _, version := range []string{"1.0-alpha", "1.0-beta-2", "1.0a1", "5.0.0-M1", "2.0.0-RC1", "1.0-cr3", "1.0-SNAPSHOT"} {
+		assert.True(t, ParseVersion(version).IsPreRelease(), version)
+	}
+	for _, version := range []string{"1.0", "1.0-final", "32.1.2-jre", "1.0-sp1", "1.0.Final", "2.0-m"} {
+		assert.False(t, ParseVersion(version).IsPreRelease(), version)
+	}
+}
+
+func TestVersion_Numbers(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3}, ParseVersion("1.2.3").Numbers())
+	assert.Equal(t, []int{32, 1, 2}, ParseVersion("32.1.2-jre").Numbers())
+	assert.Equal(t, []int{5, 0}, ParseVersion("5.0-M1").Numbers())
+	assert.Equal(t, []int{}, ParseVersion("r05").Numbers())
+}
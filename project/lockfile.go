@@ -0,0 +1,46 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const LockFilename = "jb-lock.json"
+
+// LockFileJSON pins the fully resolved dependency graph of a module so that builds are reproducible
+// and changes to the graph show up in code review.
+type LockFileJSON struct {
+	Dependencies []string            `json:"dependencies"` // declared dependencies the graph was resolved from
+	Packages     []LockedPackageJSON `json:"packages"`     // every resolved package, nearest first
+}
+
+type LockedPackageJSON struct {
+	Coordinates string `json:"gav"`
+	Parent      string `json:"parent,omitempty"`     // gav of the package that pulled this one in, empty if declared directly
+	Scope       string `json:"scope,omitempty"`      // effective scope, empty for compile
+	JarSHA256   string `json:"jar_sha256,omitempty"` // empty for packaging=pom
+	POMSHA256   string `json:"pom_sha256"`
+}
+
+func loadLockFile(data []byte) (*LockFileJSON, error) {
+	lock := &LockFileJSON{}
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("invalid lock file: %w", err)
+	}
+	if lock.Dependencies == nil {
+		lock.Dependencies = []string{}
+	}
+	if lock.Packages == nil {
+		lock.Packages = []LockedPackageJSON{}
+	}
+	return lock, nil
+}
+
+// WriteLockFile saves the lock file to the given path.
+func WriteLockFile(path string, lock *LockFileJSON) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize lock file: %w", err)
+	}
+	return WriteFile(path, string(data)+"\n")
+}
@@ -0,0 +1,59 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LockFilename)
+	lock := &LockFileJSON{
+		Dependencies: []string{"org.app:a:1.0"},
+		Packages: []LockedPackageJSON{
+			{Coordinates: "org.app:a:1.0", JarSHA256: "aa", POMSHA256: "bb"},
+			{Coordinates: "org.lib:parent:1.0", Parent: "org.app:a:1.0", POMSHA256: "cc"},
+		},
+	}
+
+	require.NoError(t, WriteLockFile(path, lock))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	loaded, err := loadLockFile(data)
+	require.NoError(t, err)
+	assert.Equal(t, lock, loaded)
+	assert.NotContains(t, string(data), `"jar_sha256": ""`)
+}
+
+func TestLoadLockFile(t *testing.T) {
+	lock, err := loadLockFile([]byte(`{}`))
+	require.NoError(t, err)
+	assert.Empty(t, lock.Dependencies)
+	assert.NotNil(t, lock.Packages)
+
+	_, err = loadLockFile([]byte(`{"packages": 1}`))
+	assert.Error(t, err)
+}
+
+func TestModuleLoader_GetModule_WithLockFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ModuleFilename), []byte(`{"dependencies": ["org.app:a:1.0"]}`), 0644))
+
+	module, err := NewModuleLoader().GetModule(dir)
+	require.NoError(t, err)
+	assert.Nil(t, module.Lock)
+	assert.Nil(t, module.LockFileBytes)
+
+	lockJSON := `{"dependencies": ["org.app:a:1.0"], "packages": [{"gav": "org.app:a:1.0", "pom_sha256": "bb"}]}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, LockFilename), []byte(lockJSON), 0644))
+
+	module, err = NewModuleLoader().GetModule(dir)
+	require.NoError(t, err)
+	require.NotNil(t, module.Lock)
+	assert.Equal(t, []byte(lockJSON), module.LockFileBytes)
+	assert.Equal(t, "org.app:a:1.0", module.Lock.Packages[0].Coordinates)
+}
@@ -8,7 +8,10 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+
+	"github.com/jsando/jb/maven"
 )
 
 const ModuleFilename = "jb-module.json"
@@ -17,26 +20,51 @@ const DefaultGroupID = "com.example"
 const DefaultVersion = "1.0.0-snapshot"
 
 type ModuleFileJSON struct {
-	Group        string   `json:"group,omitempty"`
-	Version      string   `json:"version,omitempty"`
-	SourceDir    string   `json:"source_dir,omitempty"`
-	ResourcesDir string   `json:"resources_dir,omitempty"`
-	CompileArgs  []string `json:"javac_args,omitempty"`
-	OutputType   string   `json:"output_type,omitempty"`
-	MainClass    string   `json:"main_class,omitempty"`
-	Resources    []string `json:"resources,omitempty"`
-	References   []string `json:"references,omitempty"`
-	Dependencies []string `json:"dependencies,omitempty"`
+	Group                   string            `json:"group,omitempty"`
+	Version                 string            `json:"version,omitempty"`
+	SourceDir               string            `json:"source_dir,omitempty"`
+	TestSourceDir           string            `json:"test_source_dir,omitempty"` // compiled apart from the main sources, against test dependencies
+	ResourcesDir            string            `json:"resources_dir,omitempty"`
+	CompileArgs             []string          `json:"javac_args,omitempty"`
+	OutputType              string            `json:"output_type,omitempty"`
+	MainClass               string            `json:"main_class,omitempty"`
+	SourcesJar              bool              `json:"sources_jar,omitempty"`  // also build name-version-sources.jar
+	JavadocJar              bool              `json:"javadoc_jar,omitempty"`  // also build name-version-javadoc.jar
+	JavadocArgs             []string          `json:"javadoc_args,omitempty"` // extra arguments for the javadoc tool
+	Description             string            `json:"description,omitempty"`
+	URL                     string            `json:"url,omitempty"` // project home page
+	Licenses                []maven.License   `json:"licenses,omitempty"`
+	Developers              []maven.Developer `json:"developers,omitempty"`
+	SCM                     *maven.SCM        `json:"scm,omitempty"`
+	Resources               []string          `json:"resources,omitempty"`
+	References              []string          `json:"references,omitempty"`
+	Dependencies            []string          `json:"dependencies,omitempty"`              // compile scope
+	TestDependencies        []string          `json:"test_dependencies,omitempty"`         // only for compiling and running tests
+	RuntimeDependencies     []string          `json:"runtime_dependencies,omitempty"`      // only for running and tests
+	ProvidedDependencies    []string          `json:"provided_dependencies,omitempty"`     // supplied by the runtime environment
+	CompileOnlyDependencies []string          `json:"compile_only_dependencies,omitempty"` // only for compiling, not published
+	OptionalDependencies    []string          `json:"optional_dependencies,omitempty"`     // compile scope, but not passed on to users of the module
+	Platforms               []string          `json:"platforms,omitempty"`                 // BOMs whose dependency management sets versions
 }
 
+// Dependency scopes. Compile scope is the empty string.
+const (
+	ScopeTest        = "test"         // test classpath
+	ScopeRuntime     = "runtime"      // runtime and test classpaths
+	ScopeProvided    = "provided"     // compile and test classpaths
+	ScopeCompileOnly = "compile_only" // compile classpath, left out of the POM
+)
+
 type Resource struct {
 	Dir     string `json:"dir,omitempty"`
 	Include string `json:"include,omitempty"`
 }
 
 type ProjectFileJSON struct {
-	Name    string   `json:"name"`
-	Modules []string `json:"modules"`
+	Name         string                   `json:"name"`
+	Modules      []string                 `json:"modules"`
+	Repositories []maven.RepositoryConfig `json:"repositories,omitempty"`
+	Platforms    []string                 `json:"platforms,omitempty"` // BOMs imported by every module
 }
 
 type BuildLog interface {
@@ -56,19 +84,32 @@ type TaskLog interface {
 }
 
 type Module struct {
-	ModuleFileBytes []byte // to compute hash for up-to-date check
-	ModuleDirAbs    string
-	SourceDirAbs    string
-	ResourceDirAbs  string
-	Group           string
-	Name            string
-	Version         string
-	CompileArgs     []string
-	OutputType      string
-	MainClass       string
-	Resources       []string
-	References      []*Module
-	Dependencies    []*Dependency
+	ModuleFileBytes  []byte // to compute hash for up-to-date check
+	LockFileBytes    []byte // nil if the module has no lock file
+	ProjectFileBytes []byte // nil unless loaded as part of a project, whose platforms and repositories apply to it
+	ModuleDirAbs     string
+	SourceDirAbs     string
+	TestSourceDirAbs string // empty if the module has no separate test sources
+	ResourceDirAbs   string
+	Group            string
+	Name             string
+	Version          string
+	CompileArgs      []string
+	OutputType       string
+	MainClass        string
+	SourcesJar       bool
+	JavadocJar       bool
+	JavadocArgs      []string
+	Description      string
+	URL              string
+	Licenses         []maven.License
+	Developers       []maven.Developer
+	SCM              *maven.SCM
+	Resources        []string
+	References       []*Module
+	Dependencies     []*Dependency
+	Platforms        []*Dependency // BOMs imported by the module and then the project, in order
+	Lock             *LockFileJSON // nil if the module has no lock file
 }
 
 type Dependency struct {
@@ -76,14 +117,72 @@ type Dependency struct {
 	Group       string        // maven organization id
 	Artifact    string        // maven artifact id
 	Version     string        // maven version string
+	Classifier  string        // selects a secondary artifact such as "natives-linux", empty for the main one
+	Type        string        // packaging type such as "test-jar" or "zip", empty for jar
+	Requested   string        // version range, LATEST or RELEASE that Version was resolved from, if any
 	Path        string        // empty unless resolved, path to cache folder containing artifacts (pom, jar)
+	Scope       string        // scope declared by the module file or POM, empty for compile
+	Optional    bool          // true if a POM marks the dependency optional
+	Exclusions  []Exclusion   // transitive dependencies to leave out of the graph below this one
 	Transitive  []*Dependency // nil unless resolved
+	Skipped     []*Dependency // transitive dependencies left out for their scope or because they are optional
+}
+
+// Exclusion identifies transitive dependencies to drop, either part can be "*" to match anything.
+type Exclusion struct {
+	Group    string
+	Artifact string
+}
+
+// DeclaredVersion returns the version as declared, which is a range rather than the resolved
+// version if a range was given.
+func (d *Dependency) DeclaredVersion() string {
+	if d.Requested != "" {
+		return d.Requested
+	}
+	return d.Version
+}
+
+// ResolvedCoordinates returns the coordinates of the resolved version, including the classifier
+// and type, in the form ParseCoordinates accepts.
+func (d *Dependency) ResolvedCoordinates() string {
+	return d.coordinates(d.Version)
+}
+
+func (d *Dependency) coordinates(version string) string {
+	s := d.Group + ":" + d.Artifact
+	if version != "" {
+		s += ":" + version
+	}
+	if d.Classifier != "" {
+		s += ":" + d.Classifier
+	}
+	if d.Type != "" {
+		s += "@" + d.Type
+	}
+	return s
+}
+
+// String returns the dependency as it would be written in a module file.
+func (d *Dependency) String() string {
+	s := d.coordinates(d.DeclaredVersion())
+	for _, exclusion := range d.Exclusions {
+		s += " !" + exclusion.Group + ":" + exclusion.Artifact
+	}
+	return s
+}
+
+// Matches returns true if the exclusion applies to the given dependency.
+func (e Exclusion) Matches(dep *Dependency) bool {
+	return (e.Group == "*" || e.Group == dep.Group) && (e.Artifact == "*" || e.Artifact == dep.Artifact)
 }
 
 type Project struct {
 	ProjectDirAbs string
 	Name          string
 	Modules       []*Module
+	Repositories  []maven.RepositoryConfig
+	Platforms     []*Dependency
 }
 
 type ModuleLoader struct {
@@ -213,6 +312,11 @@ func (l *ModuleLoader) internalLoadProject(projectPath string) (*Project, error)
 		ProjectDirAbs: filepath.Dir(projectPath),
 		Name:          projectJSON.Name,
 		Modules:       make([]*Module, 0),
+		Repositories:  projectJSON.Repositories,
+	}
+	project.Platforms, err = parsePlatforms(projectJSON.Platforms)
+	if err != nil {
+		return nil, err
 	}
 	for _, modulePath := range projectJSON.Modules {
 		modulePath := filepath.Join(project.ProjectDirAbs, modulePath)
@@ -220,6 +324,10 @@ func (l *ModuleLoader) internalLoadProject(projectPath string) (*Project, error)
 		if err != nil {
 			return nil, err
 		}
+		// the module's platforms come first so they win over the project's, as a child's
+		// dependency management wins over its parent's in maven
+		module.Platforms = append(slices.Clone(module.Platforms), project.Platforms...)
+		module.ProjectFileBytes = data
 		project.Modules = append(project.Modules, module)
 	}
 
@@ -265,6 +373,9 @@ func (l *ModuleLoader) GetModule(modulePath string) (*Module, error) {
 	module.ModuleFileBytes = data
 	module.ModuleDirAbs = filepath.Dir(modulePath)
 	module.SourceDirAbs = filepath.Join(module.ModuleDirAbs, moduleFile.SourceDir)
+	if moduleFile.TestSourceDir != "" {
+		module.TestSourceDirAbs = filepath.Join(module.ModuleDirAbs, moduleFile.TestSourceDir)
+	}
 	module.ResourceDirAbs = filepath.Join(module.ModuleDirAbs, moduleFile.ResourcesDir)
 	module.Group = moduleFile.Group
 	module.Name = filepath.Base(module.ModuleDirAbs)
@@ -272,15 +383,36 @@ func (l *ModuleLoader) GetModule(modulePath string) (*Module, error) {
 	module.CompileArgs = moduleFile.CompileArgs
 	module.OutputType = moduleFile.OutputType
 	module.MainClass = moduleFile.MainClass
+	module.SourcesJar = moduleFile.SourcesJar
+	module.JavadocJar = moduleFile.JavadocJar
+	module.JavadocArgs = moduleFile.JavadocArgs
+	module.Description = moduleFile.Description
+	module.URL = moduleFile.URL
+	module.Licenses = moduleFile.Licenses
+	module.Developers = moduleFile.Developers
+	module.SCM = moduleFile.SCM
+	module.Platforms, err = parsePlatforms(moduleFile.Platforms)
+	if err != nil {
+		return nil, err
+	}
 	module.Resources = moduleFile.Resources
 
-	module.Dependencies = make([]*Dependency, len(moduleFile.Dependencies))
-	for i, s := range moduleFile.Dependencies {
-		dep, err := ParseCoordinates(s)
+	module.Dependencies, err = ParseDependencyLists(moduleFile.dependencyLists())
+	if err != nil {
+		return nil, err
+	}
+
+	// load the lock file if there is one
+	lockPath := filepath.Join(module.ModuleDirAbs, LockFilename)
+	if FileExists(lockPath) {
+		module.LockFileBytes, err = readFile(lockPath)
 		if err != nil {
 			return nil, err
 		}
-		module.Dependencies[i] = dep
+		module.Lock, err = loadLockFile(module.LockFileBytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", lockPath, err)
+		}
 	}
 
 	// save new module to cache before recursively loading references to other modules
@@ -303,23 +435,108 @@ func (l *ModuleLoader) GetModule(modulePath string) (*Module, error) {
 	return module, nil
 }
 
+const coordinatesForm = "<group>:<artifact>:<version>[:<classifier>][@<type>]"
+
+// ParseCoordinates parses maven coordinates, optionally with a classifier and a type:
+//
+//	"org.lwjgl:lwjgl:3.3.4:natives-linux"
+//	"com.example:lib:1.0@test-jar"
 func ParseCoordinates(gav string) (*Dependency, error) {
-	parts := strings.Split(gav, ":")
-	if len(parts) != 3 {
-		return nil, fmt.Errorf("invalid dependency '%s', must be in the form <group>:<artifact>:<version>", gav)
+	coordinates, typ, hasType := strings.Cut(gav, "@")
+	parts := strings.Split(coordinates, ":")
+	if len(parts) != 3 && len(parts) != 4 {
+		return nil, fmt.Errorf("invalid dependency '%s', must be in the form %s", gav, coordinatesForm)
 	}
 	dep := &Dependency{
 		Coordinates: gav,
 		Group:       parts[0],
 		Artifact:    parts[1],
 		Version:     parts[2],
+		Type:        typ,
+	}
+	if len(parts) == 4 {
+		dep.Classifier = parts[3]
+	}
+	if dep.Group == "" || dep.Artifact == "" || dep.Version == "" || (len(parts) == 4 && dep.Classifier == "") || (hasType && dep.Type == "") {
+		return nil, fmt.Errorf("invalid dependency '%s', must be in the form %s", gav, coordinatesForm)
 	}
-	if dep.Group == "" || dep.Artifact == "" || dep.Version == "" {
-		return nil, fmt.Errorf("invalid dependency '%s', must be in the form <group>:<artifact>:<version>", gav)
+	if dep.Type == "jar" {
+		// the default, leave it out so that "@jar" and no type are the same dependency
+		dep.Type = ""
+	}
+	if err := maven.ValidateVersion(dep.Version); err != nil {
+		return nil, fmt.Errorf("invalid dependency '%s': %w", gav, err)
 	}
 	return dep, nil
 }
 
+// ParseDependency parses a dependency as written in a module file: maven coordinates optionally
+// followed by exclusions, each written as "!group:artifact" where either part can be "*". The
+// version can be left out if one of the module's platforms manages it.
+//
+//	"org.apache.httpcomponents:httpclient:4.5.14 !commons-logging:commons-logging"
+//	"com.fasterxml.jackson.core:jackson-databind"
+func ParseDependency(spec string) (*Dependency, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("invalid dependency '%s', must be in the form %s", spec, coordinatesForm)
+	}
+	var dep *Dependency
+	var err error
+	if coordinates, _, _ := strings.Cut(fields[0], "@"); strings.Count(coordinates, ":") == 1 {
+		dep, err = parseManagedCoordinates(fields[0])
+	} else {
+		dep, err = ParseCoordinates(fields[0])
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range fields[1:] {
+		exclusion, err := parseExclusion(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dependency '%s': %w", spec, err)
+		}
+		dep.Exclusions = append(dep.Exclusions, exclusion)
+	}
+	return dep, nil
+}
+
+// parseManagedCoordinates parses "group:artifact[@type]", a dependency whose version is set by a
+// platform.
+func parseManagedCoordinates(ga string) (*Dependency, error) {
+	coordinates, typ, hasType := strings.Cut(ga, "@")
+	group, artifact, _ := strings.Cut(coordinates, ":")
+	if group == "" || artifact == "" || (hasType && typ == "") {
+		return nil, fmt.Errorf("invalid dependency '%s', must be in the form %s", ga, coordinatesForm)
+	}
+	if typ == "jar" {
+		typ = ""
+	}
+	return &Dependency{Coordinates: ga, Group: group, Artifact: artifact, Type: typ}, nil
+}
+
+// parsePlatforms parses the coordinates of BOMs, which must have a version.
+func parsePlatforms(specs []string) ([]*Dependency, error) {
+	platforms := make([]*Dependency, 0, len(specs))
+	for _, spec := range specs {
+		platform, err := ParseCoordinates(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid platform: %w", err)
+		}
+		platforms = append(platforms, platform)
+	}
+	return platforms, nil
+}
+
+func parseExclusion(s string) (Exclusion, error) {
+	pattern, found := strings.CutPrefix(s, "!")
+	parts := strings.Split(pattern, ":")
+	if !found || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return Exclusion{}, fmt.Errorf("invalid exclusion '%s', must be in the form !<group>:<artifact>", s)
+	}
+	return Exclusion{Group: parts[0], Artifact: parts[1]}, nil
+}
+
 // If given a folder, find the module file in it
 func getModuleFilePath(modulePath string) (string, error) {
 	moduleFilePath := modulePath
@@ -393,9 +610,15 @@ func loadModuleFile(data []byte) (*ModuleFileJSON, error) {
 	return m, nil
 }
 
+// HashContent hashes the files that configure the module: its module file, lock file and the
+// project file, so that a change to any of them makes the module out of date.
 func (m *Module) HashContent(hasher hash.Hash) error {
-	_, err := hasher.Write(m.ModuleFileBytes)
-	return err
+	for _, data := range [][]byte{m.ModuleFileBytes, m.LockFileBytes, m.ProjectFileBytes} {
+		if _, err := hasher.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // GetModuleReferencesInBuildOrder returns the list of referenced modules this module depends on,
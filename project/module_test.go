@@ -1,11 +1,14 @@
 package project
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/jsando/jb/maven"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -35,9 +38,52 @@ func TestParseCoordinates(t *testing.T) {
 			},
 		},
 		{
-			name:        "valid with classifier",
-			input:       "org.example:lib:1.0.0:tests",
-			expectError: true, // ParseCoordinates only accepts 3 parts
+			name:  "valid with classifier",
+			input: "org.example:lib:1.0.0:tests",
+			expected: &Dependency{
+				Coordinates: "org.example:lib:1.0.0:tests",
+				Group:       "org.example",
+				Artifact:    "lib",
+				Version:     "1.0.0",
+				Classifier:  "tests",
+			},
+		},
+		{
+			name:  "valid with classifier and type",
+			input: "org.example:lib:1.0.0:dist@zip",
+			expected: &Dependency{
+				Coordinates: "org.example:lib:1.0.0:dist@zip",
+				Group:       "org.example",
+				Artifact:    "lib",
+				Version:     "1.0.0",
+				Classifier:  "dist",
+				Type:        "zip",
+			},
+		},
+		{
+			name:  "jar type is the default",
+			input: "org.example:lib:1.0.0@jar",
+			expected: &Dependency{
+				Coordinates: "org.example:lib:1.0.0@jar",
+				Group:       "org.example",
+				Artifact:    "lib",
+				Version:     "1.0.0",
+			},
+		},
+		{
+			name:        "empty classifier",
+			input:       "org.example:lib:1.0.0:",
+			expectError: true,
+		},
+		{
+			name:        "empty type",
+			input:       "org.example:lib:1.0.0@",
+			expectError: true,
+		},
+		{
+			name:        "too many parts",
+			input:       "org.example:lib:1.0.0:tests:extra",
+			expectError: true,
 		},
 		{
 			name:        "missing version",
@@ -74,6 +120,28 @@ func TestParseCoordinates(t *testing.T) {
 	}
 }
 
+func TestParseDependency(t *testing.T) {
+	dep, err := ParseDependency("org.apache.httpcomponents:httpclient:4.5.14 !commons-logging:commons-logging !*:log4j")
+	require.NoError(t, err)
+	assert.Equal(t, "org.apache.httpcomponents:httpclient:4.5.14", dep.Coordinates)
+	assert.Equal(t, "httpclient", dep.Artifact)
+	assert.Equal(t, []Exclusion{
+		{Group: "commons-logging", Artifact: "commons-logging"},
+		{Group: "*", Artifact: "log4j"},
+	}, dep.Exclusions)
+	assert.True(t, dep.Exclusions[1].Matches(&Dependency{Group: "log4j", Artifact: "log4j"}))
+	assert.False(t, dep.Exclusions[0].Matches(&Dependency{Group: "commons-logging", Artifact: "commons-io"}))
+
+	dep, err = ParseDependency("org.junit:junit:4.13.2")
+	require.NoError(t, err)
+	assert.Nil(t, dep.Exclusions)
+
+	for _, invalid := range []string{"", "org.junit:junit:4.13.2 commons-logging:commons-logging", "org.junit:junit:4.13.2 !commons-logging", "org.junit:junit:4.13.2 !:x"} {
+		_, err = ParseDependency(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
 func TestLoadModuleFile(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -423,6 +491,25 @@ func TestModule_HashContent(t *testing.T) {
 	assert.Equal(t, []byte("test module content"), hasher.data)
 }
 
+func TestModule_HashContent_ProjectFile(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "app"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "app", ModuleFilename), []byte(`{}`), 0644))
+	hash := func(projectFile string) string {
+		require.NoError(t, os.WriteFile(filepath.Join(projectDir, ProjectFilename), []byte(projectFile), 0644))
+		project, _, err := NewModuleLoader().LoadProject(projectDir)
+		require.NoError(t, err)
+		hasher := sha1.New()
+		require.NoError(t, project.Modules[0].HashContent(hasher))
+		return hex.EncodeToString(hasher.Sum(nil))
+	}
+
+	// a new version of a project platform makes the module out of date
+	before := hash(`{"modules": ["app"], "platforms": ["org.platform:bom:1.0"]}`)
+	assert.NotEqual(t, before, hash(`{"modules": ["app"], "platforms": ["org.platform:bom:2.0"]}`))
+	assert.Equal(t, before, hash(`{"modules": ["app"], "platforms": ["org.platform:bom:1.0"]}`))
+}
+
 func TestModule_GetModuleReferencesInBuildOrder(t *testing.T) {
 	// Test normal case without circular references
 	t.Run("normal dependencies", func(t *testing.T) {
@@ -723,3 +810,146 @@ func TestModule_NilChecks(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "module is nil")
 }
+
+func TestParseCoordinates_VersionRanges(t *testing.T) {
+	dep, err := ParseCoordinates("org.lib:common:[1.2,2.0)")
+	require.NoError(t, err)
+	assert.Equal(t, "[1.2,2.0)", dep.Version)
+
+	_, err = ParseCoordinates("org.lib:common:[2.0,1.2)")
+	assert.EqualError(t, err, "invalid dependency 'org.lib:common:[2.0,1.2)': invalid version range '[2.0,1.2)', [2.0,1.2) defies version ordering")
+
+	dep.Requested = dep.Version
+	dep.Version = "1.5"
+	assert.Equal(t, "[1.2,2.0)", dep.DeclaredVersion())
+	assert.Equal(t, "org.lib:common:[1.2,2.0)", dep.String())
+}
+
+func TestDependency_ClassifierAndType(t *testing.T) {
+	dep, err := ParseDependency("org.lwjgl:lwjgl:3.3.4:natives-linux !org.lib:*")
+	require.NoError(t, err)
+	assert.Equal(t, "natives-linux", dep.Classifier)
+	assert.Equal(t, "org.lwjgl:lwjgl:3.3.4:natives-linux !org.lib:*", dep.String())
+
+	dep, err = ParseCoordinates("com.example:lib:[1.0,2.0)@test-jar")
+	require.NoError(t, err)
+	dep.Requested = dep.Version
+	dep.Version = "1.5"
+	assert.Equal(t, "com.example:lib:[1.0,2.0)@test-jar", dep.String())
+	assert.Equal(t, "com.example:lib:1.5@test-jar", dep.ResolvedCoordinates())
+}
+
+func TestModuleLoader_GetModule_Scopes(t *testing.T) {
+	moduleDir := filepath.Join(t.TempDir(), "app")
+	require.NoError(t, os.MkdirAll(moduleDir, 0755))
+	moduleData := `{
+		"group": "com.example",
+		"version": "1.0.0",
+		"dependencies": ["org.lib:common:1.0"],
+		"test_dependencies": ["junit:junit:4.13.2"],
+		"runtime_dependencies": ["org.postgresql:postgresql:42.7.4"],
+		"provided_dependencies": ["jakarta.servlet:jakarta.servlet-api:6.0.0"],
+		"compile_only_dependencies": ["org.projectlombok:lombok:1.18.34"]
+	}`
+	moduleFile := filepath.Join(moduleDir, ModuleFilename)
+	require.NoError(t, os.WriteFile(moduleFile, []byte(moduleData), 0644))
+
+	module, err := NewModuleLoader().GetModule(moduleFile)
+	require.NoError(t, err)
+
+	scopes := make(map[string]string)
+	for _, dep := range module.Dependencies {
+		scopes[dep.Artifact] = dep.Scope
+	}
+	assert.Equal(t, map[string]string{
+		"common":              "",
+		"junit":               ScopeTest,
+		"postgresql":          ScopeRuntime,
+		"jakarta.servlet-api": ScopeProvided,
+		"lombok":              ScopeCompileOnly,
+	}, scopes)
+}
+
+func TestModuleLoader_GetModule_POMMetadata(t *testing.T) {
+	moduleDir := filepath.Join(t.TempDir(), "app")
+	require.NoError(t, os.MkdirAll(moduleDir, 0755))
+	moduleData := `{
+		"group": "com.example",
+		"version": "1.0.0",
+		"description": "An example application",
+		"url": "https://example.com/app",
+		"licenses": [{"name": "Apache-2.0", "url": "https://www.apache.org/licenses/LICENSE-2.0.txt"}],
+		"developers": [{"id": "jdoe", "name": "Jane Doe", "organization_url": "https://example.com"}],
+		"scm": {"url": "https://github.com/example/app", "developer_connection": "scm:git:ssh://git@github.com/example/app.git"},
+		"optional_dependencies": ["com.google.code.gson:gson:2.11.0"]
+	}`
+	moduleFile := filepath.Join(moduleDir, ModuleFilename)
+	require.NoError(t, os.WriteFile(moduleFile, []byte(moduleData), 0644))
+
+	module, err := NewModuleLoader().GetModule(moduleFile)
+	require.NoError(t, err)
+
+	assert.Equal(t, "An example application", module.Description)
+	assert.Equal(t, "https://example.com/app", module.URL)
+	assert.Equal(t, []maven.License{{Name: "Apache-2.0", URL: "https://www.apache.org/licenses/LICENSE-2.0.txt"}}, module.Licenses)
+	assert.Equal(t, []maven.Developer{{ID: "jdoe", Name: "Jane Doe", OrganizationURL: "https://example.com"}}, module.Developers)
+	assert.Equal(t, &maven.SCM{URL: "https://github.com/example/app", DeveloperConnection: "scm:git:ssh://git@github.com/example/app.git"}, module.SCM)
+	require.Len(t, module.Dependencies, 1)
+	assert.True(t, module.Dependencies[0].Optional)
+	assert.Equal(t, "", module.Dependencies[0].Scope)
+}
+
+func TestParseDependency_WithoutVersion(t *testing.T) {
+	dep, err := ParseDependency("com.fasterxml.jackson.core:jackson-databind@jar !org.x:y")
+	require.NoError(t, err)
+	assert.Equal(t, "com.fasterxml.jackson.core", dep.Group)
+	assert.Equal(t, "jackson-databind", dep.Artifact)
+	assert.Empty(t, dep.Version)
+	assert.Empty(t, dep.Type)
+	assert.Equal(t, "com.fasterxml.jackson.core:jackson-databind !org.x:y", dep.String())
+
+	_, err = ParseDependency("com.example:")
+	assert.Error(t, err)
+}
+
+func TestModuleLoader_LoadProject_Platforms(t *testing.T) {
+	projectDir := t.TempDir()
+	moduleDir := filepath.Join(projectDir, "app")
+	require.NoError(t, os.MkdirAll(moduleDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ProjectFilename),
+		[]byte(`{"name": "example", "modules": ["app"], "platforms": ["org.springframework.boot:spring-boot-dependencies:3.3.4"]}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(moduleDir, ModuleFilename),
+		[]byte(`{"platforms": ["com.fasterxml.jackson:jackson-bom:2.17.2"], "dependencies": ["com.fasterxml.jackson.core:jackson-databind"]}`), 0644))
+
+	project, _, err := NewModuleLoader().LoadProject(projectDir)
+	require.NoError(t, err)
+	require.Len(t, project.Platforms, 1)
+	module := project.Modules[0]
+	platforms := make([]string, 0)
+	for _, platform := range module.Platforms {
+		platforms = append(platforms, platform.Coordinates)
+	}
+	assert.Equal(t, []string{"com.fasterxml.jackson:jackson-bom:2.17.2", "org.springframework.boot:spring-boot-dependencies:3.3.4"}, platforms)
+	assert.Empty(t, module.Dependencies[0].Version)
+
+	require.NoError(t, os.WriteFile(filepath.Join(moduleDir, ModuleFilename), []byte(`{"platforms": ["com.fasterxml.jackson:jackson-bom"]}`), 0644))
+	_, err = NewModuleLoader().GetModule(filepath.Join(moduleDir, ModuleFilename))
+	assert.ErrorContains(t, err, "invalid platform")
+}
+
+func TestModuleLoader_GetModule_TestSourceDir(t *testing.T) {
+	moduleDir := filepath.Join(t.TempDir(), "app")
+	require.NoError(t, os.MkdirAll(moduleDir, 0755))
+	moduleFile := filepath.Join(moduleDir, ModuleFilename)
+	require.NoError(t, os.WriteFile(moduleFile, []byte(`{"source_dir": "src/main/java", "test_source_dir": "src/test/java"}`), 0644))
+
+	module, err := NewModuleLoader().GetModule(moduleFile)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(moduleDir, "src", "test", "java"), module.TestSourceDirAbs)
+
+	// without one, there are no separate test sources
+	require.NoError(t, os.WriteFile(moduleFile, []byte(`{}`), 0644))
+	module, err = NewModuleLoader().GetModule(moduleFile)
+	require.NoError(t, err)
+	assert.Empty(t, module.TestSourceDirAbs)
+}
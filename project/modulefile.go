@@ -0,0 +1,225 @@
+package project
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// dependencyLists are the keys of the module file that list dependencies, with the scope they
+// give them, in the order the module's dependencies are loaded.
+var dependencyLists = []struct {
+	key      string
+	scope    string
+	optional bool
+}{
+	{"dependencies", "", false},
+	{"optional_dependencies", "", true},
+	{"test_dependencies", ScopeTest, false},
+	{"runtime_dependencies", ScopeRuntime, false},
+	{"provided_dependencies", ScopeProvided, false},
+	{"compile_only_dependencies", ScopeCompileOnly, false},
+}
+
+// dependencyLists returns the dependencies of each list in the module file by key.
+func (f *ModuleFileJSON) dependencyLists() map[string][]string {
+	return map[string][]string{
+		"dependencies":              f.Dependencies,
+		"optional_dependencies":     f.OptionalDependencies,
+		"test_dependencies":         f.TestDependencies,
+		"runtime_dependencies":      f.RuntimeDependencies,
+		"provided_dependencies":     f.ProvidedDependencies,
+		"compile_only_dependencies": f.CompileOnlyDependencies,
+	}
+}
+
+// DependencyKeys returns the keys of the module file that list dependencies, compile scope first.
+func DependencyKeys() []string {
+	keys := make([]string, len(dependencyLists))
+	for i, list := range dependencyLists {
+		keys[i] = list.key
+	}
+	return keys
+}
+
+// DependencyKey returns the key of the module file that lists dependencies of scope, which is
+// compile (or empty), optional, test, runtime, provided or compile_only.
+func DependencyKey(scope string) (string, error) {
+	if scope == "" || scope == "compile" {
+		return "dependencies", nil
+	}
+	key := scope + "_dependencies"
+	if !slices.Contains(DependencyKeys(), key) {
+		return "", fmt.Errorf("invalid scope '%s', must be compile, optional, %s, %s, %s or %s", scope, ScopeTest, ScopeRuntime, ScopeProvided, ScopeCompileOnly)
+	}
+	return key, nil
+}
+
+// ParseDependencyLists parses the dependencies of each list, by module file key, giving each the
+// scope of its list.
+func ParseDependencyLists(lists map[string][]string) ([]*Dependency, error) {
+	deps := make([]*Dependency, 0)
+	for _, list := range dependencyLists {
+		for _, s := range lists[list.key] {
+			dep, err := ParseDependency(s)
+			if err != nil {
+				return nil, err
+			}
+			dep.Scope = list.scope
+			dep.Optional = list.optional
+			deps = append(deps, dep)
+		}
+	}
+	return deps, nil
+}
+
+// DeclaredDependencies returns the dependencies exactly as they are written in the module file,
+// by the key of the list they are in. Lists missing from the file are empty.
+func (m *Module) DeclaredDependencies() (map[string][]string, error) {
+	moduleFile := &ModuleFileJSON{}
+	if err := json.Unmarshal(m.ModuleFileBytes, moduleFile); err != nil {
+		return nil, err
+	}
+	lists := moduleFile.dependencyLists()
+	for key, deps := range lists {
+		if deps == nil {
+			lists[key] = []string{}
+		}
+	}
+	return lists, nil
+}
+
+// SetDependencies returns the module file with its dependency list under key, such as
+// "dependencies" or "test_dependencies", replaced, leaving every other byte as it was so that
+// key order, indentation and formatting survive the edit. The array keeps its layout of one
+// dependency per line or all on one line. If the file has no such list yet it is added as the
+// last key.
+func SetDependencies(data []byte, key string, deps []string) ([]byte, error) {
+	object, err := scanObject(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid module file: %w", err)
+	}
+	multiline := bytes.ContainsRune(data, '\n')
+	unit := indentUnit(data)
+	value, found := object.values[key]
+	if !found {
+		var sb strings.Builder
+		if len(bytes.TrimSpace(data[object.open+1:object.close])) > 0 {
+			sb.WriteString(",")
+			if !multiline {
+				sb.WriteString(" ")
+			}
+		}
+		if multiline {
+			sb.WriteString("\n" + unit)
+		}
+		sb.WriteString(strconv.Quote(key) + ": ")
+		sb.WriteString(formatArray(deps, multiline, unit, unit+unit))
+		end := bytes.LastIndexFunc(data[:object.close], func(r rune) bool { return !isSpace(r) }) + 1
+		return concat(data[:end], []byte(sb.String()), data[end:]), nil
+	}
+	original := data[value.start:value.end]
+	keyIndent := lineIndent(data, value.start)
+	elementIndent := keyIndent + unit
+	if bytes.ContainsRune(original, '\n') {
+		if first := bytes.IndexFunc(original[1:], func(r rune) bool { return !isSpace(r) }); first >= 0 && original[1+first] != ']' {
+			elementIndent = lineIndent(data, value.start+1+first)
+		}
+	} else if len(bytes.TrimSpace(original[1:len(original)-1])) > 0 {
+		// written on one line, keep it that way
+		multiline = false
+	}
+	array := formatArray(deps, multiline, keyIndent, elementIndent)
+	return concat(data[:value.start], []byte(array), data[value.end:]), nil
+}
+
+func formatArray(values []string, multiline bool, indent, elementIndent string) string {
+	if len(values) == 0 {
+		return "[]"
+	}
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		data, _ := json.Marshal(value) // strings always marshal
+		quoted[i] = string(data)
+	}
+	if !multiline {
+		return "[" + strings.Join(quoted, ", ") + "]"
+	}
+	return "[\n" + elementIndent + strings.Join(quoted, ",\n"+elementIndent) + "\n" + indent + "]"
+}
+
+// jsonObject locates the braces of a top-level JSON object and the values of its keys.
+type jsonObject struct {
+	open, close int
+	values      map[string]jsonSpan
+}
+
+type jsonSpan struct {
+	start, end int
+}
+
+func scanObject(data []byte) (*jsonObject, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected an object")
+	}
+	object := &jsonObject{open: int(dec.InputOffset()) - 1, values: make(map[string]jsonSpan)}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		// the value starts after the colon following the key
+		start := int(dec.InputOffset())
+		for start < len(data) && (isSpace(rune(data[start])) || data[start] == ':') {
+			start++
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		object.values[key] = jsonSpan{start: start, end: int(dec.InputOffset())}
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	object.close = int(dec.InputOffset()) - 1
+	return object, nil
+}
+
+// indentUnit returns the indentation of the first indented key, or two spaces.
+func indentUnit(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if strings.HasPrefix(trimmed, `"`) && len(trimmed) < len(line) {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return "  "
+}
+
+// lineIndent returns the whitespace at the start of the line containing offset.
+func lineIndent(data []byte, offset int) string {
+	lineStart := bytes.LastIndexByte(data[:offset], '\n') + 1
+	end := lineStart
+	for end < offset && (data[end] == ' ' || data[end] == '\t') {
+		end++
+	}
+	return string(data[lineStart:end])
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
@@ -0,0 +1,161 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetDependencies(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		input    string
+		deps     []string
+		expected string
+	}{
+		{
+			name: "replaces one per line",
+			input: `{
+    "version": "1.0",
+    "dependencies": [
+        "org.junit:junit:4.12"
+    ],
+    "main_class": "Main"
+}
+`,
+			deps: []string{"org.junit:junit:4.13.2", "org.lib:common:1.0 !org.lib:logging"},
+			expected: `{
+    "version": "1.0",
+    "dependencies": [
+        "org.junit:junit:4.13.2",
+        "org.lib:common:1.0 !org.lib:logging"
+    ],
+    "main_class": "Main"
+}
+`,
+		},
+		{
+			name:     "keeps a single line",
+			input:    `{"dependencies": ["a:b:1"], "version": "1.0"}`,
+			deps:     []string{"a:b:2", "c:d:1"},
+			expected: `{"dependencies": ["a:b:2", "c:d:1"], "version": "1.0"}`,
+		},
+		{
+			name: "expands an empty array",
+			input: `{
+	"dependencies": [],
+	"version": "1.0"
+}`,
+			deps: []string{"a:b:1"},
+			expected: `{
+	"dependencies": [
+		"a:b:1"
+	],
+	"version": "1.0"
+}`,
+		},
+		{
+			name: "empties the array",
+			input: `{
+  "dependencies": [
+    "a:b:1"
+  ]
+}`,
+			deps: []string{},
+			expected: `{
+  "dependencies": []
+}`,
+		},
+		{
+			name: "adds the key",
+			input: `{
+  "group": "com.example",
+  "version": "1.0"
+}
+`,
+			deps: []string{"a:b:1"},
+			expected: `{
+  "group": "com.example",
+  "version": "1.0",
+  "dependencies": [
+    "a:b:1"
+  ]
+}
+`,
+		},
+		{
+			name:     "adds the key to an empty object",
+			input:    `{}`,
+			deps:     []string{"a:b:1"},
+			expected: `{"dependencies": ["a:b:1"]}`,
+		},
+		{
+			name:     "adds a scoped key",
+			key:      "test_dependencies",
+			input:    `{"dependencies": ["a:b:1"]}`,
+			deps:     []string{"c:d:1"},
+			expected: `{"dependencies": ["a:b:1"], "test_dependencies": ["c:d:1"]}`,
+		},
+		{
+			name:     "replaces only the scoped key",
+			key:      "test_dependencies",
+			input:    `{"test_dependencies": ["c:d:1"], "dependencies": ["c:d:1"]}`,
+			deps:     []string{"c:d:2"},
+			expected: `{"test_dependencies": ["c:d:2"], "dependencies": ["c:d:1"]}`,
+		},
+		{
+			name:     "ignores nested keys",
+			input:    `{"other": {"dependencies": ["x:y:1"]}, "dependencies": []}`,
+			deps:     []string{"a:b:1"},
+			expected: `{"other": {"dependencies": ["x:y:1"]}, "dependencies": ["a:b:1"]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := tt.key
+			if key == "" {
+				key = "dependencies"
+			}
+			result, err := SetDependencies([]byte(tt.input), key, tt.deps)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(result))
+		})
+	}
+
+	_, err := SetDependencies([]byte(`["a:b:1"]`), "dependencies", nil)
+	assert.EqualError(t, err, "invalid module file: expected an object")
+}
+
+func TestModule_DeclaredDependencies(t *testing.T) {
+	module := &Module{ModuleFileBytes: []byte(`{"dependencies": ["a:b:1  !c:d"], "test_dependencies": ["e:f:1"]}`)}
+	lists, err := module.DeclaredDependencies()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a:b:1  !c:d"}, lists["dependencies"])
+	assert.Equal(t, []string{"e:f:1"}, lists["test_dependencies"])
+	assert.Equal(t, []string{}, lists["provided_dependencies"])
+	assert.Len(t, lists, len(DependencyKeys()))
+
+	deps, err := ParseDependencyLists(lists)
+	require.NoError(t, err)
+	require.Len(t, deps, 2)
+	assert.Equal(t, "", deps[0].Scope)
+	assert.Equal(t, ScopeTest, deps[1].Scope)
+}
+
+func TestDependencyKey(t *testing.T) {
+	for scope, expected := range map[string]string{
+		"":             "dependencies",
+		"compile":      "dependencies",
+		"optional":     "optional_dependencies",
+		"test":         "test_dependencies",
+		"compile_only": "compile_only_dependencies",
+	} {
+		key, err := DependencyKey(scope)
+		require.NoError(t, err)
+		assert.Equal(t, expected, key)
+	}
+	_, err := DependencyKey("system")
+	assert.EqualError(t, err, "invalid scope 'system', must be compile, optional, test, runtime, provided or compile_only")
+}